@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"backend/database"
+	"backend/services/agentclient_logs"
+)
+
+const usage = `usage: go run ./cmd/auditchain <command>
+
+commands:
+  verify      walk the agent-client log hash chain and report breaks (exit 1 if any)
+  seal        seal logs written before the hash chain existed into it`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	database.ConnectDB()
+	defer database.DB.Close()
+
+	if err := database.RequireMigrated(database.DB); err != nil {
+		log.Fatal("❌ ", err)
+	}
+
+	service := agentclient_logs.NewAgentClientLogService(agentclient_logs.NewAgentClientLogRepository(database.DB))
+
+	switch os.Args[1] {
+	case "verify":
+		report, err := service.VerifyChain()
+		if err != nil {
+			log.Fatal("❌ Failed to verify the log chain: ", err)
+		}
+		fmt.Printf("checked %d log(s): %d redacted, %d unsealed, head at log %d\n", report.Checked, report.Redacted, report.Unsealed, report.HeadLogID)
+		for _, chainBreak := range report.Breaks {
+			fmt.Printf("  log %d: %s\n", chainBreak.LogID, chainBreak.Reason)
+		}
+		if !report.Valid {
+			fmt.Printf("❌ %d break(s) in the log chain\n", len(report.Breaks))
+			os.Exit(1)
+		}
+		fmt.Println("✅ Log chain verified")
+
+	case "seal":
+		sealed, err := service.SealLegacy()
+		if err != nil {
+			log.Fatal("❌ Failed to seal legacy logs: ", err)
+		}
+		fmt.Printf("✅ %d legacy log(s) sealed\n", sealed)
+
+	default:
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+}
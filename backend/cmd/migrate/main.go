@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"backend/database"
+)
+
+const usage = `usage: go run ./cmd/migrate <command>
+
+commands:
+  up          apply all pending migrations
+  down [n]    roll back the last n applied migrations (default 1)
+  status      list migrations and whether they are applied`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	database.ConnectDB()
+	defer database.DB.Close()
+
+	migrator, err := database.NewMigrator(database.DB)
+	if err != nil {
+		log.Fatal("❌ Failed to load migrations:", err)
+	}
+
+	switch os.Args[1] {
+	case "up":
+		applied, err := migrator.Up()
+		if err != nil {
+			log.Fatalf("❌ Migrate up stopped after %d migration(s): %v", applied, err)
+		}
+		fmt.Printf("✅ %d migration(s) applied\n", applied)
+
+	case "down":
+		steps := 1
+		if len(os.Args) > 2 {
+			steps, err = strconv.Atoi(os.Args[2])
+			if err != nil || steps < 1 {
+				log.Fatalf("❌ Invalid step count %q", os.Args[2])
+			}
+		}
+		rolledBack, err := migrator.Down(steps)
+		if err != nil {
+			log.Fatalf("❌ Migrate down stopped after %d migration(s): %v", rolledBack, err)
+		}
+		fmt.Printf("✅ %d migration(s) rolled back\n", rolledBack)
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatal("❌ Failed to read migration status:", err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-35s %s\n", status.Version, status.Name, state)
+		}
+
+	default:
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+}
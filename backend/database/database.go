@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/joho/godotenv"
@@ -13,6 +14,13 @@ import (
 // Global DB connection
 var DB *sql.DB
 
+// TimeZone is the zone every session runs in, so CURRENT_TIMESTAMP and the timestamps read back
+// as text are UTC whatever the server's zone. Times compared with them must be converted to it.
+var TimeZone = time.UTC
+
+// sessionParams pins the driver's loc and the session's time_zone to TimeZone
+const sessionParams = "?loc=UTC&time_zone=%27%2B00%3A00%27"
+
 // LoadEnv loads the .env file
 func LoadEnv() {
 	err := godotenv.Load()
@@ -21,7 +29,8 @@ func LoadEnv() {
 	}
 }
 
-// ConnectDB initializes the database and creates tables if needed
+// ConnectDB initializes the database connection, creating the database if needed.
+// Tables are managed by the migrations in database/migrations.
 func ConnectDB() {
 	LoadEnv()
 
@@ -33,7 +42,7 @@ func ConnectDB() {
 	dbName := os.Getenv("DB_NAME")
 
 	// Connection string
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/", dbUser, dbPassword, dbHost, dbPort)
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", dbUser, dbPassword, dbHost, dbPort, sessionParams)
 
 	var err error
 	DB, err = sql.Open("mysql", dsn)
@@ -49,11 +58,11 @@ func ConnectDB() {
 
 	fmt.Println("✅ Connected to MySQL!")
 
-	// Initialize database and tables
+	// Initialize database
 	initializeDatabase(dbName)
 }
 
-// initializeDatabase creates the database and tables if they don't exist
+// initializeDatabase creates the database if it doesn't exist and reconnects to it
 func initializeDatabase(dbName string) {
 	// Create database if it doesn't exist
 	_, err := DB.Exec("CREATE DATABASE IF NOT EXISTS " + dbName)
@@ -65,7 +74,7 @@ func initializeDatabase(dbName string) {
 
 	// Close previous connection and reconnect to the new database
 	DB.Close()
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), dbName)
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s%s", os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), dbName, sessionParams)
 	DB, err = sql.Open("mysql", dsn)
 	if err != nil {
 		log.Fatal("❌ Error reconnecting to database:", err)
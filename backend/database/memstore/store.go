@@ -0,0 +1,106 @@
+package memstore
+
+import (
+	"backend/models"
+	"sync"
+)
+
+// Store holds every table the in-memory repositories share.
+// It mirrors the MySQL schema closely enough that services behave the same
+// against either backend, so the service layer can be exercised without a database.
+// Repositories must hold Mu while reading or writing any field. Timestamps are written
+// in UTC, the zone MySQL sessions are pinned to.
+type Store struct {
+	Mu sync.Mutex
+
+	Users      map[int]models.User
+	NextUserID int
+
+	Clients       map[string]models.Client
+	ClientCounter int
+
+	// AgentClients maps client_id to the assigned agent id (nil when unassigned)
+	AgentClients map[string]*int
+
+	Accounts      map[int]models.Account
+	NextAccountID int
+
+	AgentClientLogs []models.AgentClientLog
+	NextLogID       int
+	LogChainHead    models.LogChainHead
+	LogTombstones   []models.LogTombstone
+	// LoggedEvents maps the outbox event a log records to the log's ID
+	LoggedEvents map[models.LogSource]int
+
+	CommunicationLogs      []models.CommunicationLog
+	NextCommunicationLogID int
+
+	JournalEntries []models.JournalEntry
+	NextEntryID    int64
+	NextPostingID  int64
+	// LedgerBalances maps "ledger_account|currency" to the running balance
+	LedgerBalances map[string]models.Money
+
+	Transfers      []models.Transfer
+	NextTransferID int64
+
+	// OutboxEvents are appended in ID order; the first OutboxFannedOut have been fanned out
+	OutboxEvents         []models.OutboxEvent
+	OutboxFannedOut      int
+	OutboxDeliveries     []models.OutboxDelivery
+	NextOutboxEventID    int64
+	NextOutboxDeliveryID int64
+
+	WebhookSubscriptions      []models.WebhookSubscription
+	NextWebhookSubscriptionID int64
+	WebhookDeliveries         []models.WebhookDelivery
+	NextWebhookDeliveryID     int64
+
+	LogArchives      []models.LogArchive
+	NextLogArchiveID int64
+	// LegalHolds maps client_id to the hold suspending purges of its logs
+	LegalHolds map[string]models.LegalHold
+}
+
+// New creates an empty store
+func New() *Store {
+	return &Store{
+		Users:                     make(map[int]models.User),
+		NextUserID:                1,
+		Clients:                   make(map[string]models.Client),
+		AgentClients:              make(map[string]*int),
+		Accounts:                  make(map[int]models.Account),
+		NextAccountID:             1,
+		NextLogID:                 1,
+		LoggedEvents:              make(map[models.LogSource]int),
+		NextCommunicationLogID:    1,
+		NextEntryID:               1,
+		NextPostingID:             1,
+		LedgerBalances:            make(map[string]models.Money),
+		NextTransferID:            1,
+		NextOutboxEventID:         1,
+		NextOutboxDeliveryID:      1,
+		NextWebhookSubscriptionID: 1,
+		NextWebhookDeliveryID:     1,
+		NextLogArchiveID:          1,
+		LegalHolds:                make(map[string]models.LegalHold),
+	}
+}
+
+// SeedUser inserts a user directly, e.g. the agents a test needs to exist
+func (s *Store) SeedUser(user models.User) models.User {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	if user.ID == 0 {
+		user.ID = s.NextUserID
+	}
+	if user.ID >= s.NextUserID {
+		s.NextUserID = user.ID + 1
+	}
+	if user.Status == "" {
+		user.Status = "active"
+	}
+	s.Users[user.ID] = user
+	return user
+}
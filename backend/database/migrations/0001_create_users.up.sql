@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS users (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	first_name VARCHAR(100),
+	last_name VARCHAR(100),
+	email VARCHAR(100) UNIQUE NOT NULL,
+	role ENUM('Admin', 'Agent') NOT NULL DEFAULT 'Agent',
+	status ENUM('active', 'inactive') NOT NULL DEFAULT 'active'
+);
+
+INSERT IGNORE INTO users (id, first_name, last_name, email, role, status)
+VALUES
+	(1, 'Admin', 'Root', 'Admin@root.com', 'Admin', 'active'),
+	(2, 'Agent1', 'Agent', 'Agent1@agent.com', 'Agent', 'active'),
+	(3, 'Admin2', 'Admin', 'Admin2@notRoot.com', 'Admin', 'active'),
+	(4, 'Admin3', 'Admin', 'Admin3@notRoot.com', 'Admin', 'active'),
+	(5, 'Admin4', 'Admin', 'Admin4@notRoot.com', 'Admin', 'active'),
+	(6, 'Agent2', 'Agent', 'Agent2@agent.com', 'Agent', 'active'),
+	(7, 'Agent3', 'Agent', 'Agent3@agent.com', 'Agent', 'active'),
+	(8, 'Agent4', 'Agent', 'Agent4@agent.com', 'Agent', 'active');
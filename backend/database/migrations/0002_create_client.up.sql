@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS client (
+	client_id VARCHAR(50) PRIMARY KEY,
+	first_name CHAR(50) NOT NULL,
+	last_name CHAR(50) NOT NULL,
+	dob DATE NOT NULL,
+	gender VARCHAR(20) NOT NULL,
+	email VARCHAR(100) UNIQUE NOT NULL,
+	phone VARCHAR(15) UNIQUE NOT NULL,
+	address VARCHAR(100) NOT NULL,
+	city VARCHAR(50) NOT NULL,
+	state VARCHAR(50) NOT NULL,
+	country VARCHAR(50) NOT NULL,
+	postal_code VARCHAR(10) NOT NULL,
+	verification_status VARCHAR(20) DEFAULT 'unverified'
+);
+
+CREATE TABLE IF NOT EXISTS counter (
+	id INT PRIMARY KEY AUTO_INCREMENT,
+	name VARCHAR(50) UNIQUE NOT NULL,
+	value INT NOT NULL
+);
+
+INSERT IGNORE INTO counter (name, value) VALUES ('client', 0);
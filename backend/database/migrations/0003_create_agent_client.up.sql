@@ -0,0 +1,7 @@
+-- if client is deleted, delete value. if agent is deleted, set id to null
+CREATE TABLE IF NOT EXISTS agent_client (
+	client_id VARCHAR(50) NOT NULL,
+	id INT,
+	FOREIGN KEY (client_id) REFERENCES client(client_id) ON DELETE CASCADE ON UPDATE CASCADE,
+	FOREIGN KEY (id) REFERENCES users(id) ON DELETE SET NULL
+);
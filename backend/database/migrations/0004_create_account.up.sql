@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS account (
+	account_id INT AUTO_INCREMENT PRIMARY KEY,
+	client_id VARCHAR(50) NOT NULL,
+	account_type ENUM('Savings', 'Checking', 'Business') NOT NULL DEFAULT 'Checking',
+	account_status ENUM('Active', 'Inactive', 'Pending') NOT NULL DEFAULT 'Inactive',
+	opening_date VARCHAR(50),
+	initial_deposit FLOAT NOT NULL,
+	currency VARCHAR(50) NOT NULL,
+	branch_id VARCHAR(50) NOT NULL,
+	is_active BOOLEAN NOT NULL DEFAULT TRUE
+);
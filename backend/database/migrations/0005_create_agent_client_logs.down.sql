@@ -0,0 +1 @@
+DROP TABLE IF EXISTS agent_client_logs;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS agent_client_logs (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	agent_id INT NOT NULL,
+	client_id VARCHAR(255) NOT NULL,
+	action VARCHAR(50) NOT NULL,
+	modified_fields JSON NOT NULL,
+	timestamp DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS communication_logs;
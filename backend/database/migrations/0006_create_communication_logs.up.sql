@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS communication_logs (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	log_id INT NOT NULL,
+	client_id VARCHAR(255) NOT NULL,
+	agent_id INT NOT NULL,
+	email_subject VARCHAR(255) NOT NULL,
+	email_status ENUM('Sent', 'Failed') NOT NULL,
+	timestamp DATETIME DEFAULT CURRENT_TIMESTAMP
+);
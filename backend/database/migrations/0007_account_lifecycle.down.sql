@@ -0,0 +1,5 @@
+UPDATE account SET account_status = 'Inactive' WHERE account_status IN ('Frozen', 'Closed');
+ALTER TABLE account
+	DROP COLUMN status_reason;
+ALTER TABLE account
+	MODIFY account_status ENUM('Active', 'Inactive', 'Pending') NOT NULL DEFAULT 'Inactive';
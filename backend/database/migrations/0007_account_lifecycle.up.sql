@@ -0,0 +1,4 @@
+ALTER TABLE account
+	MODIFY account_status ENUM('Pending', 'Active', 'Inactive', 'Frozen', 'Closed') NOT NULL DEFAULT 'Pending';
+ALTER TABLE account
+	ADD COLUMN status_reason VARCHAR(255) NOT NULL DEFAULT '';
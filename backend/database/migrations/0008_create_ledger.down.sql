@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS ledger_balances;
+DROP TABLE IF EXISTS ledger_postings;
+DROP TABLE IF EXISTS journal_entries;
@@ -0,0 +1,60 @@
+-- Journal entries group balanced postings; (source, source_ref) makes posting idempotent
+CREATE TABLE IF NOT EXISTS journal_entries (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	source VARCHAR(50) NOT NULL,
+	source_ref VARCHAR(100) NOT NULL,
+	description VARCHAR(255) NOT NULL DEFAULT '',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE KEY uq_journal_entries_source (source, source_ref)
+);
+
+-- Amounts are fixed-point hundredths; balance_after is the running balance of the ledger account
+CREATE TABLE IF NOT EXISTS ledger_postings (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	entry_id BIGINT NOT NULL,
+	ledger_account VARCHAR(100) NOT NULL,
+	account_id INT NULL,
+	currency VARCHAR(50) NOT NULL,
+	amount BIGINT NOT NULL,
+	balance_after BIGINT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	KEY idx_ledger_postings_account (ledger_account, currency, id),
+	KEY idx_ledger_postings_account_id (account_id, id),
+	CONSTRAINT fk_ledger_postings_entry FOREIGN KEY (entry_id) REFERENCES journal_entries (id)
+);
+
+CREATE TABLE IF NOT EXISTS ledger_balances (
+	ledger_account VARCHAR(100) NOT NULL,
+	currency VARCHAR(50) NOT NULL,
+	account_id INT NULL,
+	balance BIGINT NOT NULL DEFAULT 0,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	PRIMARY KEY (ledger_account, currency),
+	KEY idx_ledger_balances_account_id (account_id)
+);
+
+-- Backfill an opening balance entry for every existing account from its initial deposit
+INSERT INTO journal_entries (source, source_ref, description)
+SELECT 'opening', CAST(account_id AS CHAR), 'Opening balance'
+FROM account
+WHERE is_active = TRUE AND ROUND(initial_deposit * 100) <> 0;
+
+INSERT INTO ledger_postings (entry_id, ledger_account, account_id, currency, amount, balance_after)
+SELECT je.id, CONCAT('account:', a.account_id), a.account_id, a.currency,
+	ROUND(a.initial_deposit * 100), ROUND(a.initial_deposit * 100)
+FROM journal_entries je
+JOIN account a ON je.source = 'opening' AND je.source_ref = CAST(a.account_id AS CHAR)
+ORDER BY je.id;
+
+INSERT INTO ledger_postings (entry_id, ledger_account, account_id, currency, amount, balance_after)
+SELECT je.id, 'equity:opening', NULL, a.currency,
+	-ROUND(a.initial_deposit * 100),
+	-SUM(ROUND(a.initial_deposit * 100)) OVER (PARTITION BY a.currency ORDER BY je.id)
+FROM journal_entries je
+JOIN account a ON je.source = 'opening' AND je.source_ref = CAST(a.account_id AS CHAR)
+ORDER BY je.id;
+
+INSERT INTO ledger_balances (ledger_account, currency, account_id, balance)
+SELECT ledger_account, currency, MAX(account_id), SUM(amount)
+FROM ledger_postings
+GROUP BY ledger_account, currency;
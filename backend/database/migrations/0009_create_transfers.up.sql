@@ -0,0 +1,18 @@
+-- A transfer is posted as one journal entry; (requested_by, idempotency_key) makes retries safe
+CREATE TABLE IF NOT EXISTS transfers (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	from_account_id INT NOT NULL,
+	to_account_id INT NOT NULL,
+	currency VARCHAR(50) NOT NULL,
+	amount BIGINT NOT NULL,
+	description VARCHAR(255) NOT NULL DEFAULT '',
+	entry_id BIGINT NULL,
+	requested_by INT NOT NULL,
+	idempotency_key VARCHAR(255) NULL,
+	request_hash CHAR(64) NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE KEY uq_transfers_idempotency (requested_by, idempotency_key),
+	KEY idx_transfers_from (from_account_id),
+	KEY idx_transfers_to (to_account_id),
+	CONSTRAINT fk_transfers_entry FOREIGN KEY (entry_id) REFERENCES journal_entries (id)
+);
@@ -0,0 +1,6 @@
+ALTER TABLE communication_logs
+	DROP INDEX idx_communication_logs_request_id,
+	DROP COLUMN request_id;
+ALTER TABLE agent_client_logs
+	DROP INDEX idx_agent_client_logs_request_id,
+	DROP COLUMN request_id;
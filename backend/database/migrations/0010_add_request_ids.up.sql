@@ -0,0 +1,7 @@
+-- The X-Request-ID of the API call that produced each log, so one change can be traced end to end
+ALTER TABLE agent_client_logs
+	ADD COLUMN request_id VARCHAR(64) NOT NULL DEFAULT '',
+	ADD INDEX idx_agent_client_logs_request_id (request_id);
+ALTER TABLE communication_logs
+	ADD COLUMN request_id VARCHAR(64) NOT NULL DEFAULT '',
+	ADD INDEX idx_communication_logs_request_id (request_id);
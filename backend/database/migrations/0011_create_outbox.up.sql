@@ -0,0 +1,27 @@
+-- Domain events are written here in the same transaction as the change they describe
+CREATE TABLE IF NOT EXISTS outbox_events (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	event_type VARCHAR(100) NOT NULL,
+	client_id VARCHAR(255) NOT NULL DEFAULT '',
+	agent_id INT NOT NULL DEFAULT 0,
+	request_id VARCHAR(64) NOT NULL DEFAULT '',
+	payload JSON NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	dispatched_at DATETIME NULL,
+	INDEX idx_outbox_events_undispatched (dispatched_at, id)
+);
+
+-- One row per subscriber of an event, retried with backoff until delivered or dead-lettered
+CREATE TABLE IF NOT EXISTS outbox_deliveries (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	event_id BIGINT NOT NULL,
+	subscriber VARCHAR(100) NOT NULL,
+	status ENUM('pending', 'delivered', 'dead') NOT NULL DEFAULT 'pending',
+	attempts INT NOT NULL DEFAULT 0,
+	last_error TEXT NULL,
+	available_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	delivered_at DATETIME NULL,
+	UNIQUE KEY uq_outbox_deliveries_event_subscriber (event_id, subscriber),
+	INDEX idx_outbox_deliveries_due (status, available_at),
+	FOREIGN KEY (event_id) REFERENCES outbox_events(id)
+);
@@ -0,0 +1,29 @@
+-- Downstream systems subscribed to client and account events
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	url VARCHAR(2048) NOT NULL,
+	event_types JSON NOT NULL,
+	secret VARCHAR(255) NOT NULL,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_by INT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One row per attempt to deliver an event to a subscription, including replays
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	subscription_id BIGINT NOT NULL,
+	event_id BIGINT NOT NULL,
+	event_type VARCHAR(100) NOT NULL,
+	attempt INT NOT NULL,
+	replay BOOLEAN NOT NULL DEFAULT FALSE,
+	succeeded BOOLEAN NOT NULL,
+	status_code INT NOT NULL DEFAULT 0,
+	error TEXT NULL,
+	duration_ms INT NOT NULL DEFAULT 0,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	INDEX idx_webhook_deliveries_subscription (subscription_id, id),
+	INDEX idx_webhook_deliveries_event (event_id, subscription_id),
+	FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(id),
+	FOREIGN KEY (event_id) REFERENCES outbox_events(id)
+);
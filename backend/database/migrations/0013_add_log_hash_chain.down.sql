@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS agent_client_log_chain;
+ALTER TABLE agent_client_logs
+	DROP COLUMN redacted_by,
+	DROP COLUMN hash,
+	DROP COLUMN prev_hash,
+	DROP COLUMN content_hash;
@@ -0,0 +1,18 @@
+-- Each log is chained to the one before it: hash = SHA-256(prev_hash || content_hash).
+-- A redacted log keeps its hashes; redacted_by points at the Redact entry that blanked it.
+ALTER TABLE agent_client_logs
+	ADD COLUMN content_hash CHAR(64) NOT NULL DEFAULT '',
+	ADD COLUMN prev_hash CHAR(64) NOT NULL DEFAULT '',
+	ADD COLUMN hash CHAR(64) NOT NULL DEFAULT '',
+	ADD COLUMN redacted_by INT NULL;
+
+-- The end of the chain, locked by every append. Logs written before this migration
+-- (up to legacy_max_id) are sealed into the chain by the first append.
+CREATE TABLE IF NOT EXISTS agent_client_log_chain (
+	id TINYINT PRIMARY KEY,
+	last_log_id INT NOT NULL DEFAULT 0,
+	last_hash CHAR(64) NOT NULL DEFAULT '',
+	legacy_max_id INT NOT NULL DEFAULT 0
+);
+INSERT INTO agent_client_log_chain (id, legacy_max_id)
+	SELECT 1, COALESCE(MAX(id), 0) FROM agent_client_logs;
@@ -0,0 +1,5 @@
+ALTER TABLE communication_logs DROP INDEX idx_communication_logs_timestamp;
+ALTER TABLE agent_client_logs DROP INDEX idx_agent_client_logs_timestamp;
+DROP TABLE IF EXISTS legal_holds;
+DROP TABLE IF EXISTS agent_client_log_tombstones;
+DROP TABLE IF EXISTS log_archives;
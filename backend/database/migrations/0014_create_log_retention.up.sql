@@ -0,0 +1,35 @@
+-- Batches of expired logs written to compressed JSONL files under ARCHIVE_DIR before being purged
+CREATE TABLE IF NOT EXISTS log_archives (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	log_type VARCHAR(50) NOT NULL,
+	file_name VARCHAR(255) NOT NULL,
+	sha256 CHAR(64) NOT NULL,
+	log_count INT NOT NULL,
+	first_log_id INT NOT NULL,
+	last_log_id INT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE KEY uq_log_archives_file_name (file_name)
+);
+
+-- Purged agent-client logs keep their place in the hash chain through these rows
+CREATE TABLE IF NOT EXISTS agent_client_log_tombstones (
+	log_id INT PRIMARY KEY,
+	archive_id BIGINT NOT NULL,
+	content_hash CHAR(64) NOT NULL,
+	prev_hash CHAR(64) NOT NULL,
+	hash CHAR(64) NOT NULL,
+	INDEX idx_agent_client_log_tombstones_archive (archive_id),
+	FOREIGN KEY (archive_id) REFERENCES log_archives(id)
+);
+
+-- No log of a client under legal hold is purged
+CREATE TABLE IF NOT EXISTS legal_holds (
+	client_id VARCHAR(255) PRIMARY KEY,
+	reason VARCHAR(255) NOT NULL,
+	placed_by INT NOT NULL,
+	placed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The retention job looks for expired logs by age
+ALTER TABLE agent_client_logs ADD INDEX idx_agent_client_logs_timestamp (timestamp);
+ALTER TABLE communication_logs ADD INDEX idx_communication_logs_timestamp (timestamp);
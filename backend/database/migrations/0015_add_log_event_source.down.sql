@@ -0,0 +1,4 @@
+ALTER TABLE agent_client_logs
+	DROP INDEX uq_agent_client_logs_event,
+	DROP COLUMN event_side,
+	DROP COLUMN event_id;
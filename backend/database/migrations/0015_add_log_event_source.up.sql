@@ -0,0 +1,6 @@
+-- The outbox event an audit log records, so a redelivered event is not logged twice.
+-- event_side tells apart the two logs of a transfer; logs written directly have no event_id.
+ALTER TABLE agent_client_logs
+	ADD COLUMN event_id BIGINT NULL,
+	ADD COLUMN event_side VARCHAR(10) NOT NULL DEFAULT '',
+	ADD UNIQUE KEY uq_agent_client_logs_event (event_id, event_side);
@@ -2,24 +2,33 @@ package main
 
 import (
 	_ "backend/services/envloader"
-	"fmt"
+	"context"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv" // ✅ Load .env
 
 	"backend/database"
 	"backend/routes"           // Import routes from the routes package
-	"backend/services/account" // Import acciunt service to initialize table
+	"backend/services/account"
 
 	"backend/services/agentClient"
-	"backend/services/agentclient_logs"                     // Import agent-client logs to initialize its table
-	"backend/services/client"                               // Import client service to initialize table
-	communicationlogs "backend/services/communication_logs" // Import communication service to initialize table
+	"backend/services/agentclient_logs"                     // Import agent-client logs
+	"backend/services/client"                               // Import client service
+	"backend/services/events"
+	"backend/services/ledger"
+	"backend/services/logging"
+	"backend/services/retention"
+	"backend/services/transfer"
+	"backend/services/webhooks"
+	communicationlogs "backend/services/communication_logs" // Import communication service
 	commobserver "backend/services/communication_observer"  // Import communication observer
 	"backend/services/observer"                             // import observer
-	"backend/services/user"                                 // Import user service to initialize table
 )
 
 func main() {
@@ -34,50 +43,136 @@ func main() {
 		log.Fatal("Missing AWS credentials in .env")
 	}
 
+	// Structured logger; LOG_LEVEL and LOG_FORMAT pick what each environment sees
+	logger, err := logging.FromEnv()
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+	slog.SetDefault(logger)
+
 	// Initialize the database connection
 	database.ConnectDB()
 
-	// Initialize repositories
-	agentClientLogRepo := agentclient_logs.NewAgentClientLogRepository() // Agent-client logs repo
-	communicationRepo := communicationlogs.NewCommunicationLogRepository()
-
-	// Ensure that necessary database tables are created
-	userRepo := user.NewUserRepository() // Initializes user repo (which ensures table exists)
-	_ = userRepo                         // Avoid unused variable warning
+	// Refuse to start against a schema that hasn't been migrated
+	if err := database.RequireMigrated(database.DB); err != nil {
+		log.Fatal("❌ ", err)
+	}
 
-	// Initialize the ObserverManager and register observers
-	observerManager := &observer.ObserverManager{}
+	// Initialize repositories
+	agentClientLogRepo := agentclient_logs.NewAgentClientLogRepository(database.DB) // Agent-client logs repo
+	communicationRepo := communicationlogs.NewCommunicationLogRepository(database.DB)
 
 	// Initialize repo and services
-	clientRepo := client.NewClientRepository(observerManager)
-	agentClientRepo := agentClient.NewAgentClientRepository()
-	accountRepo := account.NewAccountRepository(observerManager, agentClientRepo)
+	clientRepo := client.NewClientRepository(database.DB)
+	agentClientRepo := agentClient.NewAgentClientRepository(database.DB)
+	accountRepo := account.NewAccountRepository(database.DB)
+	ledgerRepo := ledger.NewLedgerRepository(database.DB)
 
-	clientService := client.NewClientService(clientRepo, observerManager)
+	clientService := client.NewClientService(clientRepo)
 	agentClientService := agentClient.NewAgentClientService(agentClientRepo)
-	accountService := account.NewAccountService(observerManager, accountRepo, agentClientService)
+	accountService := account.NewAccountService(accountRepo, agentClientService, logger)
+	ledgerService := ledger.NewLedgerService(ledgerRepo)
 
 	clientService.SetAgentClientService(agentClientService)
 	clientService.SetAccountService(accountService)
 	accountService.SetClientService(clientService)
+	accountService.SetLedgerService(ledgerService)
 
 	// Create the LogService which will use the repository to log actions
-	logService := agentclient_logs.NewAgentClientLogService(agentClientLogRepo, observerManager)
-	communicationService := communicationlogs.NewCommunicationLogService(communicationRepo, clientService)
+	logService := agentclient_logs.NewAgentClientLogService(agentClientLogRepo)
+	logService.SetAgentClientService(agentClientService)
+	logService.SetClientService(clientService) // reverted changes go back through the client and account services
+	logService.SetAccountService(accountService)
+	logService.SetExportSigningKey([]byte(os.Getenv("EXPORT_SIGNING_KEY"))) // unset leaves export bundles unsigned
+	communicationService := communicationlogs.NewCommunicationLogService(communicationRepo, logger)
+	transferService := transfer.NewTransferService(transfer.NewTransferRepository(database.DB))
+
+	// Domain events are written to the outbox with each change and delivered to subscribers here
+	dispatcherConfig, err := events.ConfigFromEnv()
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+	outboxRepo := events.NewOutboxRepository(database.DB)
+	dispatcher := events.NewDispatcher(outboxRepo, dispatcherConfig, logger)
+
+	webhookConfig, err := webhooks.ConfigFromEnv()
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+	webhookService := webhooks.NewWebhookService(webhooks.NewWebhookRepository(database.DB), outboxRepo, webhookConfig, logger)
+
+	// Register subscribers
+	auditSubscriber := &observer.AuditLogSubscriber{LogService: logService, AgentClientService: agentClientService, Logger: logger}
+	communicationSubscriber := &commobserver.CommunicationSubscriber{LogService: communicationService, Logger: logger}
+	auditSubscriber.Register(dispatcher)
+	communicationSubscriber.Register(dispatcher)
+	webhookService.Register(dispatcher)
 
-	clientObserver := &observer.ClientObserver{LogService: logService}
-	accountObserver := &observer.AccountObserver{LogService: logService}
-	communicationObserver := &commobserver.CommunicationObserver{LogService: communicationService}
+	dispatchCtx, stopDispatching := context.WithCancel(context.Background())
+	defer stopDispatching()
+	dispatcher.Start(dispatchCtx)
 
-	// Register observers
-	observerManager.AddClientObserver(clientObserver)
-	observerManager.AddAccountObserver(accountObserver)
-	observerManager.AddCommunicationObserver(communicationObserver)
+	// Expired logs are archived to disk and purged on a schedule, except for clients under legal hold
+	retentionConfig, err := retention.ConfigFromEnv()
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+	archiver := retention.NewArchiver(retention.NewRetentionRepository(database.DB), retentionConfig, logger)
+	retentionCtx, stopRetention := context.WithCancel(context.Background())
+	defer stopRetention()
+	archiver.Start(retentionCtx)
 
 	// Set up routes
-	router := routes.SetupRoutes(clientService, accountService, logService)
+	router := routes.SetupRoutes(clientService, accountService, ledgerService, transferService, logService, communicationService, dispatcher, webhookService, archiver, logger)
 
 	// Start the server
-	fmt.Println("Server is running on port 8080")
-	log.Fatal(http.ListenAndServe(":8080", router))
+	server := &http.Server{Addr: ":8080", Handler: router}
+	server.RegisterOnShutdown(logService.CloseStreams) // open log streams would otherwise hold Shutdown
+	go func() {
+		logger.Info("server is running", "addr", server.Addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	// Wait for SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+	stop() // a second signal kills the process outright
+
+	// Stop accepting connections and let in-flight requests finish, then the
+	// event batch being delivered, before closing the database. Webhook calls
+	// in flight are cancelled; undelivered events stay in the outbox for the
+	// next start.
+	timeout := shutdownTimeout()
+	logger.Info("shutting down, draining requests", "timeout", timeout.String())
+	drainCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := server.Shutdown(drainCtx); err != nil {
+		logger.Error("requests still in flight at shutdown", "error", err)
+	}
+	stopDispatching()
+	stopRetention()
+	if err := dispatcher.Wait(drainCtx); err != nil {
+		logger.Error("event dispatcher did not stop", "error", err)
+	}
+	if err := archiver.Wait(drainCtx); err != nil {
+		logger.Error("log retention did not stop", "error", err)
+	}
+	if err := database.DB.Close(); err != nil {
+		logger.Error("error closing database", "error", err)
+	}
+	logger.Info("server stopped")
+}
+
+// shutdownTimeout reads SHUTDOWN_TIMEOUT (default 30s), how long to drain before stopping
+func shutdownTimeout() time.Duration {
+	timeout, err := time.ParseDuration(os.Getenv("SHUTDOWN_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		return 30 * time.Second
+	}
+	return timeout
 }
\ No newline at end of file
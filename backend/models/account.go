@@ -11,6 +11,7 @@ type Account struct {
 	Currency       string  `json:"currency"`
 	BranchID       string  `json:"branch_id"`
 	IsActive       bool `json:"is_active"`
+	StatusReason   string  `json:"status_reason"`
 }
 
 // Error implements error.
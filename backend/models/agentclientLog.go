@@ -8,4 +8,51 @@ type AgentClientLog struct {
 	Action         string                 `json:"action"`
 	ModifiedFields map[string]interface{} `json:"modified_fields"`
 	Timestamp      string                 `json:"timestamp"`
+	RequestID      string                 `json:"request_id"` // X-Request-ID of the API call that made the change
+
+	// Hash chain: Hash = SHA-256(PrevHash || ContentHash), so editing or removing an entry breaks every later link
+	ContentHash string `json:"content_hash,omitempty"`
+	PrevHash    string `json:"prev_hash,omitempty"`
+	Hash        string `json:"hash,omitempty"`
+	RedactedBy  *int   `json:"redacted_by,omitempty"` // ID of the Redact entry that blanked this log's content
+}
+
+// LogSource is the outbox event a log records and, for an event logged against both of its
+// clients, which side of it. A log with no EventID was written directly and is never deduplicated.
+type LogSource struct {
+	EventID int64
+	Side    string
+}
+
+// LogChainEntry is a log as the chain verifier reads it, with the hash of its content as currently stored.
+// An archived entry is the tombstone of a purged log and carries only its ID and hashes.
+type LogChainEntry struct {
+	Log                 AgentClientLog
+	ComputedContentHash string
+	Archived            bool
+}
+
+// LogChainHead is the end of the log chain. Logs up to LegacyMaxID predate the chain and are
+// sealed into it by the first append.
+type LogChainHead struct {
+	LastLogID   int
+	LastHash    string
+	LegacyMaxID int
+}
+
+// ChainBreak is one place the log chain fails to verify
+type ChainBreak struct {
+	LogID  int    `json:"log_id"`
+	Reason string `json:"reason"`
+}
+
+// ChainReport is the result of walking the log chain
+type ChainReport struct {
+	Valid     bool         `json:"valid"`
+	Checked   int          `json:"checked"`
+	Redacted  int          `json:"redacted"`
+	Archived  int          `json:"archived"` // purged by retention, verified through their tombstones
+	Unsealed  int          `json:"unsealed"` // legacy logs not yet sealed into the chain
+	HeadLogID int          `json:"head_log_id"`
+	Breaks    []ChainBreak `json:"breaks"`
 }
@@ -0,0 +1,41 @@
+package models
+
+// ClientSnapshot is a client rebuilt by replaying its audit logs up to a point in time
+type ClientSnapshot struct {
+	Client    Client `json:"client"`
+	AsOf      string `json:"as_of"`
+	CreatedAt string `json:"created_at,omitempty"`
+	CreatedBy int    `json:"created_by,omitempty"`
+	UpdatedAt string `json:"updated_at"` // timestamp of the last change replayed
+	UpdatedBy int    `json:"updated_by"`
+	LastLogID int    `json:"last_log_id"`
+	// Complete is false when part of the history could not be replayed: a log was redacted,
+	// or the history starts after the Create because earlier logs were archived
+	Complete      bool  `json:"complete"`
+	MissingLogIDs []int `json:"missing_log_ids,omitempty"` // redacted logs that were skipped
+	// MaskedFields are the fields the audit log only holds masked, such as phone
+	MaskedFields []string `json:"masked_fields,omitempty"`
+}
+
+// FieldChange is one value a client field took and who set it
+type FieldChange struct {
+	LogID     int         `json:"log_id"`
+	Action    string      `json:"action"`
+	AgentID   int         `json:"agent_id"`
+	Timestamp string      `json:"timestamp"`
+	RequestID string      `json:"request_id"`
+	Before    interface{} `json:"before"`
+	After     interface{} `json:"after"`
+	Masked    bool        `json:"masked,omitempty"` // Before and After are masked values
+}
+
+// ClientFieldHistory lists every value each of a client's fields has held, oldest first,
+// keyed by the field's JSON name
+type ClientFieldHistory struct {
+	ClientID      string                   `json:"client_id"`
+	Fields        map[string][]FieldChange `json:"fields"`
+	DeletedAt     string                   `json:"deleted_at,omitempty"`
+	DeletedBy     int                      `json:"deleted_by,omitempty"`
+	Complete      bool                     `json:"complete"`
+	MissingLogIDs []int                    `json:"missing_log_ids,omitempty"`
+}
@@ -9,4 +9,5 @@ type CommunicationLog struct {
 	EmailSubject string `json:"email_subject"`
 	EmailStatus  string `json:"email_status"`
 	Timestamp    string `json:"timestamp"`
+	RequestID    string `json:"request_id"`
 }
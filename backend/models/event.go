@@ -0,0 +1,36 @@
+package models
+
+import "encoding/json"
+
+// EventMeta is who caused a domain event and under which request. EventID and OccurredAt
+// are filled in when the stored event is delivered.
+type EventMeta struct {
+	AgentID    int    `json:"agent_id"`
+	RequestID  string `json:"request_id"`
+	RevertOf   int    `json:"revert_of,omitempty"` // log entry a change reverts; subscribers get it in the event
+	EventID    int64  `json:"event_id,omitempty"`
+	OccurredAt string `json:"occurred_at,omitempty"`
+}
+
+// OutboxEvent is a domain event as stored in the outbox, written in the same transaction as
+// the change it describes
+type OutboxEvent struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	ClientID  string          `json:"client_id"`
+	AgentID   int             `json:"agent_id"`
+	RequestID string          `json:"request_id"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt string          `json:"created_at"`
+}
+
+// OutboxDelivery is one subscriber's copy of an outbox event
+type OutboxDelivery struct {
+	ID          int64       `json:"id"`
+	Subscriber  string      `json:"subscriber"`
+	Status      string      `json:"status"` // pending, delivered or dead
+	Attempts    int         `json:"attempts"`
+	LastError   string      `json:"last_error"`
+	AvailableAt string      `json:"available_at"`
+	Event       OutboxEvent `json:"event"`
+}
@@ -0,0 +1,39 @@
+package models
+
+// JournalEntry is one balanced set of postings, e.g. a deposit or a transfer.
+// Source and SourceRef identify where it came from and make posting idempotent.
+type JournalEntry struct {
+	ID          int64     `json:"id"`
+	Source      string    `json:"source"`
+	SourceRef   string    `json:"source_ref"`
+	Description string    `json:"description"`
+	CreatedAt   string    `json:"created_at"`
+	Postings    []Posting `json:"postings"`
+}
+
+// Posting moves an amount into (positive) or out of (negative) one ledger account
+type Posting struct {
+	ID            int64  `json:"id"`
+	EntryID       int64  `json:"entry_id"`
+	LedgerAccount string `json:"ledger_account"`
+	AccountID     *int   `json:"account_id,omitempty"` // set when the ledger account is a client account
+	Currency      string `json:"currency"`
+	Amount        Money  `json:"amount"`
+	BalanceAfter  Money  `json:"balance_after"`
+	CreatedAt     string `json:"created_at"`
+}
+
+// AccountBalance is the current ledger balance of a client account
+type AccountBalance struct {
+	AccountID int    `json:"account_id"`
+	ClientID  string `json:"client_id"`
+	Currency  string `json:"currency"`
+	Balance   Money  `json:"balance"`
+}
+
+// ClientBalance is the ledger balance of every account of a client, with totals per currency
+type ClientBalance struct {
+	ClientID string           `json:"client_id"`
+	Accounts []AccountBalance `json:"accounts"`
+	Totals   map[string]Money `json:"totals"`
+}
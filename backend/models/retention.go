@@ -0,0 +1,38 @@
+package models
+
+// LogArchive records a batch of expired logs written to a compressed JSONL file before they were purged
+type LogArchive struct {
+	ID         int64  `json:"id"`
+	LogType    string `json:"log_type"` // "client", "bank_account" or "communication"
+	FileName   string `json:"file_name"`
+	SHA256     string `json:"sha256"` // of the file as written
+	Count      int    `json:"count"`
+	FirstLogID int    `json:"first_log_id"`
+	LastLogID  int    `json:"last_log_id"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// LogTombstone keeps a purged agent-client log's place in the hash chain
+type LogTombstone struct {
+	LogID       int    `json:"log_id"`
+	ArchiveID   int64  `json:"archive_id"`
+	ContentHash string `json:"content_hash"`
+	PrevHash    string `json:"prev_hash"`
+	Hash        string `json:"hash"`
+}
+
+// LegalHold suspends purging of every log belonging to a client
+type LegalHold struct {
+	ClientID string `json:"client_id"`
+	Reason   string `json:"reason"`
+	PlacedBy int    `json:"placed_by"`
+	PlacedAt string `json:"placed_at"`
+}
+
+// RestoredArchive is an archive read back and checked for investigation; the logs are not
+// returned to the live tables
+type RestoredArchive struct {
+	Archive           LogArchive         `json:"archive"`
+	AgentClientLogs   []AgentClientLog   `json:"agent_client_logs,omitempty"`
+	CommunicationLogs []CommunicationLog `json:"communication_logs,omitempty"`
+}
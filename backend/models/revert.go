@@ -0,0 +1,21 @@
+package models
+
+// RevertConflict is a field whose current value no longer matches what the reverted change set
+type RevertConflict struct {
+	Field    string      `json:"field"`
+	Expected interface{} `json:"expected"` // the "after" value the log recorded
+	Current  interface{} `json:"current"`
+}
+
+// RevertResult is the outcome of reverting a logged change. The Revert log entry itself is
+// written when the change's event is delivered, linked to LogID through reverts_log_id.
+type RevertResult struct {
+	LogID     int                    `json:"log_id"` // the entry that was reverted
+	LogType   string                 `json:"log_type"`
+	ClientID  string                 `json:"client_id"`
+	AccountID int                    `json:"account_id,omitempty"`
+	Restored  map[string]interface{} `json:"restored,omitempty"` // the "before" values put back, by JSON name
+	Conflicts []RevertConflict       `json:"conflicts,omitempty"`
+	Client    *Client                `json:"client,omitempty"`
+	Account   *Account               `json:"account,omitempty"`
+}
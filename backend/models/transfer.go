@@ -0,0 +1,18 @@
+package models
+
+// Transfer moves money between two accounts of the same currency
+type Transfer struct {
+	ID             int64  `json:"id"`
+	FromAccountID  int    `json:"from_account_id"`
+	ToAccountID    int    `json:"to_account_id"`
+	FromClientID   string `json:"from_client_id"`
+	ToClientID     string `json:"to_client_id"`
+	Amount         Money  `json:"amount"`
+	Currency       string `json:"currency"`
+	Description    string `json:"description"`
+	EntryID        int64  `json:"entry_id"`
+	RequestedBy    int    `json:"requested_by"`
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	RequestHash    string `json:"-"`
+	CreatedAt      string `json:"created_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+// WebhookSubscription is a downstream endpoint that receives the listed event types
+type WebhookSubscription struct {
+	ID         int64    `json:"id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	// Secret signs every delivery. It is only returned when the subscription is created.
+	Secret    string `json:"secret,omitempty"`
+	Active    bool   `json:"active"`
+	CreatedBy int    `json:"created_by"`
+	CreatedAt string `json:"created_at"`
+}
+
+// WebhookDelivery is one attempt to deliver an event to a subscription
+type WebhookDelivery struct {
+	ID             int64  `json:"id"`
+	SubscriptionID int64  `json:"subscription_id"`
+	EventID        int64  `json:"event_id"`
+	EventType      string `json:"event_type"`
+	Attempt        int    `json:"attempt"`
+	Replay         bool   `json:"replay"`
+	Succeeded      bool   `json:"succeeded"`
+	StatusCode     int    `json:"status_code"`
+	Error          string `json:"error,omitempty"`
+	DurationMs     int64  `json:"duration_ms"`
+	CreatedAt      string `json:"created_at"`
+}
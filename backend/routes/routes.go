@@ -2,12 +2,19 @@
 package routes
 
 import (
+	"log/slog"
 	"net/http"
 	"backend/services/account"
 	"backend/services/agentclient_logs"
 	"backend/services/client"
+	"backend/services/events"
+	"backend/services/ledger"
+	"backend/services/metrics"
+	"backend/services/retention"
+	"backend/services/transfer"
 	"backend/services/communication_logs"
 	"backend/services/user"
+	"backend/services/webhooks"
 	"github.com/gorilla/mux"
 	"backend/services/middleware"
 )
@@ -15,15 +22,27 @@ import (
 func SetupRoutes(
 	clientService *client.ClientService,
 	accountService *account.AccountService,
+	ledgerService *ledger.LedgerService,
+	transferService *transfer.TransferService,
 	agentClientLogService *agentclient_logs.AgentClientLogService,
+	communicationLogService *communicationlogs.CommunicationLogService,
+	dispatcher *events.Dispatcher,
+	webhookService *webhooks.WebhookService,
+	archiver *retention.Archiver,
+	logger *slog.Logger,
 ) *mux.Router {
 	r := mux.NewRouter()
+	r.Use(middleware.RequestIDMiddleware(logger))
+	r.Use(middleware.MetricsMiddleware)
 
 	// Health Check Route
 	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("API is running!"))
 	}).Methods("GET")
 
+	// Prometheus Metrics
+	r.Handle("/metrics", metrics.Handler()).Methods("GET")
+
 	// Public User Routes
 r.HandleFunc("/api/users/login", user.LoginUserHandler).Methods("POST")
 r.HandleFunc("/api/users/authenticate", user.AuthenticateUserHandler).Methods("GET") // OAuth login
@@ -38,8 +57,61 @@ protected.Use(middleware.JWTAuthMiddleware) // Use middleware from correct packa
 // User Routes (protected)
 protected.HandleFunc("/users/{userId}", user.DisableUserHandler).Methods("DELETE")
 protected.HandleFunc("/users/{userId}", user.UpdateUserHandler).Methods("PUT")
+protected.HandleFunc("/users", user.ListUsersHandler).Methods("GET")
 //protected.HandleFunc("/users/reset-password", user.ResetPasswordHandler).Methods("POST") // Reset password (if supported)
 
+// List Routes (protected, paginated with limit/cursor/sort and field filters)
+protected.HandleFunc("/clients", client.GetAllClientsHandler(clientService)).Methods("GET")
+protected.HandleFunc("/agents/{agentId}/clients", client.GetClientsByAgentHandler(clientService)).Methods("GET")
+protected.HandleFunc("/accounts", account.GetAllAccountsHandler(accountService)).Methods("GET")
+protected.HandleFunc("/clients/{clientId}/accounts", account.GetAccountsByClientHandler(accountService)).Methods("GET")
+
+// Account Lifecycle Routes (protected)
+protected.HandleFunc("/accounts/{account_id}", account.UpdateAccountHandler(accountService)).Methods("PUT")
+protected.HandleFunc("/accounts/{account_id}/status", account.ChangeAccountStatusHandler(accountService)).Methods("POST")
+
+// Ledger Routes (protected)
+protected.HandleFunc("/accounts/{account_id}/balance", ledger.GetAccountBalanceHandler(ledgerService)).Methods("GET")
+protected.HandleFunc("/accounts/{account_id}/postings", ledger.GetAccountPostingsHandler(ledgerService)).Methods("GET")
+protected.HandleFunc("/clients/{clientId}/balance", ledger.GetClientBalanceHandler(ledgerService)).Methods("GET")
+
+// Transfer Routes (protected)
+protected.HandleFunc("/transfers", transfer.CreateTransferHandler(transferService)).Methods("POST")
+protected.HandleFunc("/transfers/{transfer_id}", transfer.GetTransferHandler(transferService)).Methods("GET")
+
+// Event Outbox Routes (protected, Admin only)
+protected.HandleFunc("/events/dead-letters", events.GetDeadLettersHandler(dispatcher)).Methods("GET")
+protected.HandleFunc("/events/dead-letters/{delivery_id}/retry", events.RetryDeadLetterHandler(dispatcher)).Methods("POST")
+
+// Webhook Routes (protected, Admin only)
+protected.HandleFunc("/webhooks", webhooks.CreateSubscriptionHandler(webhookService)).Methods("POST")
+protected.HandleFunc("/webhooks", webhooks.ListSubscriptionsHandler(webhookService)).Methods("GET")
+protected.HandleFunc("/webhooks/{webhook_id}", webhooks.DeleteSubscriptionHandler(webhookService)).Methods("DELETE")
+protected.HandleFunc("/webhooks/{webhook_id}/deliveries", webhooks.ListDeliveriesHandler(webhookService)).Methods("GET")
+protected.HandleFunc("/webhooks/deliveries/{delivery_id}/replay", webhooks.ReplayDeliveryHandler(webhookService)).Methods("POST")
+
+// Audit Log Stream and Export (protected; agents only see their own clients)
+protected.HandleFunc("/agentclient_logs/stream", agentclient_logs.StreamLogsHandler(agentClientLogService)).Methods("GET")
+protected.HandleFunc("/agentclient_logs/export", agentclient_logs.ExportLogsHandler(agentClientLogService)).Methods("GET")
+
+// Client History (protected; rebuilt from the audit logs, agents only see their own clients)
+protected.HandleFunc("/clients/{clientId}/as-of", agentclient_logs.ClientAsOfHandler(agentClientLogService)).Methods("GET")
+protected.HandleFunc("/clients/{clientId}/history", agentclient_logs.ClientHistoryHandler(agentClientLogService)).Methods("GET")
+
+// Audit Log Integrity (protected, Admin only; deleting a log redacts it and keeps the hash chain intact)
+protected.HandleFunc("/agentclient_logs/verify", agentclient_logs.VerifyLogChainHandler(agentClientLogService)).Methods("GET")
+protected.HandleFunc("/agentclient_logs/{logID}", agentclient_logs.RedactLogHandler(agentClientLogService)).Methods("DELETE")
+
+// Audit Log Revert (protected, Admin only; applies an Update entry's "before" values if nothing changed since)
+protected.HandleFunc("/agentclient_logs/{logID}/revert", agentclient_logs.RevertLogHandler(agentClientLogService)).Methods("POST")
+
+// Log Retention Routes (protected, Admin only; restoring returns an archive's logs for investigation)
+protected.HandleFunc("/archives", retention.ListArchivesHandler(archiver)).Methods("GET")
+protected.HandleFunc("/archives/{archive_id}/restore", retention.RestoreArchiveHandler(archiver)).Methods("POST")
+protected.HandleFunc("/legal-holds", retention.ListHoldsHandler(archiver)).Methods("GET")
+protected.HandleFunc("/clients/{clientId}/legal-hold", retention.PlaceHoldHandler(archiver)).Methods("PUT")
+protected.HandleFunc("/clients/{clientId}/legal-hold", retention.ReleaseHoldHandler(archiver)).Methods("DELETE")
+
 	// Client Routes
 	r.HandleFunc("/api/clients/{agent_id}", client.CreateClientHandler(clientService)).Methods("POST")
 	r.HandleFunc("/api/clients/{clientId}", client.GetClientHandler(clientService)).Methods("GET")
@@ -61,10 +133,9 @@ protected.HandleFunc("/users/{userId}", user.UpdateUserHandler).Methods("PUT")
 	r.HandleFunc("/agentclient_logs/all/client/{clientID}", agentclient_logs.GetClientAndAccountLogsByClientIDHandler(agentClientLogService)).Methods("GET")
 	r.HandleFunc("/agentclient_logs/all/agent/{agentID}", agentclient_logs.GetClientAndAccountLogsByAgentIDHandler(agentClientLogService)).Methods("GET")
 	r.HandleFunc("/agentclient_logs/all", agentclient_logs.GetAllLogsHandler(agentClientLogService)).Methods("GET")
-	r.HandleFunc("/agentclient_logs/{logID}", agentclient_logs.DeleteLogHandler(agentClientLogService)).Methods("DELETE")
 
 	// Communication Log Read Routes
-	r.HandleFunc("/communication_logs/{logID}", communicationlogs.GetCommunicationLogByLogIDHandler).Methods("GET")
+	r.HandleFunc("/communication_logs/{logID}", communicationlogs.GetCommunicationLogByLogIDHandler(communicationLogService)).Methods("GET")
 
 	return r
 }
@@ -2,6 +2,7 @@ package account
 
 import (
 	"backend/models"
+	"backend/services/listquery"
 	"encoding/json"
 	"net/http"
 	"strconv"
@@ -25,7 +26,7 @@ func CreateAccountHandler(service *AccountService) http.HandlerFunc {
 			return
 		}
 
-		createdAccount, err := service.CreateAccount(account)
+		createdAccount, err := service.CreateAccount(r.Context(), account)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
@@ -54,7 +55,7 @@ func DeleteAccountHandler(service *AccountService) http.HandlerFunc {
 			return
 		}
 
-		if err := service.DeleteAccount(accountID); err != nil {
+		if err := service.DeleteAccount(r.Context(), accountID); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -64,6 +65,77 @@ func DeleteAccountHandler(service *AccountService) http.HandlerFunc {
 	}
 }
 
+// UpdateAccountHandler restricts to Admin or Agent
+func UpdateAccountHandler(service *AccountService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userCtx := r.Context().Value("user").(map[string]interface{})
+		role := userCtx["role"].(string)
+		if role != "Admin" && role != "Agent" {
+			http.Error(w, "Unauthorized: only Admin or Agent can update accounts", http.StatusForbidden)
+			return
+		}
+
+		vars := mux.Vars(r)
+		accountID, err := strconv.Atoi(vars["account_id"])
+		if err != nil {
+			http.Error(w, "Invalid account ID", http.StatusBadRequest)
+			return
+		}
+
+		var account models.Account
+		if err := json.NewDecoder(r.Body).Decode(&account); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		updatedAccount, err := service.UpdateAccount(r.Context(), accountID, account)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(updatedAccount)
+	}
+}
+
+// ChangeAccountStatusHandler restricts to Admin or Agent
+func ChangeAccountStatusHandler(service *AccountService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userCtx := r.Context().Value("user").(map[string]interface{})
+		role := userCtx["role"].(string)
+		if role != "Admin" && role != "Agent" {
+			http.Error(w, "Unauthorized: only Admin or Agent can change account status", http.StatusForbidden)
+			return
+		}
+
+		vars := mux.Vars(r)
+		accountID, err := strconv.Atoi(vars["account_id"])
+		if err != nil {
+			http.Error(w, "Invalid account ID", http.StatusBadRequest)
+			return
+		}
+
+		var requestBody struct {
+			Status string `json:"status"`
+			Reason string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		updatedAccount, err := service.ChangeAccountStatus(r.Context(), accountID, requestBody.Status, requestBody.Reason)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(updatedAccount)
+	}
+}
+
 // GetAllAccountsHandler restricts to Admin or Agent
 func GetAllAccountsHandler(service *AccountService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -74,7 +146,13 @@ func GetAllAccountsHandler(service *AccountService) http.HandlerFunc {
 			return
 		}
 
-		accounts, err := service.GetAllAccounts()
+		q, err := listquery.Parse(r, ListSpec)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		accounts, err := service.GetAllAccounts(q)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -98,7 +176,13 @@ func GetAccountsByClientHandler(service *AccountService) http.HandlerFunc {
 		vars := mux.Vars(r)
 		clientID := vars["clientId"]
 
-		accounts, err := service.GetAccountsByClientID(clientID)
+		q, err := listquery.Parse(r, ListSpec)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		accounts, err := service.GetAccountsByClientID(clientID, q)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -0,0 +1,69 @@
+package account
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Account statuses, in lifecycle order
+const (
+	StatusPending  = "Pending"
+	StatusActive   = "Active"
+	StatusInactive = "Inactive"
+	StatusFrozen   = "Frozen"
+	StatusClosed   = "Closed"
+)
+
+// transitions lists the statuses each status may move to.
+// Pending -> Active -> Inactive/Frozen -> Closed, with Inactive and Frozen able to return to Active.
+var transitions = map[string][]string{
+	StatusPending:  {StatusActive, StatusClosed},
+	StatusActive:   {StatusInactive, StatusFrozen, StatusClosed},
+	StatusInactive: {StatusActive, StatusClosed},
+	StatusFrozen:   {StatusActive, StatusClosed},
+	StatusClosed:   {},
+}
+
+// initialStatuses are the statuses an account may be created with
+var initialStatuses = []string{StatusPending, StatusActive, StatusInactive}
+
+// accountTypes are the types an account may have
+var accountTypes = []string{"Savings", "Checking", "Business"}
+
+// reasonRequired lists the statuses that can only be entered with a reason
+var reasonRequired = map[string]bool{
+	StatusFrozen: true,
+	StatusClosed: true,
+}
+
+// ValidateTransition checks that an account may move from one status to another with the given reason
+func ValidateTransition(from, to, reason string) error {
+	if _, known := transitions[to]; !known {
+		return fmt.Errorf("invalid account status: %s", to)
+	}
+	if from == to {
+		return fmt.Errorf("account is already %s", to)
+	}
+
+	next := transitions[from]
+	if !contains(next, to) {
+		if len(next) == 0 {
+			return fmt.Errorf("account is %s and can no longer change status", from)
+		}
+		return fmt.Errorf("cannot move account from %s to %s; allowed: %s", from, to, strings.Join(next, ", "))
+	}
+
+	if reasonRequired[to] && strings.TrimSpace(reason) == "" {
+		return fmt.Errorf("a reason is required to mark an account %s", to)
+	}
+	return nil
+}
+
+func contains(statuses []string, status string) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
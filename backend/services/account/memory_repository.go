@@ -0,0 +1,163 @@
+package account
+
+import (
+	"backend/database/memstore"
+	"backend/models"
+	"backend/services/events"
+	"backend/services/interfaces"
+	"backend/services/ledger"
+	"backend/services/listquery"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var _ interfaces.AccountRepositoryInterface = (*MemoryAccountRepository)(nil)
+
+// MemoryAccountRepository is an in-memory implementation of interfaces.AccountRepositoryInterface
+type MemoryAccountRepository struct {
+	store  *memstore.Store
+	ledger *ledger.MemoryLedgerRepository
+}
+
+// NewMemoryAccountRepository initializes a new MemoryAccountRepository on the given store
+func NewMemoryAccountRepository(store *memstore.Store) *MemoryAccountRepository {
+	return &MemoryAccountRepository{store: store, ledger: ledger.NewMemoryLedgerRepository(store)}
+}
+
+// CreateAccount stores a new active account, posts its opening balance and records AccountCreated
+func (r *MemoryAccountRepository) CreateAccount(account models.Account, meta models.EventMeta) (models.Account, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	if account.OpeningDate == "" {
+		account.OpeningDate = time.Now().UTC().Format("2006-01-02")
+	}
+
+	account.AccountID = r.store.NextAccountID
+	account.IsActive = true
+	if entry, ok := ledger.OpeningEntry(account); ok {
+		if _, err := r.ledger.PostEntryLocked(entry); err != nil {
+			return models.Account{}, fmt.Errorf("failed to post opening balance: %v", err)
+		}
+	}
+	r.store.NextAccountID++
+	r.store.Accounts[account.AccountID] = account
+
+	if err := events.AppendLocked(r.store, meta, events.AccountCreated{Account: account}); err != nil {
+		return models.Account{}, err
+	}
+	return account, nil
+}
+
+// UpdateAccount saves the editable fields and lifecycle status of an active account and
+// records AccountUpdated
+func (r *MemoryAccountRepository) UpdateAccount(account models.Account, meta models.EventMeta) (models.Account, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	current, ok := r.store.Accounts[account.AccountID]
+	if !ok || !current.IsActive {
+		return models.Account{}, fmt.Errorf("account with ID %d does not exist", account.AccountID)
+	}
+
+	before := current
+	current.AccountType = account.AccountType
+	current.Currency = account.Currency
+	current.BranchID = account.BranchID
+	current.AccountStatus = account.AccountStatus
+	current.StatusReason = account.StatusReason
+	r.store.Accounts[account.AccountID] = current
+
+	if err := events.AppendLocked(r.store, meta, events.AccountUpdated{Before: before, After: current, RevertOf: meta.RevertOf}); err != nil {
+		return models.Account{}, err
+	}
+	return current, nil
+}
+
+// RevertAccount sets the fields in restore on an active account, provided each field in
+// expected still holds its value, and records AccountUpdated. When a field has changed,
+// nothing is written and the conflicts are returned.
+func (r *MemoryAccountRepository) RevertAccount(accountID int, expected, restore map[string]string, meta models.EventMeta) (models.Account, []models.RevertConflict, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	before, ok := r.store.Accounts[accountID]
+	if !ok || !before.IsActive {
+		return models.Account{}, nil, fmt.Errorf("account with ID %d does not exist", accountID)
+	}
+
+	conflicts, err := accountConflicts(before, expected)
+	if err != nil || len(conflicts) > 0 {
+		return before, conflicts, err
+	}
+
+	after := before
+	if err := setAccountFields(&after, restore); err != nil {
+		return models.Account{}, nil, err
+	}
+	r.store.Accounts[accountID] = after
+
+	if err := events.AppendLocked(r.store, meta, events.AccountUpdated{Before: before, After: after, RevertOf: meta.RevertOf}); err != nil {
+		return models.Account{}, nil, err
+	}
+	return after, nil, nil
+}
+
+// DeleteAccount soft deletes an account by clearing is_active and records AccountDeleted
+func (r *MemoryAccountRepository) DeleteAccount(accountID int, meta models.EventMeta) error {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	account, ok := r.store.Accounts[accountID]
+	if !ok || !account.IsActive {
+		return fmt.Errorf("account with ID %d does not exist", accountID)
+	}
+
+	deleted := account
+	deleted.IsActive = false
+	r.store.Accounts[accountID] = deleted
+	return events.AppendLocked(r.store, meta, events.AccountDeleted{Account: account})
+}
+
+// GetAccountByID retrieves an active account by accountID
+func (r *MemoryAccountRepository) GetAccountByID(account_id int) (models.Account, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	account, ok := r.store.Accounts[account_id]
+	if !ok || !account.IsActive {
+		return models.Account{}, fmt.Errorf("account with ID %d does not exist", account_id)
+	}
+	return account, nil
+}
+
+// GetAccountByClientId retrieves the active accounts of a client, or nil if there are none
+func (r *MemoryAccountRepository) GetAccountByClientId(client_id string) ([]models.Account, error) {
+	accounts := r.activeAccounts(func(account models.Account) bool { return account.ClientID == client_id })
+	if len(accounts) == 0 {
+		return nil, nil
+	}
+	return accounts, nil
+}
+
+// ListAccounts retrieves one page of active accounts matching the query
+func (r *MemoryAccountRepository) ListAccounts(q listquery.Query) ([]models.Account, error) {
+	accounts := r.activeAccounts(func(models.Account) bool { return true })
+	return listquery.Apply(accounts, q, ListField), nil
+}
+
+// activeAccounts returns the active accounts matching keep, ordered by account ID
+func (r *MemoryAccountRepository) activeAccounts(keep func(models.Account) bool) []models.Account {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	var accounts []models.Account
+	for _, account := range r.store.Accounts {
+		if account.IsActive && keep(account) {
+			accounts = append(accounts, account)
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].AccountID < accounts[j].AccountID })
+	return accounts
+}
@@ -1,56 +1,32 @@
 package account
 
 import (
-	"backend/database"
 	"backend/models"
-	"backend/services/agentClient"
-	"backend/services/observer"
+	"backend/services/events"
+	"backend/services/ledger"
+	"backend/services/listquery"
+	"backend/services/metrics"
 	"database/sql"
+	"sort"
+	"strings"
 	"time"
 
 	"fmt"
-	"log"
 )
 
-// UserRepository struct for interacting with database
+// AccountRepository is the MySQL implementation of interfaces.AccountRepositoryInterface
 type AccountRepository struct{
-	ObserverManager *observer.ObserverManager
-	AgentClientRepository *agentClient.AgentClientRepository
+	db *sql.DB
 }
 
-// NewClientRepository initializes a new ClientRepository
-func NewAccountRepository(observerManager *observer.ObserverManager, agentClientRepository *agentClient.AgentClientRepository) *AccountRepository {
-	repo := &AccountRepository{ObserverManager: observerManager, AgentClientRepository: agentClientRepository}
-	repo.InitAccountTables() // ✅ Ensure tables exist when the repository is created
-	return repo
-}
-
-// InitClientTables creates the client and account tables if they don't exist
-func (r *AccountRepository) InitAccountTables() {
-	// Create account table
-	query := `
-	CREATE TABLE IF NOT EXISTS account (
-    account_id INT AUTO_INCREMENT PRIMARY KEY,
-    client_id VARCHAR(50) NOT NULL,
-    account_type ENUM('Savings', 'Checking', 'Business') NOT NULL DEFAULT 'Checking',
-    account_status ENUM('Active', 'Inactive', 'Pending') NOT NULL DEFAULT 'Inactive',
-    opening_date VARCHAR(50),
-    initial_deposit FLOAT NOT NULL,
-    currency VARCHAR(50) NOT NULL,
-    branch_id VARCHAR(50) NOT NULL,
-	is_active BOOLEAN NOT NULL DEFAULT TRUE
-);`
-
-	_, err := database.DB.Exec(query)
-	if err != nil {
-		log.Fatal("❌ Error creating account table:", err)
-	}
-
-	fmt.Println("✅ Account tables checked/created!")
+// NewAccountRepository initializes a new AccountRepository
+func NewAccountRepository(db *sql.DB) *AccountRepository {
+	return &AccountRepository{db: db}
 }
 
-
-func (r *AccountRepository) CreateAccount(account models.Account) (models.Account, error) {
+// CreateAccount inserts the account, posts its opening balance and records AccountCreated in one transaction
+func (r *AccountRepository) CreateAccount(account models.Account, meta models.EventMeta) (models.Account, error) {
+	defer metrics.ObserveQuery("AccountRepository", "CreateAccount", time.Now())
 
 	// Check if opening_date is empty, if so, set it to today's date
 	if account.OpeningDate == "" {
@@ -60,13 +36,19 @@ func (r *AccountRepository) CreateAccount(account models.Account) (models.Accoun
 	// SQL query to insert a new account while omitting 'is_active' and using today's date for 'opening_date'
 	query := `
 	INSERT INTO account 
-	(client_id, account_type, account_status, opening_date, initial_deposit, currency, branch_id) 
-	VALUES (?, ?, ?, ?, ?, ?, ?)` 
+	(client_id, account_type, account_status, opening_date, initial_deposit, currency, branch_id, status_reason) 
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)` 
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Account{}, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
 	
-	result, err := database.DB.Exec(query,
+	result, err := tx.Exec(query,
 		account.ClientID, account.AccountType, account.AccountStatus,
 		account.OpeningDate, account.InitialDeposit, account.Currency,
-		account.BranchID,
+		account.BranchID, account.StatusReason,
 	)
 
 	if err != nil {
@@ -81,39 +63,163 @@ func (r *AccountRepository) CreateAccount(account models.Account) (models.Accoun
 	account.AccountID = int(id)
 	account.IsActive = true
 
+	// The initial deposit is posted with the account, so its balance starts from it
+	if entry, ok := ledger.OpeningEntry(account); ok {
+		if _, err := ledger.PostEntryTx(tx, entry); err != nil {
+			return models.Account{}, fmt.Errorf("failed to post opening balance: %v", err)
+		}
+	}
+
+	if err := events.AppendTx(tx, meta, events.AccountCreated{Account: account}); err != nil {
+		return models.Account{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Account{}, fmt.Errorf("failed to commit transaction: %v", err)
+	}
 	return account, nil
 }
 
-func (r *AccountRepository) DeleteAccount(accountID int) (error) {
-	// Check if account exists
-	account, err := r.GetAccountByID(accountID)
+// DeleteAccount soft deletes the account and records AccountDeleted in one transaction
+func (r *AccountRepository) DeleteAccount(accountID int, meta models.EventMeta) (error) {
+	defer metrics.ObserveQuery("AccountRepository", "DeleteAccount", time.Now())
+	tx, err := r.db.Begin()
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to begin transaction: %v", err)
 	}
+	defer tx.Rollback()
 
-	// If the account is not found, we return an error
-	if account.AccountID == 0 {
-		return fmt.Errorf("account with ID %d does not exist", accountID)
+	// Check if account exists
+	account, err := lockAccount(tx, accountID)
+	if err != nil {
+		return err
 	}
 
 	// Proceed with updating the is_active field to false (soft delete)
 	query := `UPDATE account SET is_active = FALSE WHERE account_id = ?`
 
-	_, err = database.DB.Exec(query, accountID)
+	_, err = tx.Exec(query, accountID)
 	if err != nil {
 		return fmt.Errorf("failed to soft delete account: %v", err)
 	}
-	
+
+	if err := events.AppendTx(tx, meta, events.AccountDeleted{Account: account}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
 	return nil
 }
 
-// GetAccountByID retrieves an account by accountID
-func (r *AccountRepository) GetAccountByID(account_id int) (models.Account, error) {
-	// Query updated to fetch only active accounts
-	query := `SELECT * FROM account WHERE account_id = ? AND is_active = TRUE`
+// UpdateAccount saves the editable fields and lifecycle status of an active account and
+// records AccountUpdated with the row as it was before and after, in one transaction
+func (r *AccountRepository) UpdateAccount(account models.Account, meta models.EventMeta) (models.Account, error) {
+	defer metrics.ObserveQuery("AccountRepository", "UpdateAccount", time.Now())
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Account{}, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Locking the row first also tells a missing account apart from an update that changed nothing
+	before, err := lockAccount(tx, account.AccountID)
+	if err != nil {
+		return models.Account{}, err
+	}
+
+	query := `
+	UPDATE account
+	SET account_type = ?, currency = ?, branch_id = ?, account_status = ?, status_reason = ?
+	WHERE account_id = ? AND is_active = TRUE`
+
+	_, err = tx.Exec(query,
+		account.AccountType, account.Currency, account.BranchID,
+		account.AccountStatus, account.StatusReason, account.AccountID,
+	)
+	if err != nil {
+		return models.Account{}, fmt.Errorf("failed to update account: %v", err)
+	}
+
+	after, err := scanAccount(tx.QueryRow(accountColumns+` WHERE account_id = ?`, account.AccountID))
+	if err != nil {
+		return models.Account{}, fmt.Errorf("failed to retrieve account: %v", err)
+	}
+
+	if err := events.AppendTx(tx, meta, events.AccountUpdated{Before: before, After: after, RevertOf: meta.RevertOf}); err != nil {
+		return models.Account{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Account{}, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return after, nil
+}
+
+// RevertAccount sets the fields in restore on an active account, provided each field in
+// expected still holds its value, and records AccountUpdated in the same transaction. The row
+// stays locked from the check to the write; when a field has changed, nothing is written and
+// the conflicts are returned.
+func (r *AccountRepository) RevertAccount(accountID int, expected, restore map[string]string, meta models.EventMeta) (models.Account, []models.RevertConflict, error) {
+	defer metrics.ObserveQuery("AccountRepository", "RevertAccount", time.Now())
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Account{}, nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	before, err := lockAccount(tx, accountID)
+	if err != nil {
+		return models.Account{}, nil, err
+	}
+
+	conflicts, err := accountConflicts(before, expected)
+	if err != nil || len(conflicts) > 0 {
+		return before, conflicts, err
+	}
+
+	after := before
+	if err := setAccountFields(&after, restore); err != nil {
+		return models.Account{}, nil, err
+	}
+
+	// Only the restored columns are written; setAccountFields has checked they are account columns
+	columns := make([]string, 0, len(restore))
+	for column := range restore {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	set := make([]string, len(columns))
+	args := make([]interface{}, 0, len(columns)+1)
+	for i, column := range columns {
+		set[i] = column + " = ?"
+		args = append(args, restore[column])
+	}
+	args = append(args, accountID)
 
+	if _, err := tx.Exec(`UPDATE account SET `+strings.Join(set, ", ")+` WHERE account_id = ?`, args...); err != nil {
+		return models.Account{}, nil, fmt.Errorf("failed to revert account: %v", err)
+	}
+
+	if err := events.AppendTx(tx, meta, events.AccountUpdated{Before: before, After: after, RevertOf: meta.RevertOf}); err != nil {
+		return models.Account{}, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Account{}, nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return after, nil, nil
+}
+
+const accountColumns = `SELECT account_id, client_id, account_type, account_status, opening_date,
+		initial_deposit, currency, branch_id, is_active, status_reason
+		FROM account`
+
+// scanAccount reads an accountColumns row
+func scanAccount(row *sql.Row) (models.Account, error) {
 	var account models.Account
-	err := database.DB.QueryRow(query, account_id).Scan(
+	err := row.Scan(
 		&account.AccountID,
 		&account.ClientID,
 		&account.AccountType,
@@ -123,8 +229,30 @@ func (r *AccountRepository) GetAccountByID(account_id int) (models.Account, erro
 		&account.Currency,
 		&account.BranchID,
 		&account.IsActive,
+		&account.StatusReason,
 	)
+	return account, err
+}
+
+// lockAccount reads an active account for update inside tx
+func lockAccount(tx *sql.Tx, accountID int) (models.Account, error) {
+	account, err := scanAccount(tx.QueryRow(accountColumns+` WHERE account_id = ? AND is_active = TRUE FOR UPDATE`, accountID))
+	if err == sql.ErrNoRows {
+		return models.Account{}, fmt.Errorf("account with ID %d does not exist", accountID)
+	}
+	if err != nil {
+		return models.Account{}, fmt.Errorf("failed to retrieve account: %v", err)
+	}
+	return account, nil
+}
 
+// GetAccountByID retrieves an account by accountID
+func (r *AccountRepository) GetAccountByID(account_id int) (models.Account, error) {
+	defer metrics.ObserveQuery("AccountRepository", "GetAccountByID", time.Now())
+	// Query updated to fetch only active accounts
+	query := accountColumns + ` WHERE account_id = ? AND is_active = TRUE`
+
+	account, err := scanAccount(r.db.QueryRow(query, account_id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return models.Account{}, fmt.Errorf("account with ID %d does not exist", account_id)
@@ -136,14 +264,17 @@ func (r *AccountRepository) GetAccountByID(account_id int) (models.Account, erro
 }
 
 func (r *AccountRepository) GetAccountByClientId(client_id string) ([]models.Account, error) {
+	defer metrics.ObserveQuery("AccountRepository", "GetAccountByClientId", time.Now())
 	// Query updated to fetch only active accounts
-	query := `SELECT * FROM account WHERE client_id = ? AND is_active = TRUE`
+	query := `SELECT account_id, client_id, account_type, account_status, opening_date,
+		initial_deposit, currency, branch_id, is_active, status_reason
+		FROM account WHERE client_id = ? AND is_active = TRUE`
 
 	// Prepare a slice to store all the accounts
     var accounts []models.Account
 
     // Execute the query to fetch all accounts for the client
-    rows, err := database.DB.Query(query, client_id)
+    rows, err := r.db.Query(query, client_id)
     if err != nil {
         return nil, fmt.Errorf("failed to execute query: %v", err)
     }
@@ -162,6 +293,7 @@ func (r *AccountRepository) GetAccountByClientId(client_id string) ([]models.Acc
             &account.Currency,
             &account.BranchID,
             &account.IsActive,
+            &account.StatusReason,
         ); err != nil {
             return nil, fmt.Errorf("failed to scan account: %v", err)
         }
@@ -181,53 +313,61 @@ func (r *AccountRepository) GetAccountByClientId(client_id string) ([]models.Acc
 	return accounts, nil
 }
 
-// GetAllAccounts retrieves all active accounts from the database
-func (r *AccountRepository) GetAllAccounts() ([]models.Account, error) {
-	query := `SELECT * FROM account WHERE is_active = TRUE`
-	
-	rows, err := database.DB.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve accounts: %v", err)
-	}
-	defer rows.Close()
-	
-	var accounts []models.Account
-	for rows.Next() {
-		var account models.Account
-		err := rows.Scan(
-			&account.AccountID,
-			&account.ClientID,
-			&account.AccountType,
-			&account.AccountStatus,
-			&account.OpeningDate,
-			&account.InitialDeposit,
-			&account.Currency,
-			&account.BranchID,
-			&account.IsActive,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("error scanning account row: %v", err)
-		}
-		accounts = append(accounts, account)
-	}
-	
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating account rows: %v", err)
+// ListSpec lists the sort keys and filters accepted by account listings
+var ListSpec = listquery.Spec{
+	IDColumn: "account_id",
+	SortColumns: map[string]string{
+		"account_id":      "account_id",
+		"opening_date":    "opening_date",
+		"initial_deposit": "initial_deposit",
+	},
+	DefaultSort: "account_id",
+	Filters: map[string]listquery.Filter{
+		"account_type":   {Column: "account_type", Op: "="},
+		"account_status": {Column: "account_status", Op: "="},
+		"currency":       {Column: "currency", Op: "="},
+		"branch_id":      {Column: "branch_id", Op: "="},
+		"client_id":      {Column: "client_id", Op: "="},
+	},
+}
+
+// ListField returns an account's value for a ListSpec column
+func ListField(account models.Account, column string) interface{} {
+	switch column {
+	case "account_id":
+		return account.AccountID
+	case "client_id":
+		return account.ClientID
+	case "account_type":
+		return account.AccountType
+	case "account_status":
+		return account.AccountStatus
+	case "opening_date":
+		return account.OpeningDate
+	case "initial_deposit":
+		return account.InitialDeposit
+	case "currency":
+		return account.Currency
+	case "branch_id":
+		return account.BranchID
 	}
-	
-	return accounts, nil
+	return nil
 }
 
-// GetAccountsByClientID retrieves all active accounts for a specific client
-func (r *AccountRepository) GetAccountsByClientID(clientID string) ([]models.Account, error) {
-	query := `SELECT * FROM account WHERE client_id = ? AND is_active = TRUE`
-	
-	rows, err := database.DB.Query(query, clientID)
+// ListAccounts retrieves one page of active accounts matching the query
+func (r *AccountRepository) ListAccounts(q listquery.Query) ([]models.Account, error) {
+	defer metrics.ObserveQuery("AccountRepository", "ListAccounts", time.Now())
+	q = q.Where("is_active", "=", true)
+	clause, args := q.SQL()
+	query := `SELECT account_id, client_id, account_type, account_status, opening_date,
+		initial_deposit, currency, branch_id, is_active, status_reason FROM account` + clause
+
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve accounts for client %s: %v", clientID, err)
+		return nil, fmt.Errorf("failed to retrieve accounts: %v", err)
 	}
 	defer rows.Close()
-	
+
 	var accounts []models.Account
 	for rows.Next() {
 		var account models.Account
@@ -241,16 +381,17 @@ func (r *AccountRepository) GetAccountsByClientID(clientID string) ([]models.Acc
 			&account.Currency,
 			&account.BranchID,
 			&account.IsActive,
+			&account.StatusReason,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning account row: %v", err)
 		}
 		accounts = append(accounts, account)
 	}
-	
+
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating account rows: %v", err)
 	}
-	
+
 	return accounts, nil
-}
\ No newline at end of file
+}
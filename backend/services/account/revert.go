@@ -0,0 +1,109 @@
+package account
+
+import (
+	"backend/models"
+	"backend/services/events"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// accountField returns the field of account an update or status change may set under its JSON
+// name, or nil
+func accountField(account *models.Account, name string) *string {
+	switch name {
+	case "account_type":
+		return &account.AccountType
+	case "currency":
+		return &account.Currency
+	case "branch_id":
+		return &account.BranchID
+	case "account_status":
+		return &account.AccountStatus
+	case "status_reason":
+		return &account.StatusReason
+	}
+	return nil
+}
+
+// setAccountFields sets the fields in values, by JSON name
+func setAccountFields(account *models.Account, values map[string]string) error {
+	for name, value := range values {
+		field := accountField(account, name)
+		if field == nil {
+			return fmt.Errorf("account field %s cannot be changed by an update", name)
+		}
+		*field = value
+	}
+	return nil
+}
+
+// accountConflicts returns the fields in expected whose value account no longer holds, by name
+func accountConflicts(account models.Account, expected map[string]string) ([]models.RevertConflict, error) {
+	var conflicts []models.RevertConflict
+	for name, value := range expected {
+		field := accountField(&account, name)
+		if field == nil {
+			return nil, fmt.Errorf("account field %s cannot be changed by an update", name)
+		}
+		if *field != value {
+			conflicts = append(conflicts, models.RevertConflict{Field: name, Expected: value, Current: *field})
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Field < conflicts[j].Field })
+	return conflicts, nil
+}
+
+// RevertAccount puts back the fields in restore, provided every field in expected still holds
+// its value, with the checks of UpdateAccount or, when the status is restored, of
+// ChangeAccountStatus. The account must also keep the status those checks were made against.
+// On a conflict nothing is written.
+func (s *AccountService) RevertAccount(ctx context.Context, accountID int, expected, restore map[string]string) (models.Account, []models.RevertConflict, error) {
+	before, err := s.repo.GetAccountByID(accountID)
+	if err != nil {
+		return models.Account{}, nil, fmt.Errorf("failed to check account id existence: %v", err)
+	}
+
+	after := before
+	if err := setAccountFields(&after, restore); err != nil {
+		return models.Account{}, nil, err
+	}
+	if after.AccountStatus != before.AccountStatus {
+		if err := ValidateTransition(before.AccountStatus, after.AccountStatus, after.StatusReason); err != nil {
+			return models.Account{}, nil, err
+		}
+	} else {
+		if before.AccountStatus == StatusClosed {
+			return models.Account{}, nil, fmt.Errorf("account %d is closed and cannot be updated", accountID)
+		}
+		if !contains(accountTypes, after.AccountType) {
+			return models.Account{}, nil, fmt.Errorf("invalid account type: %s. Valid options are: %s", after.AccountType, strings.Join(accountTypes, ", "))
+		}
+		if after.Currency != before.Currency {
+			posted, err := s.LedgerService.HasPostings(accountID)
+			if err != nil {
+				return models.Account{}, nil, fmt.Errorf("failed to check ledger activity: %v", err)
+			}
+			if posted {
+				return models.Account{}, nil, fmt.Errorf("account %d has ledger activity in %s and its currency cannot be changed", accountID, before.Currency)
+			}
+		}
+	}
+
+	guard := map[string]string{"account_status": before.AccountStatus}
+	for name, value := range expected {
+		guard[name] = value
+	}
+
+	agentID, err := s.AgentClientService.GetAgentIDByClientID(before.ClientID)
+	if err != nil {
+		return models.Account{}, nil, fmt.Errorf("failed to check agent id existence: %v", err)
+	}
+
+	reverted, conflicts, err := s.repo.RevertAccount(accountID, guard, restore, events.NewMeta(ctx, agentID))
+	if err != nil {
+		return models.Account{}, nil, fmt.Errorf("failed to revert account: %v", err)
+	}
+	return reverted, conflicts, nil
+}
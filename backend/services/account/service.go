@@ -2,26 +2,30 @@ package account
 
 import (
 	"backend/models"
-	"backend/services/agentClient"
+	"backend/services/events"
 	"backend/services/interfaces"
-	"backend/services/observer"
+	"backend/services/listquery"
+	"context"
 	"fmt"
+	"log/slog"
+	"strings"
 )
 
-// UserService struct to interact with the repository layer
+// AccountService struct to interact with the repository layer
 type AccountService struct {
-	ObserverManager *observer.ObserverManager
-	repo *AccountRepository
-	AgentClientService *agentClient.AgentClientService
+	repo interfaces.AccountRepositoryInterface
+	AgentClientService interfaces.AgentClientServiceInterface
 	ClientService      interfaces.ClientServiceInterface
+	LedgerService      interfaces.LedgerServiceInterface
+	logger             *slog.Logger
 }
 
-// NewUserService initializes the user service
-func NewAccountService(observerManager *observer.ObserverManager, repo *AccountRepository, agentClientService *agentClient.AgentClientService) *AccountService {
+// NewAccountService initializes the account service
+func NewAccountService(repo interfaces.AccountRepositoryInterface, agentClientService interfaces.AgentClientServiceInterface, logger *slog.Logger) *AccountService {
 	return &AccountService{
-		ObserverManager: observerManager, // Pass the ObserverManager here
 		repo: repo, 
 		AgentClientService: agentClientService,
+		logger: logger,
 		}
 }
 
@@ -30,6 +34,11 @@ func (s *AccountService) SetClientService(clientService interfaces.ClientService
 	s.ClientService = clientService
 }
 
+// SetLedgerService sets the ledger service used to check an account's ledger activity
+func (s *AccountService) SetLedgerService(ledgerService interfaces.LedgerServiceInterface) {
+	s.LedgerService = ledgerService
+}
+
 func (s *AccountService) ClientExists(clientID string) (bool, error) {
 	client, err := s.ClientService.GetClient(clientID)
 	if err != nil {
@@ -52,7 +61,16 @@ func (s *AccountService) GetAccountByClientId(clientID string) ([]models.Account
 	return s.repo.GetAccountByClientId(clientID)
 }
 
-func (s *AccountService) CreateAccount(account models.Account) (models.Account, error) {
+// GetAccount retrieves an account by its ID
+func (s *AccountService) GetAccount(accountID int) (models.Account, error) {
+	account, err := s.repo.GetAccountByID(accountID)
+	if err != nil {
+		return models.Account{}, fmt.Errorf("failed to retrieve account: %v", err)
+	}
+	return account, nil
+}
+
+func (s *AccountService) CreateAccount(ctx context.Context, account models.Account) (models.Account, error) {
 	// Check if client_id exists before proceeding
 	exists, err := s.ClientExists(account.ClientID)
 	if err != nil {
@@ -63,11 +81,15 @@ func (s *AccountService) CreateAccount(account models.Account) (models.Account,
 	}
 
 	// Validate account_status
-	validAccountStatuses := map[string]bool{"Active": true, "Inactive": true, "Pending": true}
-	if _, valid := validAccountStatuses[account.AccountStatus]; !valid {
+	if !contains(initialStatuses, account.AccountStatus) {
 		return models.Account{}, fmt.Errorf("invalid account status: %s. Valid options are: 'Active', 'Inactive', 'Pending'", account.AccountStatus)
 	}
 
+	// Validate account_type
+	if account.AccountType != "" && !contains(accountTypes, account.AccountType) {
+		return models.Account{}, fmt.Errorf("invalid account type: %s. Valid options are: %s", account.AccountType, strings.Join(accountTypes, ", "))
+	}
+
 	// Validate initial_deposit
 	if account.InitialDeposit <= 0 {
 		return models.Account{}, fmt.Errorf("initial deposit must be greater than 0")
@@ -78,28 +100,23 @@ func (s *AccountService) CreateAccount(account models.Account) (models.Account,
 		return models.Account{}, fmt.Errorf("missing required fields")
 	}
 
-	// Call repository function to insert account
-	createdAccount, err := s.repo.CreateAccount(account)
-	if err != nil {
-		return models.Account{}, fmt.Errorf("failed to create account: %v", err)
-	}
-
 	// get agent info 
 	agentID, err_agentID := s.AgentClientService.GetAgentIDByClientID(account.ClientID)
 	if err_agentID != nil {
 	    return models.Account{}, fmt.Errorf("failed to check agent id existence: %v", err_agentID)
 	}
 
-	// Notify observers after client update
-	if s.ObserverManager != nil {
-		s.ObserverManager.NotifyAccountCreate(agentID, account.ClientID, &account)
+	// Call repository function to insert account and post its opening balance; AccountCreated is delivered from the outbox
+	createdAccount, err := s.repo.CreateAccount(account, events.NewMeta(ctx, agentID))
+	if err != nil {
+		return models.Account{}, fmt.Errorf("failed to create account: %v", err)
 	}
 
 	return createdAccount, nil
 }
 
 
-func (s *AccountService) DeleteAccount(AccountID int) (error) {
+func (s *AccountService) DeleteAccount(ctx context.Context, AccountID int) (error) {
 	// Check if account_id exists before proceeding
 	account, err := s.repo.GetAccountByID(AccountID)
 	if err != nil {
@@ -117,14 +134,10 @@ func (s *AccountService) DeleteAccount(AccountID int) (error) {
 	    return fmt.Errorf("failed to check agent id existence: %v", err_agentID)
 	}
 
-	// Notify observers after client update
-	if s.ObserverManager != nil {
-		fmt.Println(account.AccountID)
-		s.ObserverManager.NotifyAccountDelete(agentID, account.ClientID, &account)
-	}
+	s.logger.DebugContext(ctx, "deleting account", "account_id", account.AccountID)
 
-	// Call repository function to delete account
-	err = s.repo.DeleteAccount(AccountID)
+	// Call repository function to delete account; AccountDeleted is delivered from the outbox
+	err = s.repo.DeleteAccount(AccountID, events.NewMeta(ctx, agentID))
 	if err != nil {
 		return fmt.Errorf("failed to delete account: %v", err)
 	}
@@ -132,32 +145,107 @@ func (s *AccountService) DeleteAccount(AccountID int) (error) {
 	return nil
 }
 
-// GetAllAccounts retrieves a list of all active accounts
-func (s *AccountService) GetAllAccounts() ([]models.Account, error) {
-	accounts, err := s.repo.GetAllAccounts()
+// UpdateAccount changes an account's type, currency or branch. Status changes go through ChangeAccountStatus,
+// and the currency is fixed once anything has been posted to the account.
+func (s *AccountService) UpdateAccount(ctx context.Context, accountID int, update models.Account) (models.Account, error) {
+	before, err := s.repo.GetAccountByID(accountID)
+	if err != nil {
+		return models.Account{}, fmt.Errorf("failed to check account id existence: %v", err)
+	}
+
+	if before.AccountStatus == StatusClosed {
+		return models.Account{}, fmt.Errorf("account %d is closed and cannot be updated", accountID)
+	}
+	if update.AccountStatus != "" && update.AccountStatus != before.AccountStatus {
+		return models.Account{}, fmt.Errorf("account status cannot be changed by an update; use the status endpoint")
+	}
+
+	after := before
+	if update.AccountType != "" {
+		if !contains(accountTypes, update.AccountType) {
+			return models.Account{}, fmt.Errorf("invalid account type: %s. Valid options are: %s", update.AccountType, strings.Join(accountTypes, ", "))
+		}
+		after.AccountType = update.AccountType
+	}
+	if update.Currency != "" && update.Currency != before.Currency {
+		// Balances are kept per currency, so postings made in the old one would no longer count
+		posted, err := s.LedgerService.HasPostings(accountID)
+		if err != nil {
+			return models.Account{}, fmt.Errorf("failed to check ledger activity: %v", err)
+		}
+		if posted {
+			return models.Account{}, fmt.Errorf("account %d has ledger activity in %s and its currency cannot be changed", accountID, before.Currency)
+		}
+		after.Currency = update.Currency
+	}
+	if update.BranchID != "" {
+		after.BranchID = update.BranchID
+	}
+
+	return s.saveAccount(ctx, before, after)
+}
+
+// ChangeAccountStatus moves an account through its lifecycle, recording the reason
+func (s *AccountService) ChangeAccountStatus(ctx context.Context, accountID int, status string, reason string) (models.Account, error) {
+	before, err := s.repo.GetAccountByID(accountID)
+	if err != nil {
+		return models.Account{}, fmt.Errorf("failed to check account id existence: %v", err)
+	}
+
+	if err := ValidateTransition(before.AccountStatus, status, reason); err != nil {
+		return models.Account{}, err
+	}
+
+	after := before
+	after.AccountStatus = status
+	after.StatusReason = reason
+
+	return s.saveAccount(ctx, before, after)
+}
+
+// saveAccount persists the changed account; the repository records AccountUpdated with the
+// stored before/after pair
+func (s *AccountService) saveAccount(ctx context.Context, before, after models.Account) (models.Account, error) {
+	agentID, err := s.AgentClientService.GetAgentIDByClientID(before.ClientID)
+	if err != nil {
+		return models.Account{}, fmt.Errorf("failed to check agent id existence: %v", err)
+	}
+
+	updatedAccount, err := s.repo.UpdateAccount(after, events.NewMeta(ctx, agentID))
+	if err != nil {
+		return models.Account{}, fmt.Errorf("failed to update account: %v", err)
+	}
+
+	return updatedAccount, nil
+}
+
+// GetAllAccounts retrieves one page of all active accounts
+func (s *AccountService) GetAllAccounts(q listquery.Query) (listquery.Page[models.Account], error) {
+	accounts, err := s.repo.ListAccounts(q)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve accounts: %v", err)
+		return listquery.Page[models.Account]{}, fmt.Errorf("failed to retrieve accounts: %v", err)
 	}
 	
-	return accounts, nil
+	return listquery.NewPage(accounts, q, ListField)
 }
 
-// GetAccountsByClientID retrieves accounts associated with a specific client
-func (s *AccountService) GetAccountsByClientID(clientID string) ([]models.Account, error) {
+// GetAccountsByClientID retrieves one page of the accounts associated with a specific client
+func (s *AccountService) GetAccountsByClientID(clientID string, q listquery.Query) (listquery.Page[models.Account], error) {
 	// Check if client exists
 	exists, err := s.ClientExists(clientID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check client existence: %v", err)
+		return listquery.Page[models.Account]{}, fmt.Errorf("failed to check client existence: %v", err)
 	}
 	if !exists {
-		return nil, fmt.Errorf("client with ID %s not found", clientID)
+		return listquery.Page[models.Account]{}, fmt.Errorf("client with ID %s not found", clientID)
 	}
 	
-	accounts, err := s.repo.GetAccountsByClientID(clientID)
+	q = q.Where("client_id", "=", clientID)
+	accounts, err := s.repo.ListAccounts(q)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve accounts for client %s: %v", clientID, err)
+		return listquery.Page[models.Account]{}, fmt.Errorf("failed to retrieve accounts for client %s: %v", clientID, err)
 	}
 	
-	return accounts, nil
+	return listquery.NewPage(accounts, q, ListField)
 }
 
@@ -6,65 +6,63 @@ import (
 )
 
 // ✅ ONLY Admins can assign clients — role is verified from JWT token context
-func AssignAgentsToUnassignedClientsHandler(w http.ResponseWriter, r *http.Request) {
-	// ✅ Extract role from context (set by middleware)
-	userCtx := r.Context().Value("user")
-	if userCtx == nil {
-		http.Error(w, "Unauthorized: missing token context", http.StatusUnauthorized)
-		return
-	}
-	claims := userCtx.(map[string]interface{})
-	role := claims["role"].(string)
+func AssignAgentsToUnassignedClientsHandler(service *AgentClientService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// ✅ Extract role from context (set by middleware)
+		userCtx := r.Context().Value("user")
+		if userCtx == nil {
+			http.Error(w, "Unauthorized: missing token context", http.StatusUnauthorized)
+			return
+		}
+		claims := userCtx.(map[string]interface{})
+		role := claims["role"].(string)
 
-	if role != "Admin" {
-		http.Error(w, "Forbidden: only Admins can assign clients", http.StatusForbidden)
-		return
-	}
+		if role != "Admin" {
+			http.Error(w, "Forbidden: only Admins can assign clients", http.StatusForbidden)
+			return
+		}
 
-	// ✅ Continue with logic
-	repo := NewAgentClientRepository()
-	service := NewAgentClientService(repo)
+		// ✅ Continue with logic
+		err := service.AssignAgentsToUnassignedClients()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 
-	err := service.AssignAgentsToUnassignedClients()
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Clients have been assigned to agents"))
 	}
-
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Clients have been assigned to agents"))
 }
 
 // ✅ ONLY Admins can view unassigned clients
-func GetUnassignedClientsHandler(w http.ResponseWriter, r *http.Request) {
-	// ✅ Extract role from context (set by middleware)
-	userCtx := r.Context().Value("user")
-	if userCtx == nil {
-		http.Error(w, "Unauthorized: missing token context", http.StatusUnauthorized)
-		return
-	}
-	claims := userCtx.(map[string]interface{})
-	role := claims["role"].(string)
+func GetUnassignedClientsHandler(service *AgentClientService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// ✅ Extract role from context (set by middleware)
+		userCtx := r.Context().Value("user")
+		if userCtx == nil {
+			http.Error(w, "Unauthorized: missing token context", http.StatusUnauthorized)
+			return
+		}
+		claims := userCtx.(map[string]interface{})
+		role := claims["role"].(string)
 
-	if role != "Admin" {
-		http.Error(w, "Forbidden: only Admins can view unassigned clients", http.StatusForbidden)
-		return
-	}
-
-	// ✅ Continue with logic
-	repo := NewAgentClientRepository()
-	service := NewAgentClientService(repo)
+		if role != "Admin" {
+			http.Error(w, "Forbidden: only Admins can view unassigned clients", http.StatusForbidden)
+			return
+		}
 
-	clients, err := service.GetUnassignedClients()
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
+		// ✅ Continue with logic
+		clients, err := service.GetUnassignedClients()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(w).Encode(clients)
-	if err != nil {
-		http.Error(w, "Error encoding response", http.StatusInternalServerError)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err = json.NewEncoder(w).Encode(clients)
+		if err != nil {
+			http.Error(w, "Error encoding response", http.StatusInternalServerError)
+		}
 	}
 }
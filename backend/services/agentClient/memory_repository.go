@@ -0,0 +1,132 @@
+package agentClient
+
+import (
+	"backend/database/memstore"
+	"backend/models"
+	"backend/services/interfaces"
+	"fmt"
+	"sort"
+)
+
+var _ interfaces.AgentClientRepositoryInterface = (*MemoryAgentClientRepository)(nil)
+
+// MemoryAgentClientRepository is an in-memory implementation of interfaces.AgentClientRepositoryInterface
+type MemoryAgentClientRepository struct {
+	store *memstore.Store
+}
+
+// NewMemoryAgentClientRepository initializes a new MemoryAgentClientRepository on the given store
+func NewMemoryAgentClientRepository(store *memstore.Store) *MemoryAgentClientRepository {
+	return &MemoryAgentClientRepository{store: store}
+}
+
+func (r *MemoryAgentClientRepository) ClientExists(clientID string) (bool, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	_, ok := r.store.AgentClients[clientID]
+	return ok, nil
+}
+
+// IsAgentNull checks if the agent ID for a given client is unassigned
+func (r *MemoryAgentClientRepository) IsAgentNull(clientID string) (bool, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	agentID, ok := r.store.AgentClients[clientID]
+	if !ok {
+		return false, fmt.Errorf("client with ID %s not found", clientID)
+	}
+	return agentID == nil, nil
+}
+
+func (r *MemoryAgentClientRepository) UpdateAgentToClient(clientID string, newID int) error {
+	isNull, err := r.IsAgentNull(clientID)
+	if err != nil {
+		return err
+	}
+	if !isNull {
+		return fmt.Errorf("agent is already assigned to client %s", clientID)
+	}
+
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	agentID := newID
+	r.store.AgentClients[clientID] = &agentID
+	return nil
+}
+
+func (r *MemoryAgentClientRepository) GetUnassignedClients() ([]models.AgentClient, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	var clients []models.AgentClient
+	for clientID, agentID := range r.store.AgentClients {
+		if agentID == nil {
+			clients = append(clients, models.AgentClient{ClientID: clientID})
+		}
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].ClientID < clients[j].ClientID })
+	return clients, nil
+}
+
+func (r *MemoryAgentClientRepository) GetAllAgents() ([]models.Agent, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	var agents []models.Agent
+	for _, user := range r.store.Users {
+		if user.Role == "Agent" {
+			agents = append(agents, models.Agent{
+				ID:        user.ID,
+				FirstName: user.FirstName,
+				LastName:  user.LastName,
+				Email:     user.Email,
+				Role:      user.Role,
+			})
+		}
+	}
+	sort.Slice(agents, func(i, j int) bool { return agents[i].ID < agents[j].ID })
+	return agents, nil
+}
+
+func (r *MemoryAgentClientRepository) IsAgent(userID int) (bool, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	user, ok := r.store.Users[userID]
+	if !ok {
+		return false, fmt.Errorf("failed to retrieve user role: user %d not found", userID)
+	}
+	return user.Role == "Agent", nil
+}
+
+// GetAgentIDByClientID returns just the agent ID assigned to a specific client
+func (r *MemoryAgentClientRepository) GetAgentIDByClientID(clientID string) (int, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	agentID, ok := r.store.AgentClients[clientID]
+	if !ok {
+		return 0, fmt.Errorf("no client found with ID %s", clientID)
+	}
+	if agentID == nil {
+		return 0, fmt.Errorf("client %s has no agent assigned", clientID)
+	}
+	return *agentID, nil
+}
+
+// GetAgentClientCount returns a map of agent IDs to their client count
+func (r *MemoryAgentClientRepository) GetAgentClientCount() (map[int]int, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	counts := make(map[int]int)
+	for _, agentID := range r.store.AgentClients {
+		if agentID != nil {
+			counts[*agentID]++
+		}
+	}
+	return counts, nil
+}
@@ -1,44 +1,28 @@
 package agentClient
 
 import (
-	"backend/database"
 	"backend/models"
+	"backend/services/metrics"
 	"database/sql"
 	"fmt"
-	"log"
+	"time"
 )
 
-// UserRepository struct for interacting with database
-type AgentClientRepository struct{}
-
-// NewClientRepository initializes a new ClientRepository
-func NewAgentClientRepository() *AgentClientRepository {
-	repo := &AgentClientRepository{}
-	repo.InitAgentClientTables() // ✅ Ensure tables exist when the repository is created
-	return repo
+// AgentClientRepository is the MySQL implementation of interfaces.AgentClientRepositoryInterface
+type AgentClientRepository struct{
+	db *sql.DB
 }
 
-// InitClientTables creates the client and account tables if they don't exist
-func (r *AgentClientRepository) InitAgentClientTables() {
-	// if client is deleted, delete value. if agent is deleted, set id to null 
-	query := `
-	CREATE TABLE IF NOT EXISTS agent_client (
-		client_id VARCHAR(50) NOT NULL,
-		id INT,
-		FOREIGN KEY (client_id) REFERENCES client(client_id) ON DELETE CASCADE ON UPDATE CASCADE,
-		FOREIGN KEY (id) REFERENCES users(id) ON DELETE SET NULL
-	);`
-
-	_, err := database.DB.Exec(query)
-	if err != nil {	
-		log.Fatal("❌ Error creating agent client table:", err)
-	}
-}	
+// NewAgentClientRepository initializes a new AgentClientRepository
+func NewAgentClientRepository(db *sql.DB) *AgentClientRepository {
+	return &AgentClientRepository{db: db}
+}
 
 func (r *AgentClientRepository) ClientExists(clientID string) (bool, error) {
+	defer metrics.ObserveQuery("AgentClientRepository", "ClientExists", time.Now())
 	query := `SELECT 1 FROM agent_client WHERE client_id = ?`
 	var exists int
-	err := database.DB.QueryRow(query, clientID).Scan(&exists)
+	err := r.db.QueryRow(query, clientID).Scan(&exists)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return false, nil
@@ -50,10 +34,11 @@ func (r *AgentClientRepository) ClientExists(clientID string) (bool, error) {
 
 // IsAgentNull checks if the agent ID for a given client is NULL
 func (r *AgentClientRepository) IsAgentNull(clientID string) (bool, error) {
+	defer metrics.ObserveQuery("AgentClientRepository", "IsAgentNull", time.Now())
 	query := `SELECT id FROM agent_client WHERE client_id = ?`
 	var agentID *int
 
-	err := database.DB.QueryRow(query, clientID).Scan(&agentID)
+	err := r.db.QueryRow(query, clientID).Scan(&agentID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return false, fmt.Errorf("client with ID %s not found", clientID)
@@ -65,6 +50,7 @@ func (r *AgentClientRepository) IsAgentNull(clientID string) (bool, error) {
 }
 
 func (r *AgentClientRepository) UpdateAgentToClient(clientID string, newID int) error {
+	defer metrics.ObserveQuery("AgentClientRepository", "UpdateAgentToClient", time.Now())
 	// Check if the agent ID is NULL
 	isNull,user_id_err := r.IsAgentNull(clientID)
 	if user_id_err != nil  {
@@ -75,13 +61,14 @@ func (r *AgentClientRepository) UpdateAgentToClient(clientID string, newID int)
 	}
 
 	query := `UPDATE agent_client SET id = ? WHERE client_id = ?`
-	_, err := database.DB.Exec(query, newID, clientID)
+	_, err := r.db.Exec(query, newID, clientID)
 	return err
 }
 
 func (r *AgentClientRepository) GetUnassignedClients() ([]models.AgentClient, error) {
+	defer metrics.ObserveQuery("AgentClientRepository", "GetUnassignedClients", time.Now())
 	query := `SELECT client_id FROM agent_client WHERE id IS NULL`
-	rows, err := database.DB.Query(query)
+	rows, err := r.db.Query(query)
 	if err != nil {
 		return nil, err
 	}
@@ -101,8 +88,9 @@ func (r *AgentClientRepository) GetUnassignedClients() ([]models.AgentClient, er
 }
 
 func (r *AgentClientRepository) GetAllAgents() ([]models.Agent, error) {
+	defer metrics.ObserveQuery("AgentClientRepository", "GetAllAgents", time.Now())
 	query := `SELECT id, first_name, last_name, email, role FROM users WHERE role = 'Agent'`
-	rows, err := database.DB.Query(query)
+	rows, err := r.db.Query(query)
 	if err != nil {
 		return nil, err
 	}
@@ -123,9 +111,10 @@ func (r *AgentClientRepository) GetAllAgents() ([]models.Agent, error) {
 
 
 func (r *AgentClientRepository) IsAgent(userID int) (bool, error) {
+	defer metrics.ObserveQuery("AgentClientRepository", "IsAgent", time.Now())
 	var role string
 	query := `SELECT role FROM users WHERE id = ?`
-	err := database.DB.QueryRow(query, userID).Scan(&role)
+	err := r.db.QueryRow(query, userID).Scan(&role)
 	if err != nil {
 		return false, fmt.Errorf("failed to retrieve user role: %v", err)
 	}
@@ -135,10 +124,11 @@ func (r *AgentClientRepository) IsAgent(userID int) (bool, error) {
 
 // GetAgentIDByClientID returns just the agent ID assigned to a specific client
 func (r *AgentClientRepository) GetAgentIDByClientID(clientID string) (int, error) {
+	defer metrics.ObserveQuery("AgentClientRepository", "GetAgentIDByClientID", time.Now())
 	query := `SELECT id FROM agent_client WHERE client_id = ?`
 	
 	var agentID int
-	err := database.DB.QueryRow(query, clientID).Scan(&agentID)
+	err := r.db.QueryRow(query, clientID).Scan(&agentID)
 	
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -152,6 +142,7 @@ func (r *AgentClientRepository) GetAgentIDByClientID(clientID string) (int, erro
 
 // GetAgentClientCount returns a map of agent IDs to their client count
 func (r *AgentClientRepository) GetAgentClientCount() (map[int]int, error) {
+	defer metrics.ObserveQuery("AgentClientRepository", "GetAgentClientCount", time.Now())
 	query := `
 	SELECT id, COUNT(client_id) as client_count
 	FROM agent_client
@@ -159,7 +150,7 @@ func (r *AgentClientRepository) GetAgentClientCount() (map[int]int, error) {
 	GROUP BY id
 	ORDER BY client_count ASC, id ASC`
 	
-	rows, err := database.DB.Query(query)
+	rows, err := r.db.Query(query)
 	if err != nil {
 		return nil, err
 	}
@@ -2,17 +2,18 @@ package agentClient
 
 import (
 	"backend/models"
+	"backend/services/interfaces"
 	"fmt"
 	"sort"
 )
 
-// UserService struct to interact with the repository layer
+// AgentClientService struct to interact with the repository layer
 type AgentClientService struct {
-	repo *AgentClientRepository
+	repo interfaces.AgentClientRepositoryInterface
 }
 
-// NewUserService initializes the user service
-func NewAgentClientService(repo *AgentClientRepository) *AgentClientService {
+// NewAgentClientService initializes the agent-client service
+func NewAgentClientService(repo interfaces.AgentClientRepositoryInterface) *AgentClientService {
 	return &AgentClientService{repo: repo}
 }
 
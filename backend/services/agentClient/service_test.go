@@ -0,0 +1,81 @@
+package agentClient
+
+import (
+	"backend/database/memstore"
+	"backend/models"
+	"testing"
+)
+
+func assign(store *memstore.Store, clientID string, agentID int) {
+	if agentID == 0 {
+		store.AgentClients[clientID] = nil
+		return
+	}
+	store.AgentClients[clientID] = &agentID
+}
+
+func TestAssignAgentsToUnassignedClients(t *testing.T) {
+	store := memstore.New()
+	store.SeedUser(models.User{ID: 1, Role: "Agent"})
+	store.SeedUser(models.User{ID: 2, Role: "Agent"})
+	store.SeedUser(models.User{ID: 3, Role: "Admin"})
+
+	// Agent 1 already has two clients, agent 2 none
+	assign(store, "client1", 1)
+	assign(store, "client2", 1)
+	for _, clientID := range []string{"client3", "client4", "client5", "client6"} {
+		assign(store, clientID, 0)
+	}
+
+	service := NewAgentClientService(NewMemoryAgentClientRepository(store))
+	if err := service.AssignAgentsToUnassignedClients(); err != nil {
+		t.Fatalf("AssignAgentsToUnassignedClients: %v", err)
+	}
+
+	// Clients go to the least loaded agent, the lower ID winning ties
+	want := map[string]int{"client3": 2, "client4": 2, "client5": 1, "client6": 2}
+	for clientID, agentID := range want {
+		got, err := service.GetAgentIDByClientID(clientID)
+		if err != nil {
+			t.Fatalf("GetAgentIDByClientID(%s): %v", clientID, err)
+		}
+		if got != agentID {
+			t.Errorf("%s assigned to agent %d, want %d", clientID, got, agentID)
+		}
+	}
+
+	unassigned, err := service.GetUnassignedClients()
+	if err != nil {
+		t.Fatalf("GetUnassignedClients: %v", err)
+	}
+	if len(unassigned) != 0 {
+		t.Errorf("clients left unassigned: %v", unassigned)
+	}
+}
+
+func TestAssignAgentsWithoutAgents(t *testing.T) {
+	store := memstore.New()
+	store.SeedUser(models.User{ID: 1, Role: "Admin"})
+	assign(store, "client1", 0)
+
+	service := NewAgentClientService(NewMemoryAgentClientRepository(store))
+	if err := service.AssignAgentsToUnassignedClients(); err == nil {
+		t.Fatal("AssignAgentsToUnassignedClients succeeded with no agents")
+	}
+	if _, err := service.GetAgentIDByClientID("client1"); err == nil {
+		t.Error("client1 was assigned with no agents")
+	}
+}
+
+func TestAssignAgentsNothingToAssign(t *testing.T) {
+	store := memstore.New()
+	assign(store, "client1", 7)
+
+	service := NewAgentClientService(NewMemoryAgentClientRepository(store))
+	if err := service.AssignAgentsToUnassignedClients(); err != nil {
+		t.Fatalf("AssignAgentsToUnassignedClients: %v", err)
+	}
+	if agentID, _ := service.GetAgentIDByClientID("client1"); agentID != 7 {
+		t.Errorf("client1 moved to agent %d", agentID)
+	}
+}
@@ -0,0 +1,138 @@
+package agentclient_logs
+
+import (
+	"backend/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RedactAction is the action of the entry that records a redaction. The redacted log keeps
+// its place and hashes in the chain; only its content is blanked.
+const RedactAction = "Redact"
+
+// RedactionLogType is the log_type of Redact entries, so they stay out of client and account listings
+const RedactionLogType = "redaction"
+
+var (
+	ErrLogNotFound        = errors.New("log not found")
+	ErrLogAlreadyRedacted = errors.New("log is already redacted")
+	ErrRedactRedaction    = errors.New("a redaction entry cannot be redacted")
+	ErrAlreadyLogged      = errors.New("event is already logged")
+)
+
+// chainBatch is how many logs the verifier reads at a time
+const chainBatch = 500
+
+// chainHash links a log's content hash to the hash of the entry before it
+func chainHash(prevHash, contentHash string) string {
+	sum := sha256.Sum256([]byte(prevHash + contentHash))
+	return hex.EncodeToString(sum[:])
+}
+
+// contentHash hashes the text a log's content_hash covers. It mirrors chainContent in the
+// MySQL repository: the columns joined with "|", modified_fields as JSON.
+func contentHash(id int, agentID int, clientID string, action string, modifiedFieldsJSON string, timestamp string, requestID string) string {
+	content := strings.Join([]string{fmt.Sprint(id), fmt.Sprint(agentID), clientID, action, modifiedFieldsJSON, timestamp, requestID}, "|")
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// redactionTarget returns the log a Redact entry redacts
+func redactionTarget(log models.AgentClientLog) int {
+	details, _ := log.ModifiedFields["details"].(map[string]interface{})
+	target, _ := details["log_id"].(float64)
+	return int(target)
+}
+
+// VerifyChain walks every log in ID order and reports where the chain does not hold: an entry
+// whose content no longer matches its hash, a link to something other than the previous
+// entry (an entry was removed or inserted), a redaction with no Redact entry behind it, or a
+// chain that stops short of the recorded head (entries were removed from the end). Logs purged
+// by retention are checked through their tombstones, which keep the links but not the content.
+func (s *AgentClientLogService) VerifyChain() (models.ChainReport, error) {
+	head, err := s.repo.ChainHead()
+	if err != nil {
+		return models.ChainReport{}, err
+	}
+
+	report := models.ChainReport{HeadLogID: head.LastLogID, Breaks: []models.ChainBreak{}}
+	fail := func(logID int, reason string) {
+		report.Breaks = append(report.Breaks, models.ChainBreak{LogID: logID, Reason: reason})
+	}
+
+	redactedBy := make(map[int]int) // redacted log -> Redact entry it names
+	redacts := make(map[int]int)    // Redact entry -> log it redacts
+	archived := make(map[int]bool)
+	prevHash, lastID := "", 0
+
+	for afterID := 0; ; {
+		entries, err := s.repo.ListChain(afterID, chainBatch)
+		if err != nil {
+			return models.ChainReport{}, err
+		}
+
+		for _, entry := range entries {
+			log := entry.Log
+			afterID = log.ID
+			report.Checked++
+
+			if log.Hash == "" {
+				if log.ID <= head.LegacyMaxID {
+					report.Unsealed++
+				} else {
+					fail(log.ID, "not sealed into the chain")
+				}
+				continue
+			}
+
+			if log.PrevHash != prevHash {
+				fail(log.ID, "does not link to the previous entry; an entry was removed or inserted")
+			}
+			if chainHash(log.PrevHash, log.ContentHash) != log.Hash {
+				fail(log.ID, "hash does not match its content hash")
+			}
+			if entry.Archived {
+				report.Archived++
+				archived[log.ID] = true
+			} else if log.RedactedBy != nil {
+				report.Redacted++
+				redactedBy[log.ID] = *log.RedactedBy
+			} else if entry.ComputedContentHash != log.ContentHash {
+				fail(log.ID, "content was modified")
+			}
+			if log.Action == RedactAction {
+				redacts[log.ID] = redactionTarget(log)
+			}
+
+			prevHash, lastID = log.Hash, log.ID
+		}
+
+		if len(entries) < chainBatch {
+			break
+		}
+	}
+
+	for logID, redaction := range redactedBy {
+		// A purged Redact entry can no longer name its target; its tombstone vouches for it
+		if redacts[redaction] != logID && !archived[redaction] {
+			fail(logID, "content was blanked without a matching Redact entry")
+		}
+	}
+	if lastID != head.LastLogID || prevHash != head.LastHash {
+		fail(lastID, fmt.Sprintf("chain does not end at the recorded head (log %d); entries were removed from the end", head.LastLogID))
+	}
+
+	sort.SliceStable(report.Breaks, func(i, j int) bool { return report.Breaks[i].LogID < report.Breaks[j].LogID })
+	report.Valid = len(report.Breaks) == 0
+	return report, nil
+}
+
+// SealLegacy seals logs written before the chain existed, returning how many were sealed.
+// The first append does the same, so this only needs running to verify before any new writes.
+func (s *AgentClientLogService) SealLegacy() (int, error) {
+	return s.repo.SealLegacy()
+}
@@ -1,10 +1,16 @@
 package agentclient_logs
 
 import (
+	"backend/database"
 	"backend/models"
+	"backend/services/listquery"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -25,7 +31,7 @@ func CreateAgentClientLogHandler(service *AgentClientLogService) http.HandlerFun
 		}
 
 		// Call the service
-		createdLog, err := service.LogAgentClientAction(logData.AgentID, logData.ClientID, logData.Action, logData.ModifiedFields)
+		createdLog, err := service.LogAgentClientAction(r.Context(), logData.AgentID, logData.ClientID, logData.Action, logData.ModifiedFields)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -43,7 +49,13 @@ func GetAgentClientLogsByClientHandler(service *AgentClientLogService) http.Hand
 		vars := mux.Vars(r)
 		clientID := vars["clientID"]
 
-		logs, err := service.GetAgentClientLogs(clientID)
+		q, err := listquery.Parse(r, ListSpec)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		logs, err := service.GetAgentClientLogs(clientID, q)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -65,7 +77,13 @@ func GetAgentClientLogsByAgentHandler(service *AgentClientLogService) http.Handl
 			return
 		}
 
-		logs, err := service.GetAgentClientLogsByAgent(agentIDInt)
+		q, err := listquery.Parse(r, ListSpec)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		logs, err := service.GetAgentClientLogsByAgent(agentIDInt, q)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -79,7 +97,13 @@ func GetAgentClientLogsByAgentHandler(service *AgentClientLogService) http.Handl
 // GetAllAgentClientLogsHandler retrieves all agent-client logs
 func GetAllAgentClientLogsHandler(service *AgentClientLogService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logs, err := service.GetAllAgentClientLogs()
+		q, err := listquery.Parse(r, ListSpec)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		logs, err := service.GetAllAgentClientLogs(q)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -105,7 +129,7 @@ func CreateBankAccountLogHandler(service *AgentClientLogService) http.HandlerFun
 			return
 		}
 
-		err = service.LogAccountChange(logData.AgentID, logData.ClientID, logData.Action, logData.ModifiedFields)
+		err = service.LogAccountChange(r.Context(), logData.AgentID, logData.ClientID, logData.Action, logData.ModifiedFields)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -122,7 +146,13 @@ func GetAccountLogsByClientHandler(service *AgentClientLogService) http.HandlerF
 		vars := mux.Vars(r)
 		clientID := vars["clientID"]
 
-		logs, err := service.GetAccountLogsByClientID(clientID)
+		q, err := listquery.Parse(r, ListSpec)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		logs, err := service.GetAccountLogsByClientID(clientID, q)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -144,7 +174,13 @@ func GetAccountLogsByAgentHandler(service *AgentClientLogService) http.HandlerFu
 			return
 		}
 
-		logs, err := service.GetAccountLogsByAgentID(agentIDInt)
+		q, err := listquery.Parse(r, ListSpec)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		logs, err := service.GetAccountLogsByAgentID(agentIDInt, q)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -158,7 +194,13 @@ func GetAccountLogsByAgentHandler(service *AgentClientLogService) http.HandlerFu
 // GetAllAccountLogsHandler retrieves all bank account transaction logs
 func GetAllAccountLogsHandler(service *AgentClientLogService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logs, err := service.GetAllAccountLogs()
+		q, err := listquery.Parse(r, ListSpec)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		logs, err := service.GetAllAccountLogs(q)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -179,7 +221,13 @@ func GetClientAndAccountLogsByAgentIDHandler(service *AgentClientLogService) htt
 			return
 		}
 
-		logs, err := service.GetClientAndAccountLogsByAgentID(agentID)
+		q, err := listquery.Parse(r, ListSpec)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		logs, err := service.GetClientAndAccountLogsByAgentID(agentID, q)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -196,7 +244,13 @@ func GetClientAndAccountLogsByClientIDHandler(service *AgentClientLogService) ht
 		vars := mux.Vars(r)
 		clientID := vars["clientID"]
 
-		logs, err := service.GetClientAndAccountLogsByClientID(clientID)
+		q, err := listquery.Parse(r, ListSpec)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		logs, err := service.GetClientAndAccountLogsByClientID(clientID, q)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -210,7 +264,13 @@ func GetClientAndAccountLogsByClientIDHandler(service *AgentClientLogService) ht
 // GetAllLogsHandler retrieves all logs from the database (client and bank account logs)
 func GetAllLogsHandler(service *AgentClientLogService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logs, err := service.GetAllLogs()
+		q, err := listquery.Parse(r, ListSpec)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		logs, err := service.GetAllLogs(q)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -221,9 +281,16 @@ func GetAllLogsHandler(service *AgentClientLogService) http.HandlerFunc {
 	}
 }
 
-// DeleteLogHandler handles deleting any log by its ID
-func DeleteLogHandler(service *AgentClientLogService) http.HandlerFunc {
+// RedactLogHandler handles deleting a log, which redacts it: the content is blanked and a Redact
+// entry recording the reason is appended. Restricted to Admin.
+func RedactLogHandler(service *AgentClientLogService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		userCtx := r.Context().Value("user").(map[string]interface{})
+		if userCtx["role"].(string) != "Admin" {
+			http.Error(w, "Unauthorized: only Admin can redact logs", http.StatusForbidden)
+			return
+		}
+
 		vars := mux.Vars(r)
 		logID, err := strconv.Atoi(vars["logID"])
 		if err != nil {
@@ -231,13 +298,370 @@ func DeleteLogHandler(service *AgentClientLogService) http.HandlerFunc {
 			return
 		}
 
-		err = service.DeleteLog(logID)
+		var body struct {
+			Reason string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		redaction, err := service.RedactLog(r.Context(), logID, userCtx["id"].(int), body.Reason)
+		switch {
+		case errors.Is(err, ErrLogNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, ErrLogAlreadyRedacted), errors.Is(err, ErrRedactRedaction):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(redaction)
+	}
+}
+
+// VerifyLogChainHandler walks the log hash chain and reports any breaks. Restricted to Admin.
+func VerifyLogChainHandler(service *AgentClientLogService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userCtx := r.Context().Value("user").(map[string]interface{})
+		if userCtx["role"].(string) != "Admin" {
+			http.Error(w, "Unauthorized: only Admin can verify logs", http.StatusForbidden)
+			return
+		}
+
+		report, err := service.VerifyChain()
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+// RevertLogHandler reverts the change an Update log entry recorded, restoring the "before"
+// values. If any field has changed since, nothing is applied and 409 returns the conflicts.
+// Restricted to Admin.
+func RevertLogHandler(service *AgentClientLogService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userCtx := r.Context().Value("user").(map[string]interface{})
+		if userCtx["role"].(string) != "Admin" {
+			http.Error(w, "Unauthorized: only Admin can revert changes", http.StatusForbidden)
+			return
+		}
+
+		vars := mux.Vars(r)
+		logID, err := strconv.Atoi(vars["logID"])
+		if err != nil {
+			http.Error(w, "Invalid log ID", http.StatusBadRequest)
+			return
+		}
+
+		result, err := service.RevertLog(r.Context(), logID, userCtx["id"].(int))
+		switch {
+		case errors.Is(err, ErrLogNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, ErrRevertConflict):
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(result)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// streamFilters are the query parameters a log stream can be narrowed by
+var streamFilters = []string{"agent_id", "client_id", "action"}
+
+const (
+	streamBuffer      = 64
+	streamReplayBatch = 100
+	streamHeartbeat   = 15 * time.Second
+)
+
+// StreamLogsHandler streams new agent-client logs as Server-Sent Events, filtered by agent_id,
+// client_id and action. A client resuming with Last-Event-ID (or ?last_event_id=) first
+// receives the logs it missed. Agents only see logs of their own clients.
+func StreamLogsHandler(service *AgentClientLogService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userCtx := r.Context().Value("user").(map[string]interface{})
+		visible := service.VisibleTo(userCtx["id"].(int), userCtx["role"].(string))
+
+		filter := listquery.All(ListSpec.IDColumn)
+		for _, param := range streamFilters {
+			if value := r.URL.Query().Get(param); value != "" {
+				filter = filter.Where(ListSpec.Filters[param].Column, ListSpec.Filters[param].Op, value)
+			}
+		}
+
+		lastEventID := r.Header.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = r.URL.Query().Get("last_event_id")
+		}
+		lastID := 0
+		if lastEventID != "" {
+			id, err := strconv.Atoi(lastEventID)
+			if err != nil || id < 0 {
+				http.Error(w, "Invalid Last-Event-ID, must be a log ID", http.StatusBadRequest)
+				return
+			}
+			lastID = id
+		}
+
+		// Subscribe before catching up so nothing written meanwhile is missed
+		live, cancel := service.Subscribe(streamBuffer)
+		defer cancel()
+
+		rc := http.NewResponseController(w)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
 		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("Log deleted successfully"))
+
+		send := func(log models.AgentClientLog) error {
+			data, err := json.Marshal(log)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: log\ndata: %s\n\n", log.ID, data); err != nil {
+				return err
+			}
+			return rc.Flush()
+		}
+
+		if lastEventID != "" {
+			for {
+				logs, err := service.LogsAfter(lastID, streamReplayBatch, filter.Conditions...)
+				if err != nil {
+					fmt.Fprintf(w, "event: error\ndata: %s\n\n", err.Error())
+					rc.Flush()
+					return
+				}
+				for _, log := range logs {
+					if visible(log) {
+						if err := send(log); err != nil {
+							return
+						}
+					}
+					lastID = log.ID
+				}
+				if len(logs) < streamReplayBatch {
+					break
+				}
+			}
+		} else if err := rc.Flush(); err != nil {
+			return
+		}
+		caughtUp := lastID
+
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+				if err := rc.Flush(); err != nil {
+					return
+				}
+			case log, ok := <-live:
+				if !ok {
+					// Dropped for falling behind or shutting down; the client resumes with Last-Event-ID
+					return
+				}
+				if log.ID <= caughtUp || !listquery.Matches(log, filter, ListField) || !visible(log) {
+					continue
+				}
+				if err := send(log); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// exportContentTypes maps export formats to the Content-Type they are served with
+var exportContentTypes = map[string]string{
+	ExportCSV:   "text/csv; charset=utf-8",
+	ExportJSONL: "application/x-ndjson",
+}
+
+// ExportLogsHandler streams the logs matching the list filters (client_id, agent_id, action,
+// request_id, from, to) as ?format=csv (default) or jsonl. ?bundle=true wraps the file in a ZIP
+// with a manifest of SHA-256 checksums, signed when a signing key is configured. Agents only
+// export logs of their own clients.
+func ExportLogsHandler(service *AgentClientLogService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userCtx := r.Context().Value("user").(map[string]interface{})
+		agentID := userCtx["id"].(int)
+
+		params := r.URL.Query()
+		opts := ExportOptions{
+			Format:     params.Get("format"),
+			Filters:    map[string]string{},
+			Visible:    service.VisibleTo(agentID, userCtx["role"].(string)),
+			ExportedBy: agentID,
+		}
+		if opts.Format == "" {
+			opts.Format = ExportCSV
+		}
+		contentType, ok := exportContentTypes[opts.Format]
+		if !ok {
+			http.Error(w, "format must be csv or jsonl", http.StatusBadRequest)
+			return
+		}
+		if raw := params.Get("bundle"); raw != "" {
+			bundle, err := strconv.ParseBool(raw)
+			if err != nil {
+				http.Error(w, "bundle must be true or false", http.StatusBadRequest)
+				return
+			}
+			opts.Bundle = bundle
+		}
+
+		for param, filter := range ListSpec.Filters {
+			if value := params.Get(param); value != "" {
+				opts.Conditions = append(opts.Conditions, listquery.Condition{Column: filter.Column, Op: filter.Op, Value: value})
+				opts.Filters[param] = value
+			}
+		}
+
+		fileName := "agentclient_logs-" + time.Now().UTC().Format("20060102T150405Z") + "." + opts.Format
+		if opts.Bundle {
+			contentType, fileName = "application/zip", fileName+".zip"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+
+		// The status is already sent once rows are streaming, so a failure aborts the response
+		// rather than letting a truncated file look complete
+		if err := service.Export(r.Context(), w, opts); err != nil {
+			slog.ErrorContext(r.Context(), "log export failed", "error", err)
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
+
+// asOfLayouts are the forms ?ts= is accepted in; a time without an offset is read in the
+// database's zone, like the logs' own timestamps
+var asOfLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05"}
+
+// parseAsOf converts ?ts= to the logs' timestamp format and zone. A bare date means the end of that day.
+func parseAsOf(raw string) (string, error) {
+	for _, layout := range asOfLayouts {
+		if ts, err := time.ParseInLocation(layout, raw, database.TimeZone); err == nil {
+			return ts.In(database.TimeZone).Format("2006-01-02 15:04:05"), nil
+		}
+	}
+	if day, err := time.ParseInLocation("2006-01-02", raw, database.TimeZone); err == nil {
+		return day.Format("2006-01-02") + " 23:59:59", nil
+	}
+	return "", fmt.Errorf("invalid ts, must be RFC 3339, YYYY-MM-DD HH:MM:SS or YYYY-MM-DD")
+}
+
+// canSeeClientHistory reports whether the caller may read a client's history. Agents may read
+// the history of clients they own, or of deleted clients they created.
+func canSeeClientHistory(service *AgentClientLogService, r *http.Request, clientID string) (bool, error) {
+	userCtx := r.Context().Value("user").(map[string]interface{})
+	if userCtx["role"].(string) == "Admin" {
+		return true, nil
+	}
+
+	first, err := service.LogsAfter(0, 1, listquery.Condition{Column: "client_id", Op: "=", Value: clientID})
+	if err != nil {
+		return false, err
+	}
+	if len(first) == 0 {
+		return true, nil // nothing to leak; the handler answers 404
+	}
+	return service.VisibleTo(userCtx["id"].(int), userCtx["role"].(string))(first[0]), nil
+}
+
+// clientHistoryStatus maps replay errors to HTTP statuses
+func clientHistoryStatus(err error) int {
+	if errors.Is(err, ErrNoClientHistory) || errors.Is(err, ErrClientNotCreated) || errors.Is(err, ErrClientDeleted) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// ClientAsOfHandler reconstructs a client as it was at ?ts= from its audit logs
+func ClientAsOfHandler(service *AgentClientLogService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		clientID := mux.Vars(r)["clientId"]
+
+		raw := r.URL.Query().Get("ts")
+		if raw == "" {
+			http.Error(w, "ts is required", http.StatusBadRequest)
+			return
+		}
+		asOf, err := parseAsOf(raw)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		visible, err := canSeeClientHistory(service, r, clientID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !visible {
+			http.Error(w, "Unauthorized: client is not assigned to this agent", http.StatusForbidden)
+			return
+		}
+
+		snapshot, err := service.ClientAsOf(clientID, asOf)
+		if err != nil {
+			http.Error(w, err.Error(), clientHistoryStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(snapshot)
+	}
+}
+
+// ClientHistoryHandler lists every value a client's fields have held and who changed them,
+// optionally narrowed to one field with ?field=
+func ClientHistoryHandler(service *AgentClientLogService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		clientID := mux.Vars(r)["clientId"]
+
+		visible, err := canSeeClientHistory(service, r, clientID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !visible {
+			http.Error(w, "Unauthorized: client is not assigned to this agent", http.StatusForbidden)
+			return
+		}
+
+		history, err := service.ClientHistory(clientID, r.URL.Query().Get("field"))
+		if err != nil {
+			http.Error(w, err.Error(), clientHistoryStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(history)
 	}
 }
@@ -0,0 +1,31 @@
+package agentclient_logs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAsOfUsesDatabaseZone(t *testing.T) {
+	// The server's own zone makes no difference
+	local := time.Local
+	time.Local = time.FixedZone("SGT", 8*60*60)
+	defer func() { time.Local = local }()
+
+	tests := map[string]string{
+		"2025-03-01T18:30:00+08:00": "2025-03-01 10:30:00",
+		"2025-03-01T10:30:00Z":      "2025-03-01 10:30:00",
+		"2025-03-01 10:30:00":       "2025-03-01 10:30:00",
+		"2025-03-01T10:30:00":       "2025-03-01 10:30:00",
+		"2025-03-01":                "2025-03-01 23:59:59",
+	}
+	for raw, want := range tests {
+		got, err := parseAsOf(raw)
+		if err != nil || got != want {
+			t.Errorf("parseAsOf(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+
+	if _, err := parseAsOf("yesterday"); err == nil {
+		t.Error("parseAsOf accepted yesterday")
+	}
+}
@@ -0,0 +1,232 @@
+package agentclient_logs
+
+import (
+	"archive/zip"
+	"backend/models"
+	"backend/services/listquery"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Export formats
+const (
+	ExportCSV   = "csv"
+	ExportJSONL = "jsonl"
+)
+
+// exportBatch is how many logs an export reads at a time
+const exportBatch = 500
+
+// exportColumns is the CSV header; modified_fields is written as JSON
+var exportColumns = []string{"id", "timestamp", "agent_id", "client_id", "action", "log_type", "request_id", "modified_fields", "hash"}
+
+// ExportOptions selects what an export contains and how it is written
+type ExportOptions struct {
+	Format     string
+	Bundle     bool // wrap the file in a ZIP with a manifest of checksums
+	Conditions []listquery.Condition
+	Filters    map[string]string // as requested, recorded in the manifest
+	Visible    func(log models.AgentClientLog) bool
+	ExportedBy int
+}
+
+// ExportManifest describes a bundle's contents so an auditor can check nothing was altered
+type ExportManifest struct {
+	GeneratedAt string               `json:"generated_at"`
+	GeneratedBy int                  `json:"generated_by"`
+	Filters     map[string]string    `json:"filters"`
+	Files       []ExportManifestFile `json:"files"`
+	// ChainHead is the end of the log hash chain when the export started
+	ChainHead struct {
+		LogID int    `json:"log_id"`
+		Hash  string `json:"hash"`
+	} `json:"chain_head"`
+	// Signature names how manifest.sig signs this file, when the bundle is signed
+	Signature string `json:"signature,omitempty"`
+}
+
+// ExportManifestFile is one file in a bundle
+type ExportManifestFile struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+	Bytes  int64  `json:"bytes"`
+	Rows   int    `json:"rows"`
+}
+
+// SetExportSigningKey sets the key bundles are signed with; without one they are not signed
+func (s *AgentClientLogService) SetExportSigningKey(key []byte) {
+	s.exportSigningKey = key
+}
+
+// Export writes every log matching the options to w, oldest first, a batch at a time so the
+// whole result is never held in memory
+func (s *AgentClientLogService) Export(ctx context.Context, w io.Writer, opts ExportOptions) error {
+	if opts.Format != ExportCSV && opts.Format != ExportJSONL {
+		return fmt.Errorf("unsupported export format %q", opts.Format)
+	}
+	if !opts.Bundle {
+		_, err := s.writeExport(ctx, w, opts)
+		return err
+	}
+	return s.writeBundle(ctx, w, opts)
+}
+
+// writeBundle streams a ZIP holding the logs, manifest.json with their SHA-256 and, if a signing
+// key is set, manifest.sig with the manifest's HMAC-SHA256 ("sha256=<hex>")
+func (s *AgentClientLogService) writeBundle(ctx context.Context, w io.Writer, opts ExportOptions) error {
+	head, err := s.repo.ChainHead()
+	if err != nil {
+		return err
+	}
+
+	manifest := ExportManifest{
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		GeneratedBy: opts.ExportedBy,
+		Filters:     opts.Filters,
+	}
+	manifest.ChainHead.LogID, manifest.ChainHead.Hash = head.LastLogID, head.LastHash
+
+	// Logs appended while the bundle is written are left out, so the file ends at the chain head
+	// in the manifest. Legacy logs not yet sealed into the chain are still exported.
+	opts.Conditions = append(slices.Clone(opts.Conditions), listquery.Condition{Column: "id", Op: "<=", Value: max(head.LastLogID, head.LegacyMaxID)})
+
+	bundle := zip.NewWriter(w)
+	name := "agentclient_logs." + opts.Format
+	file, err := bundle.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(file, hash)}
+	rows, err := s.writeExport(ctx, counter, opts)
+	if err != nil {
+		return err
+	}
+	manifest.Files = append(manifest.Files, ExportManifestFile{Name: name, SHA256: hex.EncodeToString(hash.Sum(nil)), Bytes: counter.n, Rows: rows})
+
+	if len(s.exportSigningKey) > 0 {
+		manifest.Signature = "hmac-sha256"
+	}
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeZipFile(bundle, "manifest.json", manifestJSON); err != nil {
+		return err
+	}
+	if len(s.exportSigningKey) > 0 {
+		mac := hmac.New(sha256.New, s.exportSigningKey)
+		mac.Write(manifestJSON)
+		if err := writeZipFile(bundle, "manifest.sig", []byte("sha256="+hex.EncodeToString(mac.Sum(nil))+"\n")); err != nil {
+			return err
+		}
+	}
+	return bundle.Close()
+}
+
+func writeZipFile(bundle *zip.Writer, name string, data []byte) error {
+	file, err := bundle.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	return err
+}
+
+// writeExport writes the matching logs in the requested format and reports how many it wrote
+func (s *AgentClientLogService) writeExport(ctx context.Context, w io.Writer, opts ExportOptions) (int, error) {
+	var csvWriter *csv.Writer
+	var encoder *json.Encoder
+	if opts.Format == ExportCSV {
+		csvWriter = csv.NewWriter(w)
+		if err := csvWriter.Write(exportColumns); err != nil {
+			return 0, err
+		}
+	} else {
+		encoder = json.NewEncoder(w)
+	}
+
+	rows := 0
+	for afterID := 0; ; {
+		if err := ctx.Err(); err != nil {
+			return rows, err
+		}
+
+		logs, err := s.LogsAfter(afterID, exportBatch, opts.Conditions...)
+		if err != nil {
+			return rows, err
+		}
+
+		for _, log := range logs {
+			afterID = log.ID
+			if opts.Visible != nil && !opts.Visible(log) {
+				continue
+			}
+
+			if csvWriter != nil {
+				record, err := csvRecord(log)
+				if err != nil {
+					return rows, err
+				}
+				if err := csvWriter.Write(record); err != nil {
+					return rows, err
+				}
+			} else if err := encoder.Encode(log); err != nil {
+				return rows, err
+			}
+			rows++
+		}
+
+		if csvWriter != nil {
+			csvWriter.Flush()
+			if err := csvWriter.Error(); err != nil {
+				return rows, err
+			}
+		}
+		if len(logs) < exportBatch {
+			return rows, nil
+		}
+	}
+}
+
+func csvRecord(log models.AgentClientLog) ([]string, error) {
+	modifiedFields, err := json.Marshal(log.ModifiedFields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert modified fields to JSON: %v", err)
+	}
+	logType, _ := log.ModifiedFields["log_type"].(string)
+
+	return []string{
+		strconv.Itoa(log.ID),
+		log.Timestamp,
+		strconv.Itoa(log.AgentID),
+		log.ClientID,
+		log.Action,
+		logType,
+		log.RequestID,
+		string(modifiedFields),
+		log.Hash,
+	}, nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
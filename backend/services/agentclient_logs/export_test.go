@@ -0,0 +1,83 @@
+package agentclient_logs
+
+import (
+	"archive/zip"
+	"backend/database/memstore"
+	"backend/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func readZipFile(t *testing.T, bundle *zip.Reader, name string) []byte {
+	t.Helper()
+	file, err := bundle.Open(name)
+	if err != nil {
+		t.Fatalf("bundle has no %s: %v", name, err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestExportBundleStopsAtChainHead(t *testing.T) {
+	repo := NewMemoryAgentClientLogRepository(memstore.New())
+	service := NewAgentClientLogService(repo)
+	ctx := context.Background()
+	// A full batch, so the export reads again after it
+	for i := 0; i < exportBatch; i++ {
+		if _, err := service.LogAgentClientAction(ctx, 1, "client1", "Update", map[string]interface{}{"details": map[string]interface{}{}}); err != nil {
+			t.Fatalf("LogAgentClientAction: %v", err)
+		}
+	}
+
+	// A log appended once the export has started is not part of it
+	appended := false
+	visible := func(models.AgentClientLog) bool {
+		if !appended {
+			appended = true
+			if _, err := service.LogAgentClientAction(ctx, 1, "client1", "Delete", map[string]interface{}{"details": map[string]interface{}{}}); err != nil {
+				t.Errorf("LogAgentClientAction: %v", err)
+			}
+		}
+		return true
+	}
+
+	var out bytes.Buffer
+	if err := service.Export(ctx, &out, ExportOptions{Format: ExportJSONL, Bundle: true, Visible: visible}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if !appended {
+		t.Fatal("no log was appended during the export")
+	}
+
+	bundle, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatalf("bundle is not a ZIP: %v", err)
+	}
+	var manifest ExportManifest
+	if err := json.Unmarshal(readZipFile(t, bundle, "manifest.json"), &manifest); err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	if manifest.ChainHead.LogID != exportBatch || len(manifest.Files) != 1 || manifest.Files[0].Rows != exportBatch {
+		t.Fatalf("manifest = %+v, want %d rows up to the chain head", manifest, exportBatch)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(readZipFile(t, bundle, "agentclient_logs.jsonl"))), "\n")
+	if len(lines) != exportBatch {
+		t.Fatalf("exported %d logs, want %d", len(lines), exportBatch)
+	}
+	var last models.AgentClientLog
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &last); err != nil {
+		t.Fatal(err)
+	}
+	if last.ID != exportBatch || last.Hash != manifest.ChainHead.Hash {
+		t.Errorf("last exported log %d with hash %s, want the chain head %s", last.ID, last.Hash, manifest.ChainHead.Hash)
+	}
+}
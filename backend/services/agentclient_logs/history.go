@@ -0,0 +1,225 @@
+package agentclient_logs
+
+import (
+	"backend/models"
+	"backend/services/listquery"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrNoClientHistory  = errors.New("no audit history for client")
+	ErrClientNotCreated = errors.New("client did not exist yet at that time")
+	ErrClientDeleted    = errors.New("client had been deleted by that time")
+)
+
+// historyBatch is how many logs a replay reads at a time
+const historyBatch = 500
+
+// clientFieldNames maps models.Client's Go field names, which Update logs written before the
+// diff engine key changes by, to the JSON names every other log uses
+var clientFieldNames = jsonFieldNames(reflect.TypeOf(models.Client{}))
+
+// jsonFieldNames maps each field of a struct type, by Go name and by JSON name, to its JSON name
+func jsonFieldNames(t reflect.Type) map[string]string {
+	names := make(map[string]string)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		names[t.Field(i).Name] = name
+		names[name] = name
+	}
+	return names
+}
+
+func clientFieldName(key string) string {
+	if name, ok := clientFieldNames[key]; ok {
+		return name
+	}
+	return key
+}
+
+// maskedPaths is the set of paths a log lists as masked
+func maskedPaths(log models.AgentClientLog) map[string]bool {
+	paths := make(map[string]bool)
+	listed, _ := log.ModifiedFields[MaskedField].([]interface{})
+	for _, path := range listed {
+		if path, ok := path.(string); ok {
+			paths[path] = true
+		}
+	}
+	return paths
+}
+
+// clientReplay is the state of a client part way through its logs. state is nil before the
+// Create and after the Delete; masked holds the fields whose value in state is masked.
+type clientReplay struct {
+	state    map[string]interface{}
+	masked   map[string]bool
+	created  *models.AgentClientLog
+	deleted  *models.AgentClientLog
+	last     *models.AgentClientLog
+	headless bool // the first readable log was not a Create
+	missing  []int
+}
+
+// replayClient applies a client's logs, oldest first, up to and including asOf ("" for all of
+// them), calling onChange for every field value a log sets
+func (s *AgentClientLogService) replayClient(clientID, asOf string, onChange func(log models.AgentClientLog, field string, before, after interface{}, masked bool)) (*clientReplay, error) {
+	conditions := []listquery.Condition{
+		{Column: "client_id", Op: "=", Value: clientID},
+		{Column: LogTypeColumn, Op: "=", Value: "client"},
+	}
+	if asOf != "" {
+		conditions = append(conditions, listquery.Condition{Column: "timestamp", Op: "<=", Value: asOf})
+	}
+
+	replay := &clientReplay{masked: make(map[string]bool)}
+	seen := false
+	for afterID := 0; ; {
+		logs, err := s.LogsAfter(afterID, historyBatch, conditions...)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range logs {
+			log := logs[i]
+			afterID = log.ID
+			if log.RedactedBy != nil || log.ModifiedFields["redacted"] == true {
+				replay.missing = append(replay.missing, log.ID)
+				continue
+			}
+			details, _ := log.ModifiedFields["details"].(map[string]interface{})
+			masked := maskedPaths(log)
+
+			switch log.Action {
+			case "Create":
+				replay.state, replay.masked = make(map[string]interface{}), masked
+				for field, value := range details {
+					replay.state[field] = value
+					onChange(log, field, nil, value, masked[field])
+				}
+				replay.created, replay.deleted = &log, nil
+			case "Update", RevertAction:
+				if replay.state == nil {
+					replay.headless = replay.headless || !seen
+					replay.state = make(map[string]interface{})
+				}
+				for key, raw := range details {
+					change, _ := raw.(map[string]interface{})
+					field := clientFieldName(key)
+					replay.state[field] = change["after"]
+					replay.masked[field] = masked[key]
+					onChange(log, field, change["before"], change["after"], masked[key])
+				}
+			case "Delete":
+				replay.state, replay.deleted = nil, &log
+			default:
+				continue
+			}
+			seen = true
+			replay.last = &log
+		}
+
+		if len(logs) < historyBatch {
+			break
+		}
+	}
+
+	if !seen && len(replay.missing) == 0 {
+		return nil, ErrNoClientHistory
+	}
+	return replay, nil
+}
+
+// ClientAsOf reconstructs a client as it was at asOf ("2006-01-02 15:04:05", the logs' own
+// timestamp format) by replaying its Create and every Update logged up to then
+func (s *AgentClientLogService) ClientAsOf(clientID, asOf string) (models.ClientSnapshot, error) {
+	replay, err := s.replayClient(clientID, asOf, func(models.AgentClientLog, string, interface{}, interface{}, bool) {})
+	if errors.Is(err, ErrNoClientHistory) {
+		// Nothing up to asOf; tell a client created later apart from one never logged
+		later, laterErr := s.LogsAfter(0, 1, listquery.Condition{Column: "client_id", Op: "=", Value: clientID})
+		if laterErr != nil {
+			return models.ClientSnapshot{}, laterErr
+		}
+		if len(later) > 0 {
+			err = ErrClientNotCreated
+		}
+	}
+	if err != nil {
+		return models.ClientSnapshot{}, err
+	}
+	if replay.deleted != nil && replay.state == nil {
+		return models.ClientSnapshot{}, fmt.Errorf("%w (deleted at %s)", ErrClientDeleted, replay.deleted.Timestamp)
+	}
+	if replay.state == nil {
+		return models.ClientSnapshot{}, ErrClientNotCreated
+	}
+
+	stateJSON, err := json.Marshal(replay.state)
+	if err != nil {
+		return models.ClientSnapshot{}, fmt.Errorf("failed to encode client state: %v", err)
+	}
+	snapshot := models.ClientSnapshot{
+		AsOf:          asOf,
+		UpdatedAt:     replay.last.Timestamp,
+		UpdatedBy:     replay.last.AgentID,
+		LastLogID:     replay.last.ID,
+		Complete:      !replay.headless && len(replay.missing) == 0,
+		MissingLogIDs: replay.missing,
+	}
+	if err := json.Unmarshal(stateJSON, &snapshot.Client); err != nil {
+		return models.ClientSnapshot{}, fmt.Errorf("failed to decode client state: %v", err)
+	}
+	if snapshot.Client.ClientID == "" {
+		snapshot.Client.ClientID = clientID
+	}
+	if replay.created != nil {
+		snapshot.CreatedAt, snapshot.CreatedBy = replay.created.Timestamp, replay.created.AgentID
+	}
+	for field, masked := range replay.masked {
+		if masked {
+			snapshot.MaskedFields = append(snapshot.MaskedFields, field)
+		}
+	}
+	sort.Strings(snapshot.MaskedFields)
+	return snapshot, nil
+}
+
+// ClientHistory lists every value each of a client's fields has held and who set it. field,
+// a JSON name, narrows the result to one field; "" returns them all.
+func (s *AgentClientLogService) ClientHistory(clientID, field string) (models.ClientFieldHistory, error) {
+	history := models.ClientFieldHistory{ClientID: clientID, Fields: make(map[string][]models.FieldChange)}
+	if field != "" {
+		field = clientFieldName(field)
+	}
+
+	replay, err := s.replayClient(clientID, "", func(log models.AgentClientLog, name string, before, after interface{}, masked bool) {
+		if field != "" && name != field {
+			return
+		}
+		history.Fields[name] = append(history.Fields[name], models.FieldChange{
+			LogID:     log.ID,
+			Action:    log.Action,
+			AgentID:   log.AgentID,
+			Timestamp: log.Timestamp,
+			RequestID: log.RequestID,
+			Before:    before,
+			After:     after,
+			Masked:    masked,
+		})
+	})
+	if err != nil {
+		return models.ClientFieldHistory{}, err
+	}
+
+	if replay.deleted != nil {
+		history.DeletedAt, history.DeletedBy = replay.deleted.Timestamp, replay.deleted.AgentID
+	}
+	history.Complete = !replay.headless && len(replay.missing) == 0
+	history.MissingLogIDs = replay.missing
+	return history, nil
+}
@@ -0,0 +1,186 @@
+package agentclient_logs
+
+import (
+	"backend/database/memstore"
+	"backend/models"
+	"backend/services/events"
+	"backend/services/interfaces"
+	"backend/services/listquery"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var _ interfaces.AgentClientLogRepositoryInterface = (*MemoryAgentClientLogRepository)(nil)
+
+// MemoryAgentClientLogRepository is an in-memory implementation of interfaces.AgentClientLogRepositoryInterface
+type MemoryAgentClientLogRepository struct {
+	store *memstore.Store
+}
+
+// NewMemoryAgentClientLogRepository initializes a new MemoryAgentClientLogRepository on the given store
+func NewMemoryAgentClientLogRepository(store *memstore.Store) *MemoryAgentClientLogRepository {
+	return &MemoryAgentClientLogRepository{store: store}
+}
+
+// insertLocked stores a log at the end of the hash chain, round-tripping its fields (log_type,
+// details and any links) through JSON like the MySQL column does. A log recording an outbox
+// event that is already logged is not stored again: ErrAlreadyLogged. The caller must hold store.Mu.
+func (r *MemoryAgentClientLogRepository) insertLocked(agentID int, clientID string, action string, fields map[string]interface{}, requestID string, source models.LogSource) (models.AgentClientLog, error) {
+	if _, ok := r.store.LoggedEvents[source]; source.EventID != 0 && ok {
+		return models.AgentClientLog{}, ErrAlreadyLogged
+	}
+
+	modifiedFieldsJSON, err := json.Marshal(fields)
+	if err != nil {
+		return models.AgentClientLog{}, fmt.Errorf("failed to convert modified fields to JSON: %v", err)
+	}
+
+	var modifiedFields map[string]interface{}
+	if err := json.Unmarshal(modifiedFieldsJSON, &modifiedFields); err != nil {
+		return models.AgentClientLog{}, fmt.Errorf("failed to decode modified fields: %v", err)
+	}
+	// Hash the fields as they are stored and read back, which is how ListChain will see them
+	if modifiedFieldsJSON, err = json.Marshal(modifiedFields); err != nil {
+		return models.AgentClientLog{}, fmt.Errorf("failed to convert modified fields to JSON: %v", err)
+	}
+
+	log := models.AgentClientLog{
+		ID:             r.store.NextLogID,
+		AgentID:        agentID,
+		ClientID:       clientID,
+		Action:         action,
+		ModifiedFields: modifiedFields,
+		Timestamp:      time.Now().UTC().Format("2006-01-02 15:04:05"),
+		RequestID:      requestID,
+	}
+	log.ContentHash = contentHash(log.ID, log.AgentID, log.ClientID, log.Action, string(modifiedFieldsJSON), log.Timestamp, log.RequestID)
+	log.PrevHash = r.store.LogChainHead.LastHash
+	log.Hash = chainHash(log.PrevHash, log.ContentHash)
+
+	r.store.NextLogID++
+	r.store.AgentClientLogs = append(r.store.AgentClientLogs, log)
+	r.store.LogChainHead.LastLogID, r.store.LogChainHead.LastHash = log.ID, log.Hash
+	if source.EventID != 0 {
+		r.store.LoggedEvents[source] = log.ID
+	}
+
+	return log, nil
+}
+
+// CreateAgentClientLog stores a new client log and records ClientChangeLogged
+func (r *MemoryAgentClientLogRepository) CreateAgentClientLog(agentID int, clientID string, action string, modifiedFields map[string]interface{}, meta models.EventMeta, source models.LogSource) (models.AgentClientLog, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	log, err := r.insertLocked(agentID, clientID, action, loggedFields("client", modifiedFields), meta.RequestID, source)
+	if err != nil {
+		return models.AgentClientLog{}, err
+	}
+	if err := events.AppendLocked(r.store, meta, events.ClientChangeLogged{Log: log}); err != nil {
+		return models.AgentClientLog{}, err
+	}
+	return log, nil
+}
+
+// LogAccountChange stores a new bank account log
+func (r *MemoryAgentClientLogRepository) LogAccountChange(agentID int, clientID string, action string, bankAccountInfo map[string]interface{}, requestID string, source models.LogSource) (models.AgentClientLog, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	return r.insertLocked(agentID, clientID, action, loggedFields("bank_account", bankAccountInfo), requestID, source)
+}
+
+// ListLogs retrieves one page of logs matching the query
+func (r *MemoryAgentClientLogRepository) ListLogs(q listquery.Query) ([]models.AgentClientLog, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	logs := append([]models.AgentClientLog{}, r.store.AgentClientLogs...)
+	return listquery.Apply(logs, q, ListField), nil
+}
+
+// RedactLog appends a Redact entry for a log and blanks the log's content, keeping its log_type and hashes
+func (r *MemoryAgentClientLogRepository) RedactLog(logID int, agentID int, reason string, requestID string) (models.AgentClientLog, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	index := -1
+	for i, log := range r.store.AgentClientLogs {
+		if log.ID == logID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return models.AgentClientLog{}, ErrLogNotFound
+	}
+	target := r.store.AgentClientLogs[index]
+	if target.RedactedBy != nil {
+		return models.AgentClientLog{}, ErrLogAlreadyRedacted
+	}
+	if target.Action == RedactAction {
+		return models.AgentClientLog{}, ErrRedactRedaction
+	}
+
+	redaction, err := r.insertLocked(agentID, target.ClientID, RedactAction, map[string]interface{}{"log_type": RedactionLogType, "details": map[string]interface{}{"log_id": logID, "reason": reason}}, requestID, models.LogSource{})
+	if err != nil {
+		return models.AgentClientLog{}, err
+	}
+
+	target.ModifiedFields = map[string]interface{}{"log_type": target.ModifiedFields["log_type"], "redacted": true}
+	target.RedactedBy = &redaction.ID
+	r.store.AgentClientLogs[index] = target
+	return redaction, nil
+}
+
+// ListChain retrieves up to limit logs after afterID in ID order, with the hash of their stored
+// content, and the tombstones of purged logs in their place
+func (r *MemoryAgentClientLogRepository) ListChain(afterID int, limit int) ([]models.LogChainEntry, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	var entries []models.LogChainEntry
+	for _, tombstone := range r.store.LogTombstones {
+		if tombstone.LogID > afterID {
+			entries = append(entries, models.LogChainEntry{
+				Log:      models.AgentClientLog{ID: tombstone.LogID, ContentHash: tombstone.ContentHash, PrevHash: tombstone.PrevHash, Hash: tombstone.Hash},
+				Archived: true,
+			})
+		}
+	}
+	for _, log := range r.store.AgentClientLogs {
+		if log.ID <= afterID {
+			continue
+		}
+
+		modifiedFieldsJSON, err := json.Marshal(log.ModifiedFields)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert modified fields to JSON: %v", err)
+		}
+		entries = append(entries, models.LogChainEntry{
+			Log:                 log,
+			ComputedContentHash: contentHash(log.ID, log.AgentID, log.ClientID, log.Action, string(modifiedFieldsJSON), log.Timestamp, log.RequestID),
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Log.ID < entries[j].Log.ID })
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// ChainHead returns the end of the log chain
+func (r *MemoryAgentClientLogRepository) ChainHead() (models.LogChainHead, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	return r.store.LogChainHead, nil
+}
+
+// SealLegacy has nothing to seal; every log in the store is chained as it is inserted
+func (r *MemoryAgentClientLogRepository) SealLegacy() (int, error) {
+	return 0, nil
+}
@@ -1,50 +1,37 @@
 package agentclient_logs
 
 import (
-	"backend/database"
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"backend/models"
+	"backend/services/events"
+	"backend/services/listquery"
+	"backend/services/metrics"
 )
 
-// AgentClientLogRepository handles database operations
-type AgentClientLogRepository struct{}
-
-// NewAgentClientLogRepository initializes the repository
-func NewAgentClientLogRepository() *AgentClientLogRepository {
-	repo := &AgentClientLogRepository{}
-	repo.InitTable()
-	return repo
+// AgentClientLogRepository is the MySQL implementation of interfaces.AgentClientLogRepositoryInterface
+type AgentClientLogRepository struct {
+	db *sql.DB
 }
 
-// InitTable ensures the agent_client_logs table exists
-func (r *AgentClientLogRepository) InitTable() {
-	query := `
-	CREATE TABLE IF NOT EXISTS agent_client_logs (
-		id INT AUTO_INCREMENT PRIMARY KEY,
-		agent_id INT NOT NULL,
-		client_id VARCHAR(255) NOT NULL,
-		action VARCHAR(50) NOT NULL,
-		modified_fields JSON NOT NULL,
-		timestamp DATETIME DEFAULT CURRENT_TIMESTAMP
-	);`
-	_, err := database.DB.Exec(query)
-	if err != nil {
-		fmt.Println("❌ Error creating agent_client_logs table:", err)
-	} else {
-		fmt.Println("✅ agent_client_logs table checked/created!")
-	}
+// NewAgentClientLogRepository initializes the repository
+func NewAgentClientLogRepository(db *sql.DB) *AgentClientLogRepository {
+	return &AgentClientLogRepository{db: db}
 }
 
 // CreateAgentClientLog inserts a new agent-client log into the database
-func (r *AgentClientLogRepository) CreateAgentClientLog(agentID int, clientID string, action string, modifiedFields map[string]interface{}) (models.AgentClientLog, error) {
+func (r *AgentClientLogRepository) CreateAgentClientLog(agentID int, clientID string, action string, modifiedFields map[string]interface{}, meta models.EventMeta, source models.LogSource) (models.AgentClientLog, error) {
+	defer metrics.ObserveQuery("AgentClientLogRepository", "CreateAgentClientLog", time.Now())
 	// No need to create timestamp manually, MySQL will do it for you
 	logData := models.AgentClientLog{
 		AgentID:        agentID,
 		ClientID:       clientID,
 		Action:         action,
-		ModifiedFields: map[string]interface{}{"log_type": "client", "details": modifiedFields["details"]},
+		ModifiedFields: loggedFields("client", modifiedFields),
+		RequestID:      meta.RequestID,
 		// No need to pass Timestamp here, MySQL will fill it automatically
 	}
 
@@ -54,350 +41,416 @@ func (r *AgentClientLogRepository) CreateAgentClientLog(agentID int, clientID st
 		return models.AgentClientLog{}, fmt.Errorf("failed to convert modified fields to JSON: %v", err)
 	}
 
-	// Insert the log into the agent_client_logs table
-	query := "INSERT INTO agent_client_logs (agent_id, client_id, action, modified_fields) VALUES (?, ?, ?, ?)"
-	result, err := database.DB.Exec(query, agentID, clientID, action, modifiedFieldsJSON)
+	tx, err := r.db.Begin()
 	if err != nil {
-		return models.AgentClientLog{}, fmt.Errorf("failed to insert agent-client log: %v", err)
+		return models.AgentClientLog{}, fmt.Errorf("failed to begin transaction: %v", err)
 	}
+	defer tx.Rollback()
 
-	// Get the auto-generated log_id (the ID of the newly inserted log)
-	logID, err := result.LastInsertId()
+	// Insert the log into the agent_client_logs table, chained to the previous entry
+	logData, err = appendLogTx(tx, logData, modifiedFieldsJSON, source)
 	if err != nil {
-		return models.AgentClientLog{}, fmt.Errorf("failed to get the last inserted log_id: %v", err)
+		return models.AgentClientLog{}, err
 	}
 
-	// Update the logData with the generated logID
-	logData.ID = int(logID) // Type assertion since `LastInsertId()` returns int64
+	// ClientChangeLogged drives the communication email
+	if err := events.AppendTx(tx, meta, events.ClientChangeLogged{Log: logData}); err != nil {
+		return models.AgentClientLog{}, err
+	}
 
+	if err := tx.Commit(); err != nil {
+		return models.AgentClientLog{}, fmt.Errorf("failed to commit agent-client log: %v", err)
+	}
 	return logData, nil
 }
 
-// GetClientTransactionLogsByClientID retrieves all client logs for a specific client
-func (r *AgentClientLogRepository) GetClientTransactionLogsByClientID(clientID string) ([]models.AgentClientLog, error) {
-	query := `SELECT id, agent_id, client_id, action, modified_fields, timestamp FROM agent_client_logs
-		WHERE client_id = ? AND JSON_UNQUOTE(JSON_EXTRACT(modified_fields, '$.log_type')) = 'client'`
-	rows, err := database.DB.Query(query, clientID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve logs for client: %v", err)
+// LogAccountChange inserts a new bank account log into the database
+func (r *AgentClientLogRepository) LogAccountChange(agentID int, clientID string, action string, bankAccountInfo map[string]interface{}, requestID string, source models.LogSource) (models.AgentClientLog, error) {
+	defer metrics.ObserveQuery("AgentClientLogRepository", "LogAccountChange", time.Now())
+	// Log data for bank account
+	logData := models.AgentClientLog{
+		AgentID:        agentID,
+		ClientID:       clientID,
+		Action:         action,                                                                                    // "Create", "Update", "Delete"
+		ModifiedFields: loggedFields("bank_account", bankAccountInfo), // "log_type": "bank_account"
+		RequestID:      requestID,
+		// Don't manually set Timestamp, MySQL will handle it with CURRENT_TIMESTAMP
 	}
-	defer rows.Close()
 
-	var logs []models.AgentClientLog
-	for rows.Next() {
-		var log models.AgentClientLog
-		var modifiedFieldsJSON string
+	// Convert modified fields to JSON
+	modifiedFieldsJSON, err := json.Marshal(logData.ModifiedFields)
+	if err != nil {
+		return models.AgentClientLog{}, fmt.Errorf("failed to convert modified fields to JSON: %v", err)
+	}
 
-		if err := rows.Scan(&log.ID, &log.AgentID, &log.ClientID, &log.Action, &modifiedFieldsJSON, &log.Timestamp); err != nil {
-			return nil, err
-		}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.AgentClientLog{}, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
 
-		err = json.Unmarshal([]byte(modifiedFieldsJSON), &log.ModifiedFields)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decode modified fields: %v", err)
-		}
+	// Insert the log into the agent_client_logs table, chained to the previous entry
+	logData, err = appendLogTx(tx, logData, modifiedFieldsJSON, source)
+	if err != nil {
+		return models.AgentClientLog{}, err
+	}
 
-		logs = append(logs, log)
+	if err := tx.Commit(); err != nil {
+		return models.AgentClientLog{}, fmt.Errorf("failed to commit bank account log: %v", err)
 	}
-	return logs, nil
+	return logData, nil
 }
 
-// GetClientTransactionLogsByAgentID retrieves all client logs for a specific agent
-func (r *AgentClientLogRepository) GetClientTransactionLogsByAgentID(agentID int) ([]models.AgentClientLog, error) {
-	query := `SELECT id, agent_id, client_id, action, modified_fields, timestamp FROM agent_client_logs
-		WHERE agent_id = ? AND JSON_UNQUOTE(JSON_EXTRACT(modified_fields, '$.log_type')) = 'client'`
-	rows, err := database.DB.Query(query, agentID)
+// chainContent is the text a log's content_hash covers; contentHash in chain.go builds the same
+// text for the in-memory store
+const chainContent = "CONCAT_WS('|', id, agent_id, client_id, action, modified_fields, timestamp, request_id)"
+
+// lockChainHead locks the end of the chain for the rest of the transaction, so logs are
+// inserted and chained one at a time
+func lockChainHead(tx *sql.Tx) (models.LogChainHead, error) {
+	var head models.LogChainHead
+	err := tx.QueryRow(`SELECT last_log_id, last_hash, legacy_max_id FROM agent_client_log_chain WHERE id = 1 FOR UPDATE`).
+		Scan(&head.LastLogID, &head.LastHash, &head.LegacyMaxID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve logs for agent: %v", err)
+		return models.LogChainHead{}, fmt.Errorf("failed to lock the log chain: %v", err)
 	}
-	defer rows.Close()
+	return head, nil
+}
 
-	var logs []models.AgentClientLog
-	for rows.Next() {
-		var log models.AgentClientLog
-		var modifiedFieldsJSON string
+// sealLegacyTx chains the logs that predate the chain, in ID order, onto a locked head and
+// returns how many it sealed
+func sealLegacyTx(tx *sql.Tx, head *models.LogChainHead) (int, error) {
+	if head.LastLogID >= head.LegacyMaxID {
+		return 0, nil
+	}
 
-		if err := rows.Scan(&log.ID, &log.AgentID, &log.ClientID, &log.Action, &modifiedFieldsJSON, &log.Timestamp); err != nil {
-			return nil, err
+	rows, err := tx.Query(`SELECT id, SHA2(`+chainContent+`, 256) FROM agent_client_logs WHERE id > ? AND id <= ? ORDER BY id`, head.LastLogID, head.LegacyMaxID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read legacy logs: %v", err)
+	}
+	type legacyLog struct {
+		id          int
+		contentHash string
+	}
+	var legacy []legacyLog
+	for rows.Next() {
+		var log legacyLog
+		if err := rows.Scan(&log.id, &log.contentHash); err != nil {
+			rows.Close()
+			return 0, err
 		}
+		legacy = append(legacy, log)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating legacy logs: %v", err)
+	}
 
-		err = json.Unmarshal([]byte(modifiedFieldsJSON), &log.ModifiedFields)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decode modified fields: %v", err)
+	for _, log := range legacy {
+		if err := sealTx(tx, head, log.id, log.contentHash); err != nil {
+			return 0, err
 		}
+	}
+	head.LastLogID = head.LegacyMaxID
+	if err := saveChainHead(tx, *head); err != nil {
+		return 0, err
+	}
+	return len(legacy), nil
+}
 
-		logs = append(logs, log)
+// sealTx chains one log after the head and advances the head in memory
+func sealTx(tx *sql.Tx, head *models.LogChainHead, logID int, contentHash string) error {
+	hash := chainHash(head.LastHash, contentHash)
+	_, err := tx.Exec(`UPDATE agent_client_logs SET content_hash = ?, prev_hash = ?, hash = ? WHERE id = ?`, contentHash, head.LastHash, hash, logID)
+	if err != nil {
+		return fmt.Errorf("failed to seal log %d: %v", logID, err)
 	}
-	return logs, nil
+	head.LastLogID, head.LastHash = logID, hash
+	return nil
 }
 
-// GetAllClientTransactionLogs retrieves all client transaction logs
-func (r *AgentClientLogRepository) GetAllClientTransactionLogs() ([]models.AgentClientLog, error) {
-	query := `SELECT id, agent_id, client_id, action, modified_fields, timestamp FROM agent_client_logs
-		WHERE JSON_UNQUOTE(JSON_EXTRACT(modified_fields, '$.log_type')) = 'client'`
-	rows, err := database.DB.Query(query)
+func saveChainHead(tx *sql.Tx, head models.LogChainHead) error {
+	_, err := tx.Exec(`UPDATE agent_client_log_chain SET last_log_id = ?, last_hash = ? WHERE id = 1`, head.LastLogID, head.LastHash)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve all agent-client logs: %v", err)
+		return fmt.Errorf("failed to advance the log chain: %v", err)
 	}
-	defer rows.Close()
+	return nil
+}
 
-	var logs []models.AgentClientLog
-	for rows.Next() {
-		var log models.AgentClientLog
-		var modifiedFieldsJSON string
+// appendLogTx inserts a log at the end of the chain and returns it with its ID, timestamp and hashes.
+// A log recording an outbox event that is already logged is not inserted again: ErrAlreadyLogged.
+func appendLogTx(tx *sql.Tx, logData models.AgentClientLog, modifiedFieldsJSON []byte, source models.LogSource) (models.AgentClientLog, error) {
+	head, err := lockChainHead(tx)
+	if err != nil {
+		return models.AgentClientLog{}, err
+	}
 
-		if err := rows.Scan(&log.ID, &log.AgentID, &log.ClientID, &log.Action, &modifiedFieldsJSON, &log.Timestamp); err != nil {
-			return nil, err
+	// The chain lock serializes appends, so an event delivered twice at once is seen here
+	var eventID interface{}
+	if source.EventID != 0 {
+		var existing int
+		err := tx.QueryRow(`SELECT id FROM agent_client_logs WHERE event_id = ? AND event_side = ?`, source.EventID, source.Side).Scan(&existing)
+		if err == nil {
+			return models.AgentClientLog{}, ErrAlreadyLogged
 		}
-
-		err = json.Unmarshal([]byte(modifiedFieldsJSON), &log.ModifiedFields)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decode modified fields: %v", err)
+		if err != sql.ErrNoRows {
+			return models.AgentClientLog{}, fmt.Errorf("failed to look up the log of event %d: %v", source.EventID, err)
 		}
-
-		logs = append(logs, log)
+		eventID = source.EventID
 	}
-	return logs, nil
-}
-
-// LogAccountChange inserts a new bank account log into the database
-func (r *AgentClientLogRepository) LogAccountChange(agentID int, clientID string, action string, bankAccountInfo map[string]interface{}) error {
-	// Log data for bank account
-	logData := models.AgentClientLog{
-		AgentID:        agentID,
-		ClientID:       clientID,
-		Action:         action,                                                                                    // "Create", "Update", "Delete"
-		ModifiedFields: map[string]interface{}{"log_type": "bank_account", "details": bankAccountInfo["details"]}, // "log_type": "bank_account"
-		// Don't manually set Timestamp, MySQL will handle it with CURRENT_TIMESTAMP
+	if _, err := sealLegacyTx(tx, &head); err != nil {
+		return models.AgentClientLog{}, err
 	}
 
-	// Convert modified fields to JSON
-	modifiedFieldsJSON, err := json.Marshal(logData.ModifiedFields)
+	query := "INSERT INTO agent_client_logs (agent_id, client_id, action, modified_fields, request_id, event_id, event_side) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	result, err := tx.Exec(query, logData.AgentID, logData.ClientID, logData.Action, modifiedFieldsJSON, logData.RequestID, eventID, source.Side)
 	if err != nil {
-		return fmt.Errorf("failed to convert modified fields to JSON: %v", err)
+		return models.AgentClientLog{}, fmt.Errorf("failed to insert agent-client log: %v", err)
 	}
 
-	// Insert the log into the agent_client_logs table, without passing the timestamp
-	query := `
-		INSERT INTO agent_client_logs (agent_id, client_id, action, modified_fields)
-		VALUES (?, ?, ?, ?)
-	`
-
-	_, err = database.DB.Exec(query, agentID, clientID, action, modifiedFieldsJSON)
+	// Get the auto-generated log_id (the ID of the newly inserted log)
+	logID, err := result.LastInsertId()
 	if err != nil {
-		return fmt.Errorf("failed to create log: %v", err)
+		return models.AgentClientLog{}, fmt.Errorf("failed to get the last inserted log_id: %v", err)
 	}
+	logData.ID = int(logID)
 
-	return nil
-}
-
-// GetAccountLogsByClientID retrieves all bank account logs for a specific client
-func (r *AgentClientLogRepository) GetAccountLogsByClientID(clientID string) ([]models.AgentClientLog, error) {
-	query := `
-		SELECT id, agent_id, client_id, action, modified_fields, timestamp
-		FROM agent_client_logs
-		WHERE client_id = ? AND JSON_UNQUOTE(JSON_EXTRACT(modified_fields, '$.log_type')) = 'bank_account'
-	`
-	rows, err := database.DB.Query(query, clientID)
+	// Hash the row as stored, timestamp included
+	err = tx.QueryRow(`SELECT timestamp, SHA2(`+chainContent+`, 256) FROM agent_client_logs WHERE id = ?`, logData.ID).
+		Scan(&logData.Timestamp, &logData.ContentHash)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve bank account logs for client by client_id: %v", err)
+		return models.AgentClientLog{}, fmt.Errorf("failed to hash log %d: %v", logData.ID, err)
 	}
-	defer rows.Close()
 
-	var logs []models.AgentClientLog
-	for rows.Next() {
-		var log models.AgentClientLog
-		var modifiedFieldsJSON string
+	logData.PrevHash = head.LastHash
+	if err := sealTx(tx, &head, logData.ID, logData.ContentHash); err != nil {
+		return models.AgentClientLog{}, err
+	}
+	logData.Hash = head.LastHash
 
-		if err := rows.Scan(&log.ID, &log.AgentID, &log.ClientID, &log.Action, &modifiedFieldsJSON, &log.Timestamp); err != nil {
-			return nil, err
-		}
+	if err := saveChainHead(tx, head); err != nil {
+		return models.AgentClientLog{}, err
+	}
+	return logData, nil
+}
 
-		err = json.Unmarshal([]byte(modifiedFieldsJSON), &log.ModifiedFields)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decode modified fields: %v", err)
-		}
+// LogTypeColumn extracts the log type ("client" or "bank_account") stored in modified_fields
+const LogTypeColumn = "JSON_UNQUOTE(JSON_EXTRACT(modified_fields, '$.log_type'))"
+
+// ListSpec lists the sort keys and filters accepted by log listings
+var ListSpec = listquery.Spec{
+	IDColumn: "id",
+	SortColumns: map[string]string{
+		"id":        "id",
+		"timestamp": "timestamp",
+	},
+	DefaultSort: "id",
+	Filters: map[string]listquery.Filter{
+		"action":     {Column: "action", Op: "="},
+		"agent_id":   {Column: "agent_id", Op: "="},
+		"client_id":  {Column: "client_id", Op: "="},
+		"request_id": {Column: "request_id", Op: "="},
+		"from":       {Column: "timestamp", Op: ">="},
+		"to":         {Column: "timestamp", Op: "<="},
+	},
+}
 
-		logs = append(logs, log)
+// ListField returns a log's value for a ListSpec column
+func ListField(log models.AgentClientLog, column string) interface{} {
+	switch column {
+	case "id":
+		return log.ID
+	case "agent_id":
+		return log.AgentID
+	case "client_id":
+		return log.ClientID
+	case "action":
+		return log.Action
+	case "timestamp":
+		return log.Timestamp
+	case "request_id":
+		return log.RequestID
+	case LogTypeColumn:
+		return log.ModifiedFields["log_type"]
 	}
-	return logs, nil
+	return nil
 }
 
-// GetAccountLogsByAgentID retrieves all bank account logs for a specific agent
-func (r *AgentClientLogRepository) GetAccountLogsByAgentID(agentID int) ([]models.AgentClientLog, error) {
-	query := `
-		SELECT id, agent_id, client_id, action, modified_fields, timestamp
-		FROM agent_client_logs
-		WHERE agent_id = ? AND JSON_UNQUOTE(JSON_EXTRACT(modified_fields, '$.log_type')) = 'bank_account'
-	`
-	rows, err := database.DB.Query(query, agentID)
+// ListLogs retrieves one page of logs matching the query
+func (r *AgentClientLogRepository) ListLogs(q listquery.Query) ([]models.AgentClientLog, error) {
+	defer metrics.ObserveQuery("AgentClientLogRepository", "ListLogs", time.Now())
+	clause, args := q.SQL()
+	query := `SELECT id, agent_id, client_id, action, modified_fields, timestamp, request_id, content_hash, prev_hash, hash, redacted_by FROM agent_client_logs` + clause
+
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve bank account logs for agent by agent_id: %v", err)
+		return nil, fmt.Errorf("failed to retrieve logs: %v", err)
 	}
 	defer rows.Close()
 
 	var logs []models.AgentClientLog
 	for rows.Next() {
-		var log models.AgentClientLog
-		var modifiedFieldsJSON string
-
-		if err := rows.Scan(&log.ID, &log.AgentID, &log.ClientID, &log.Action, &modifiedFieldsJSON, &log.Timestamp); err != nil {
-			return nil, err
-		}
-
-		err = json.Unmarshal([]byte(modifiedFieldsJSON), &log.ModifiedFields)
+		log, err := scanLog(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to decode modified fields: %v", err)
+			return nil, err
 		}
-
 		logs = append(logs, log)
 	}
-	return logs, nil
-}
 
-// GetAllAccountLogs retrieves all bank account transaction logs
-func (r *AgentClientLogRepository) GetAllAccountLogs() ([]models.AgentClientLog, error) {
-	query := `
-		SELECT id, agent_id, client_id, action, modified_fields, timestamp
-		FROM agent_client_logs
-		WHERE JSON_UNQUOTE(JSON_EXTRACT(modified_fields, '$.log_type')) = 'bank_account'
-	`
-	rows, err := database.DB.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve all bank account transaction logs: %v", err)
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating log rows: %v", err)
 	}
-	defer rows.Close()
 
-	var logs []models.AgentClientLog
-	for rows.Next() {
-		var log models.AgentClientLog
-		var modifiedFieldsJSON string
+	return logs, nil
+}
 
-		if err := rows.Scan(&log.ID, &log.AgentID, &log.ClientID, &log.Action, &modifiedFieldsJSON, &log.Timestamp); err != nil {
-			return nil, err
-		}
+// scanLog reads a row selected as in ListLogs, optionally followed by extra columns
+func scanLog(rows *sql.Rows, extra ...interface{}) (models.AgentClientLog, error) {
+	var log models.AgentClientLog
+	var modifiedFieldsJSON string
+	var redactedBy sql.NullInt64
 
-		err = json.Unmarshal([]byte(modifiedFieldsJSON), &log.ModifiedFields)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decode modified fields: %v", err)
-		}
+	dest := []interface{}{&log.ID, &log.AgentID, &log.ClientID, &log.Action, &modifiedFieldsJSON, &log.Timestamp, &log.RequestID,
+		&log.ContentHash, &log.PrevHash, &log.Hash, &redactedBy}
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return models.AgentClientLog{}, err
+	}
 
-		logs = append(logs, log)
+	if err := json.Unmarshal([]byte(modifiedFieldsJSON), &log.ModifiedFields); err != nil {
+		return models.AgentClientLog{}, fmt.Errorf("failed to decode modified fields: %v", err)
 	}
-	return logs, nil
+	if redactedBy.Valid {
+		id := int(redactedBy.Int64)
+		log.RedactedBy = &id
+	}
+	return log, nil
 }
 
-// GetClientAndAccountLogsByAgentID retrieves both client and account logs for a specific agent
-func (r *AgentClientLogRepository) GetClientAndAccountLogsByAgentID(agentID int) ([]models.AgentClientLog, error) {
-	query := `
-		SELECT id, agent_id, client_id, action, modified_fields, timestamp
-		FROM agent_client_logs
-		WHERE agent_id = ? AND JSON_UNQUOTE(JSON_EXTRACT(modified_fields, '$.log_type')) IN ('client', 'bank_account')
-	`
-	rows, err := database.DB.Query(query, agentID)
+// RedactLog appends a Redact entry for a log and blanks the log's content, keeping its log_type
+// and hashes so the chain still verifies
+func (r *AgentClientLogRepository) RedactLog(logID int, agentID int, reason string, requestID string) (models.AgentClientLog, error) {
+	defer metrics.ObserveQuery("AgentClientLogRepository", "RedactLog", time.Now())
+	tx, err := r.db.Begin()
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve client and account logs for agent by agent_id: %v", err)
+		return models.AgentClientLog{}, fmt.Errorf("failed to begin transaction: %v", err)
 	}
-	defer rows.Close()
+	defer tx.Rollback()
 
-	var logs []models.AgentClientLog
-	for rows.Next() {
-		var log models.AgentClientLog
-		var modifiedFieldsJSON string
-
-		if err := rows.Scan(&log.ID, &log.AgentID, &log.ClientID, &log.Action, &modifiedFieldsJSON, &log.Timestamp); err != nil {
-			return nil, err
-		}
-
-		// Decode the modified fields (details)
-		err = json.Unmarshal([]byte(modifiedFieldsJSON), &log.ModifiedFields)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decode modified fields: %v", err)
-		}
-
-		logs = append(logs, log)
+	// Lock the chain before the target so redactions and appends take locks in the same order
+	if _, err := lockChainHead(tx); err != nil {
+		return models.AgentClientLog{}, err
 	}
-	return logs, nil
-}
 
-// GetClientAndAccountLogsByClientID retrieves both client and account logs for a specific client
-func (r *AgentClientLogRepository) GetClientAndAccountLogsByClientID(clientID string) ([]models.AgentClientLog, error) {
-	query := `
-		SELECT id, agent_id, client_id, action, modified_fields, timestamp
-		FROM agent_client_logs
-		WHERE client_id = ? AND JSON_UNQUOTE(JSON_EXTRACT(modified_fields, '$.log_type')) IN ('client', 'bank_account')
-	`
-	rows, err := database.DB.Query(query, clientID)
+	var clientID, action string
+	var redactedBy sql.NullInt64
+	err = tx.QueryRow(`SELECT client_id, action, redacted_by FROM agent_client_logs WHERE id = ? FOR UPDATE`, logID).
+		Scan(&clientID, &action, &redactedBy)
+	if err == sql.ErrNoRows {
+		return models.AgentClientLog{}, ErrLogNotFound
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve client and account logs for client by client_id: %v", err)
+		return models.AgentClientLog{}, fmt.Errorf("failed to read log %d: %v", logID, err)
+	}
+	if redactedBy.Valid {
+		return models.AgentClientLog{}, ErrLogAlreadyRedacted
+	}
+	if action == RedactAction {
+		return models.AgentClientLog{}, ErrRedactRedaction
 	}
-	defer rows.Close()
 
-	var logs []models.AgentClientLog
-	for rows.Next() {
-		var log models.AgentClientLog
-		var modifiedFieldsJSON string
+	logData := models.AgentClientLog{
+		AgentID:        agentID,
+		ClientID:       clientID,
+		Action:         RedactAction,
+		ModifiedFields: map[string]interface{}{"log_type": RedactionLogType, "details": map[string]interface{}{"log_id": logID, "reason": reason}},
+		RequestID:      requestID,
+	}
+	modifiedFieldsJSON, err := json.Marshal(logData.ModifiedFields)
+	if err != nil {
+		return models.AgentClientLog{}, fmt.Errorf("failed to convert modified fields to JSON: %v", err)
+	}
 
-		if err := rows.Scan(&log.ID, &log.AgentID, &log.ClientID, &log.Action, &modifiedFieldsJSON, &log.Timestamp); err != nil {
-			return nil, err
-		}
+	logData, err = appendLogTx(tx, logData, modifiedFieldsJSON, models.LogSource{})
+	if err != nil {
+		return models.AgentClientLog{}, err
+	}
 
-		// Decode the modified fields (details)
-		err = json.Unmarshal([]byte(modifiedFieldsJSON), &log.ModifiedFields)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decode modified fields: %v", err)
-		}
+	query := `UPDATE agent_client_logs SET modified_fields = JSON_OBJECT('log_type', modified_fields->>'$.log_type', 'redacted', TRUE), redacted_by = ? WHERE id = ?`
+	if _, err := tx.Exec(query, logData.ID, logID); err != nil {
+		return models.AgentClientLog{}, fmt.Errorf("failed to redact log %d: %v", logID, err)
+	}
 
-		logs = append(logs, log)
+	if err := tx.Commit(); err != nil {
+		return models.AgentClientLog{}, fmt.Errorf("failed to commit redaction: %v", err)
 	}
-	return logs, nil
+	return logData, nil
 }
 
-// GetAllLogs retrieves all logs (both client and bank account logs)
-func (r *AgentClientLogRepository) GetAllLogs() ([]models.AgentClientLog, error) {
-	// Use SELECT to explicitly filter log types 'client' and 'bank_account'
-	query := `
-		SELECT id, agent_id, client_id, action, modified_fields, timestamp
-		FROM agent_client_logs
-		WHERE JSON_UNQUOTE(JSON_EXTRACT(modified_fields, '$.log_type')) IN ('client', 'bank_account')
-	`
-
-	rows, err := database.DB.Query(query)
+// ListChain retrieves up to limit logs after afterID in ID order, with the hash of their stored
+// content, and the tombstones of purged logs in their place
+func (r *AgentClientLogRepository) ListChain(afterID int, limit int) ([]models.LogChainEntry, error) {
+	defer metrics.ObserveQuery("AgentClientLogRepository", "ListChain", time.Now())
+	query := `SELECT * FROM (
+			SELECT id, agent_id, client_id, action, modified_fields, timestamp, request_id, content_hash, prev_hash, hash, redacted_by,
+				SHA2(` + chainContent + `, 256) AS computed_hash, FALSE AS archived
+			FROM agent_client_logs WHERE id > ?
+			UNION ALL
+			SELECT log_id, 0, '', '', '{}', '', '', content_hash, prev_hash, hash, NULL, '', TRUE
+			FROM agent_client_log_tombstones WHERE log_id > ?
+		) chain ORDER BY id LIMIT ?`
+
+	rows, err := r.db.Query(query, afterID, afterID, limit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve all logs: %v", err)
+		return nil, fmt.Errorf("failed to read the log chain: %v", err)
 	}
 	defer rows.Close()
 
-	var logs []models.AgentClientLog
+	var entries []models.LogChainEntry
 	for rows.Next() {
-		var log models.AgentClientLog
-		var modifiedFieldsJSON string
-		var timestamp string // Store the timestamp as string
-
-		// Scan the log data from the database
-		if err := rows.Scan(&log.ID, &log.AgentID, &log.ClientID, &log.Action, &modifiedFieldsJSON, &timestamp); err != nil {
+		var entry models.LogChainEntry
+		entry.Log, err = scanLog(rows, &entry.ComputedContentHash, &entry.Archived)
+		if err != nil {
 			return nil, err
 		}
+		entries = append(entries, entry)
+	}
 
-		log.Timestamp = timestamp // Store the timestamp as a string
-
-		// Decode the modified fields (info)
-		err = json.Unmarshal([]byte(modifiedFieldsJSON), &log.ModifiedFields)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decode modified fields: %v", err)
-		}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating log rows: %v", err)
+	}
+	return entries, nil
+}
 
-		logs = append(logs, log)
+// ChainHead reads the end of the log chain
+func (r *AgentClientLogRepository) ChainHead() (models.LogChainHead, error) {
+	var head models.LogChainHead
+	err := r.db.QueryRow(`SELECT last_log_id, last_hash, legacy_max_id FROM agent_client_log_chain WHERE id = 1`).
+		Scan(&head.LastLogID, &head.LastHash, &head.LegacyMaxID)
+	if err != nil {
+		return models.LogChainHead{}, fmt.Errorf("failed to read the log chain head: %v", err)
 	}
-	return logs, nil
+	return head, nil
 }
 
-// DeleteLog deletes an agent-client log by its ID
-func (r *AgentClientLogRepository) DeleteLog(logID int) error {
-	query := "DELETE FROM agent_client_logs WHERE id = ?"
-	_, err := database.DB.Exec(query, logID)
+// SealLegacy seals logs that predate the chain, returning how many were sealed
+func (r *AgentClientLogRepository) SealLegacy() (int, error) {
+	tx, err := r.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to delete agent-client log: %v", err)
+		return 0, fmt.Errorf("failed to begin transaction: %v", err)
 	}
-	return nil
+	defer tx.Rollback()
+
+	head, err := lockChainHead(tx)
+	if err != nil {
+		return 0, err
+	}
+	sealed, err := sealLegacyTx(tx, &head)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit sealed logs: %v", err)
+	}
+	return sealed, nil
 }
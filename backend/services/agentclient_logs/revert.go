@@ -0,0 +1,212 @@
+package agentclient_logs
+
+import (
+	"backend/models"
+	"backend/services/events"
+	"backend/services/interfaces"
+	"backend/services/listquery"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// RevertAction is the action of the entry logged for a change that undid an earlier Update
+const RevertAction = "Revert"
+
+// Keys a log's modified_fields may carry besides log_type and details
+const (
+	RevertsField   = "reverts_log_id" // on a Revert entry, the ID of the entry it undid
+	AccountIDField = "account_id"     // on an account Update or Revert, the account that changed
+	MaskedField    = "masked_fields"  // the JSON paths in details whose values are masked
+)
+
+var (
+	ErrNotRevertible  = errors.New("log entry cannot be reverted")
+	ErrRevertConflict = errors.New("fields have changed since the logged change")
+)
+
+// accountFieldNames maps models.Account's Go field names to their JSON names
+var accountFieldNames = jsonFieldNames(reflect.TypeOf(models.Account{}))
+
+// accountDetailFields are the account fields UpdateAccount can set; status and its reason go
+// through ChangeAccountStatus
+var accountDetailFields = map[string]bool{"account_type": true, "currency": true, "branch_id": true}
+
+// loggedFields is what a log keeps of the fields it is given: its log_type, the details and
+// the keys that link it to an account or to the entry it reverts and list its masked fields
+func loggedFields(logType string, modifiedFields map[string]interface{}) map[string]interface{} {
+	fields := map[string]interface{}{"log_type": logType, "details": modifiedFields["details"]}
+	for _, key := range []string{RevertsField, AccountIDField, MaskedField} {
+		if value, ok := modifiedFields[key]; ok {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+// SetClientService sets the service client reverts are applied through
+func (s *AgentClientLogService) SetClientService(clientService interfaces.ClientServiceInterface) {
+	s.ClientService = clientService
+}
+
+// SetAccountService sets the service account reverts are applied through
+func (s *AgentClientLogService) SetAccountService(accountService interfaces.AccountServiceInterface) {
+	s.AccountService = accountService
+}
+
+// GetLog retrieves a single log entry
+func (s *AgentClientLogService) GetLog(logID int) (models.AgentClientLog, error) {
+	logs, err := s.LogsAfter(logID-1, 1, listquery.Condition{Column: "id", Op: "=", Value: logID})
+	if err != nil {
+		return models.AgentClientLog{}, err
+	}
+	if len(logs) == 0 {
+		return models.AgentClientLog{}, ErrLogNotFound
+	}
+	return logs[0], nil
+}
+
+// RevertLog undoes the change an Update (or Revert) entry recorded by putting its "before"
+// values back through the client or account service. Every field must still hold the entry's
+// "after" value, checked in the same transaction that writes only the restored fields;
+// otherwise nothing is changed and the conflicts are returned with ErrRevertConflict. The
+// change is logged as a Revert entry linked to the original.
+func (s *AgentClientLogService) RevertLog(ctx context.Context, logID int, agentID int) (models.RevertResult, error) {
+	log, err := s.GetLog(logID)
+	if err != nil {
+		return models.RevertResult{}, err
+	}
+	if log.RedactedBy != nil || log.ModifiedFields["redacted"] == true {
+		return models.RevertResult{}, fmt.Errorf("%w: its content was redacted", ErrNotRevertible)
+	}
+	if log.Action != "Update" && log.Action != RevertAction {
+		return models.RevertResult{}, fmt.Errorf("%w: only updates can be reverted, not %s", ErrNotRevertible, log.Action)
+	}
+	changes, _ := log.ModifiedFields["details"].(map[string]interface{})
+	if len(changes) == 0 {
+		return models.RevertResult{}, fmt.Errorf("%w: it records no changes", ErrNotRevertible)
+	}
+	// A masked "before" is not the value to restore
+	var masked []string
+	for path := range maskedPaths(log) {
+		for key := range changes {
+			if path == key || strings.HasPrefix(path, key+".") || strings.HasPrefix(path, key+"[") {
+				masked = append(masked, path)
+				break
+			}
+		}
+	}
+	if len(masked) > 0 {
+		sort.Strings(masked)
+		return models.RevertResult{}, fmt.Errorf("%w: the log only holds masked values of %s", ErrNotRevertible, strings.Join(masked, ", "))
+	}
+
+	logType, _ := log.ModifiedFields["log_type"].(string)
+	result := models.RevertResult{LogID: log.ID, LogType: logType, ClientID: log.ClientID}
+	ctx = events.WithRevertOf(ctx, log.ID)
+
+	switch logType {
+	case "client":
+		return s.revertClient(ctx, log, changes, agentID, result)
+	case "bank_account":
+		return s.revertAccount(ctx, log, changes, result)
+	}
+	return models.RevertResult{}, fmt.Errorf("%w: %s logs cannot be reverted", ErrNotRevertible, logType)
+}
+
+func (s *AgentClientLogService) revertClient(ctx context.Context, log models.AgentClientLog, changes map[string]interface{}, agentID int, result models.RevertResult) (models.RevertResult, error) {
+	expected, restore, err := planRevert(clientFieldNames, changes)
+	if err != nil {
+		return models.RevertResult{}, err
+	}
+
+	updated, conflicts, err := s.ClientService.RevertClient(ctx, log.ClientID, expected, restore, agentID)
+	if err != nil {
+		return models.RevertResult{}, err
+	}
+	if len(conflicts) > 0 {
+		result.Conflicts = conflicts
+		return result, ErrRevertConflict
+	}
+	result.Restored = restoredValues(restore)
+	result.Client = &updated
+	return result, nil
+}
+
+func (s *AgentClientLogService) revertAccount(ctx context.Context, log models.AgentClientLog, changes map[string]interface{}, result models.RevertResult) (models.RevertResult, error) {
+	accountID, ok := log.ModifiedFields[AccountIDField].(float64)
+	if !ok {
+		return models.RevertResult{}, fmt.Errorf("%w: the log does not record which account changed", ErrNotRevertible)
+	}
+	result.AccountID = int(accountID)
+
+	expected, restore, err := planRevert(accountFieldNames, changes)
+	if err != nil {
+		return models.RevertResult{}, err
+	}
+
+	details, status := false, false
+	for field, value := range restore {
+		switch {
+		case accountDetailFields[field]:
+			if value == "" {
+				return models.RevertResult{}, fmt.Errorf("%w: %s was empty and an update cannot clear it", ErrNotRevertible, field)
+			}
+			details = true
+		case field == "account_status" || field == "status_reason":
+			status = true
+		default:
+			return models.RevertResult{}, fmt.Errorf("%w: %s cannot be changed by an update", ErrNotRevertible, field)
+		}
+	}
+	if details && status {
+		return models.RevertResult{}, fmt.Errorf("%w: it changes both the account's details and its status", ErrNotRevertible)
+	}
+
+	updated, conflicts, err := s.AccountService.RevertAccount(ctx, result.AccountID, expected, restore)
+	if err != nil {
+		return models.RevertResult{}, err
+	}
+	if len(conflicts) > 0 {
+		result.Conflicts = conflicts
+		return result, ErrRevertConflict
+	}
+	result.Restored = restoredValues(restore)
+	result.Account = &updated
+	return result, nil
+}
+
+// planRevert reads each logged change into the "after" value the field must still hold and
+// the "before" value to put back, keyed by JSON name. changes is keyed by Go or JSON field
+// name; names maps both to the JSON name. Only text fields can be reverted.
+func planRevert(names map[string]string, changes map[string]interface{}) (expected, restore map[string]string, err error) {
+	expected = make(map[string]string, len(changes))
+	restore = make(map[string]string, len(changes))
+	for key, raw := range changes {
+		field, known := names[key]
+		change, isChange := raw.(map[string]interface{})
+		if !known || !isChange {
+			return nil, nil, fmt.Errorf("%w: unknown field %s", ErrNotRevertible, key)
+		}
+		after, afterOK := change["after"].(string)
+		before, beforeOK := change["before"].(string)
+		if !afterOK || !beforeOK {
+			return nil, nil, fmt.Errorf("%w: %s does not hold text values", ErrNotRevertible, field)
+		}
+		expected[field] = after
+		restore[field] = before
+	}
+	return expected, restore, nil
+}
+
+// restoredValues is restore as RevertResult reports it
+func restoredValues(restore map[string]string) map[string]interface{} {
+	restored := make(map[string]interface{}, len(restore))
+	for field, value := range restore {
+		restored[field] = value
+	}
+	return restored
+}
@@ -2,92 +2,208 @@ package agentclient_logs
 
 import (
 	"backend/models"
+	"backend/services/events"
+	"backend/services/interfaces"
+	"backend/services/listquery"
+	"backend/services/logging"
+	"context"
 	"fmt"
 )
 
-type CommunicationNotifier interface {
-	NotifyCommunication(agentID int, clientID string, log models.AgentClientLog)
-}
-
 // AgentClientLogService handles log operations
 type AgentClientLogService struct {
-	repo     *AgentClientLogRepository
-	notifier CommunicationNotifier // only this interface, not the whole ObserverManager
+	repo               interfaces.AgentClientLogRepositoryInterface
+	stream             *LogStream
+	AgentClientService interfaces.AgentClientServiceInterface
+	ClientService      interfaces.ClientServiceInterface
+	AccountService     interfaces.AccountServiceInterface
+	exportSigningKey   []byte
 }
 
 // NewAgentClientLogService initializes the service
-func NewAgentClientLogService(repo *AgentClientLogRepository, notifier CommunicationNotifier) *AgentClientLogService {
-	return &AgentClientLogService{repo: repo, notifier: notifier}
+func NewAgentClientLogService(repo interfaces.AgentClientLogRepositoryInterface) *AgentClientLogService {
+	return &AgentClientLogService{repo: repo, stream: NewLogStream()}
+}
+
+// LogAgentClientAction processes and stores agent-client logs, tagged with the request ID in ctx
+func (s *AgentClientLogService) LogAgentClientAction(ctx context.Context, agentID int, clientID string, action string, modifiedFields map[string]interface{}) (models.AgentClientLog, error) {
+	return s.logClient(ctx, models.LogSource{}, agentID, clientID, action, modifiedFields)
 }
 
-// LogAgentClientAction processes and stores agent-client logs
-func (s *AgentClientLogService) LogAgentClientAction(agentID int, clientID string, action string, modifiedFields map[string]interface{}) (models.AgentClientLog, error) {
+// LogClientEvent stores the client log of an outbox event. Delivery is at least once, so an
+// event already logged under the same source is skipped rather than logged twice.
+func (s *AgentClientLogService) LogClientEvent(ctx context.Context, source models.LogSource, agentID int, clientID string, action string, modifiedFields map[string]interface{}) error {
+	_, err := s.logClient(ctx, source, agentID, clientID, action, modifiedFields)
+	if err == ErrAlreadyLogged {
+		return nil
+	}
+	return err
+}
+
+func (s *AgentClientLogService) logClient(ctx context.Context, source models.LogSource, agentID int, clientID string, action string, modifiedFields map[string]interface{}) (models.AgentClientLog, error) {
 	if action == "" {
 		return models.AgentClientLog{}, fmt.Errorf("missing action type")
 	}
 
-	// Pass the correct types to the repository
-	log, err := s.repo.CreateAgentClientLog(agentID, clientID, action, modifiedFields)
+	// Pass the correct types to the repository; ClientChangeLogged is delivered from the outbox
+	log, err := s.repo.CreateAgentClientLog(agentID, clientID, action, modifiedFields, events.NewMeta(ctx, agentID), source)
 	if err != nil {
 		return log, err
 	}
 
-	s.notifier.NotifyCommunication(agentID, clientID, log)
+	s.stream.Publish(log)
 	return log, nil
 }
 
-// GetAgentClientLogs retrieves logs for a specific client
-func (s *AgentClientLogService) GetAgentClientLogs(clientID string) ([]models.AgentClientLog, error) {
-	return s.repo.GetClientTransactionLogsByClientID(clientID)
+// GetAgentClientLogs retrieves a page of logs for a specific client
+func (s *AgentClientLogService) GetAgentClientLogs(clientID string, q listquery.Query) (listquery.Page[models.AgentClientLog], error) {
+	return s.list(q.Where("client_id", "=", clientID), "client")
 }
 
-// GetAgentClientLogsByAgent retrieves logs for a specific agent
-func (s *AgentClientLogService) GetAgentClientLogsByAgent(agentID int) ([]models.AgentClientLog, error) {
-	return s.repo.GetClientTransactionLogsByAgentID(agentID)
+// GetAgentClientLogsByAgent retrieves a page of logs for a specific agent
+func (s *AgentClientLogService) GetAgentClientLogsByAgent(agentID int, q listquery.Query) (listquery.Page[models.AgentClientLog], error) {
+	return s.list(q.Where("agent_id", "=", agentID), "client")
 }
 
-// GetAllAgentClientLogs retrieves all logs
-func (s *AgentClientLogService) GetAllAgentClientLogs() ([]models.AgentClientLog, error) {
-	return s.repo.GetAllClientTransactionLogs()
+// GetAllAgentClientLogs retrieves a page of logs
+func (s *AgentClientLogService) GetAllAgentClientLogs(q listquery.Query) (listquery.Page[models.AgentClientLog], error) {
+	return s.list(q, "client")
 }
 
-// LogAccountChange inserts a new bank account log into the database
-func (s *AgentClientLogService) LogAccountChange(agentID int, clientID string, action string, bankAccountInfo map[string]interface{}) error {
-	return s.repo.LogAccountChange(agentID, clientID, action, bankAccountInfo)
+// LogAccountChange inserts a new bank account log into the database, tagged with the request ID in ctx
+func (s *AgentClientLogService) LogAccountChange(ctx context.Context, agentID int, clientID string, action string, bankAccountInfo map[string]interface{}) error {
+	return s.logAccount(ctx, models.LogSource{}, agentID, clientID, action, bankAccountInfo)
 }
 
-// GetAccountLogsByClientID retrieves all bank account logs for a specific client
-func (s *AgentClientLogService) GetAccountLogsByClientID(clientID string) ([]models.AgentClientLog, error) {
-	return s.repo.GetAccountLogsByClientID(clientID)
+// LogAccountEvent stores the bank account log of an outbox event, skipping an event already
+// logged under the same source like LogClientEvent
+func (s *AgentClientLogService) LogAccountEvent(ctx context.Context, source models.LogSource, agentID int, clientID string, action string, bankAccountInfo map[string]interface{}) error {
+	err := s.logAccount(ctx, source, agentID, clientID, action, bankAccountInfo)
+	if err == ErrAlreadyLogged {
+		return nil
+	}
+	return err
 }
 
-// GetAccountLogsByAgentID retrieves all bank account logs for a specific agent
-func (s *AgentClientLogService) GetAccountLogsByAgentID(agentID int) ([]models.AgentClientLog, error) {
-	return s.repo.GetAccountLogsByAgentID(agentID)
+func (s *AgentClientLogService) logAccount(ctx context.Context, source models.LogSource, agentID int, clientID string, action string, bankAccountInfo map[string]interface{}) error {
+	log, err := s.repo.LogAccountChange(agentID, clientID, action, bankAccountInfo, logging.RequestID(ctx), source)
+	if err != nil {
+		return err
+	}
+
+	s.stream.Publish(log)
+	return nil
 }
 
-// GetAllAccountLogs retrieves all bank account transaction logs
-func (s *AgentClientLogService) GetAllAccountLogs() ([]models.AgentClientLog, error) {
-	return s.repo.GetAllAccountLogs()
+// GetAccountLogsByClientID retrieves a page of bank account logs for a specific client
+func (s *AgentClientLogService) GetAccountLogsByClientID(clientID string, q listquery.Query) (listquery.Page[models.AgentClientLog], error) {
+	return s.list(q.Where("client_id", "=", clientID), "bank_account")
 }
 
-// GetClientAndAccountLogsByAgentID retreieves client and account logs by agent ID
-func (s *AgentClientLogService) GetClientAndAccountLogsByAgentID(agentID int) ([]models.AgentClientLog, error) {
-	return s.repo.GetClientAndAccountLogsByAgentID(agentID)
+// GetAccountLogsByAgentID retrieves a page of bank account logs for a specific agent
+func (s *AgentClientLogService) GetAccountLogsByAgentID(agentID int, q listquery.Query) (listquery.Page[models.AgentClientLog], error) {
+	return s.list(q.Where("agent_id", "=", agentID), "bank_account")
 }
 
-// GetClientAndAccountLogsByClientID retreives client and account logs by client ID
-func (s *AgentClientLogService) GetClientAndAccountLogsByClientID(clientID string) ([]models.AgentClientLog, error) {
-	return s.repo.GetClientAndAccountLogsByClientID(clientID)
+// GetAllAccountLogs retrieves a page of bank account transaction logs
+func (s *AgentClientLogService) GetAllAccountLogs(q listquery.Query) (listquery.Page[models.AgentClientLog], error) {
+	return s.list(q, "bank_account")
+}
+
+// GetClientAndAccountLogsByAgentID retrieves a page of client and account logs by agent ID
+func (s *AgentClientLogService) GetClientAndAccountLogsByAgentID(agentID int, q listquery.Query) (listquery.Page[models.AgentClientLog], error) {
+	return s.list(q.Where("agent_id", "=", agentID), "client", "bank_account")
+}
+
+// GetClientAndAccountLogsByClientID retrieves a page of client and account logs by client ID
+func (s *AgentClientLogService) GetClientAndAccountLogsByClientID(clientID string, q listquery.Query) (listquery.Page[models.AgentClientLog], error) {
+	return s.list(q.Where("client_id", "=", clientID), "client", "bank_account")
+}
+
+// GetAllLogs retrieves a page of logs (client and bank account logs)
+func (s *AgentClientLogService) GetAllLogs(q listquery.Query) (listquery.Page[models.AgentClientLog], error) {
+	return s.list(q, "client", "bank_account")
+}
+
+// list retrieves one page of logs of the given types
+func (s *AgentClientLogService) list(q listquery.Query, logTypes ...string) (listquery.Page[models.AgentClientLog], error) {
+	q = q.Where(LogTypeColumn, "IN", logTypes)
+	logs, err := s.repo.ListLogs(q)
+	if err != nil {
+		return listquery.Page[models.AgentClientLog]{}, err
+	}
+	return listquery.NewPage(logs, q, ListField)
 }
 
-// GetAllLogs retrieves all logs (client and bank account logs)
-func (s *AgentClientLogService) GetAllLogs() ([]models.AgentClientLog, error) {
-	return s.repo.GetAllLogs()
+// SetAgentClientService sets the service used to decide which clients an agent owns
+func (s *AgentClientLogService) SetAgentClientService(agentClientService interfaces.AgentClientServiceInterface) {
+	s.AgentClientService = agentClientService
 }
 
-// DeleteLog deletes any log by its ID (either client or bank account)
-func (s *AgentClientLogService) DeleteLog(logID int) error {
-	// Call the repository to delete the log (it could be of any type)
-	return s.repo.DeleteLog(logID)
+// Subscribe streams logs created from now on; see LogStream
+func (s *AgentClientLogService) Subscribe(buffer int) (<-chan models.AgentClientLog, func()) {
+	return s.stream.Subscribe(buffer)
+}
+
+// CloseStreams ends all open log streams
+func (s *AgentClientLogService) CloseStreams() {
+	s.stream.Close()
+}
+
+// VisibleTo returns a check for whether a caller may see a log: Admins see every log, agents
+// only logs of clients they currently own. Ownership is looked up once per client.
+func (s *AgentClientLogService) VisibleTo(agentID int, role string) func(log models.AgentClientLog) bool {
+	if role == "Admin" {
+		return func(models.AgentClientLog) bool { return true }
+	}
+
+	owners := make(map[string]int)
+	return func(log models.AgentClientLog) bool {
+		owner, ok := owners[log.ClientID]
+		if !ok {
+			var err error
+			owner, err = s.AgentClientService.GetAgentIDByClientID(log.ClientID)
+			if err != nil {
+				// The client is gone; its logs stay with the agent who wrote them
+				owner = log.AgentID
+			}
+			owners[log.ClientID] = owner
+		}
+		return owner == agentID
+	}
+}
+
+// LogsAfter retrieves up to limit logs with an ID above afterID, oldest first, matching the
+// filter conditions. It is how a stream catches up after Last-Event-ID.
+func (s *AgentClientLogService) LogsAfter(afterID int, limit int, conditions ...listquery.Condition) ([]models.AgentClientLog, error) {
+	q := listquery.All("id")
+	q.Limit = limit
+	q.Conditions = append(q.Conditions, conditions...)
+	q = q.Where("id", ">=", afterID+1)
+
+	logs, err := s.repo.ListLogs(q)
+	if err != nil {
+		return nil, err
+	}
+	// ListLogs fetches one extra row to detect a next page
+	if len(logs) > limit {
+		logs = logs[:limit]
+	}
+	return logs, nil
+}
+
+// RedactLog replaces deletion: the log's content is blanked and a Redact entry records who
+// redacted it and why, so the hash chain still verifies
+func (s *AgentClientLogService) RedactLog(ctx context.Context, logID int, agentID int, reason string) (models.AgentClientLog, error) {
+	if reason == "" {
+		return models.AgentClientLog{}, fmt.Errorf("a reason is required to redact a log")
+	}
+
+	redaction, err := s.repo.RedactLog(logID, agentID, reason, logging.RequestID(ctx))
+	if err != nil {
+		return models.AgentClientLog{}, err
+	}
+
+	s.stream.Publish(redaction)
+	return redaction, nil
 }
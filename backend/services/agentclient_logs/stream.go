@@ -0,0 +1,73 @@
+package agentclient_logs
+
+import (
+	"backend/models"
+	"sync"
+)
+
+// LogStream fans newly created logs out to live subscribers in this process. A subscriber
+// that falls behind is dropped rather than slowing down the writer; it reconnects with
+// Last-Event-ID and catches up from the database.
+type LogStream struct {
+	mu          sync.Mutex
+	subscribers map[chan models.AgentClientLog]struct{}
+	closed      bool
+}
+
+// NewLogStream initializes an empty LogStream
+func NewLogStream() *LogStream {
+	return &LogStream{subscribers: make(map[chan models.AgentClientLog]struct{})}
+}
+
+// Subscribe returns a channel of new logs, closed when the subscriber is dropped, and a
+// function that unsubscribes
+func (s *LogStream) Subscribe(buffer int) (<-chan models.AgentClientLog, func()) {
+	ch := make(chan models.AgentClientLog, buffer)
+
+	s.mu.Lock()
+	if s.closed {
+		close(ch)
+	} else {
+		s.subscribers[ch] = struct{}{}
+	}
+	s.mu.Unlock()
+
+	return ch, func() { s.drop(ch) }
+}
+
+// Publish hands a log to every subscriber without blocking
+func (s *LogStream) Publish(log models.AgentClientLog) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.subscribers {
+		select {
+		case ch <- log:
+		default:
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Close ends every subscription and refuses new ones, so open streams finish at shutdown
+func (s *LogStream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for ch := range s.subscribers {
+		delete(s.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (s *LogStream) drop(ch chan models.AgentClientLog) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscribers[ch]; ok {
+		delete(s.subscribers, ch)
+		close(ch)
+	}
+}
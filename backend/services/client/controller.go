@@ -2,6 +2,7 @@ package client
 
 import (
 	"backend/models"
+	"backend/services/listquery"
 	"encoding/json"
 	"net/http"
 	"strconv"
@@ -47,7 +48,7 @@ func CreateClientHandler(service *ClientService) http.HandlerFunc {
 			return
 		}
 
-		createdClient, err := service.CreateClient(client, AgentID)
+		createdClient, err := service.CreateClient(r.Context(), client, AgentID)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
@@ -103,7 +104,7 @@ func UpdateClientHandler(service *ClientService) http.HandlerFunc {
 		}
 		client.ClientID = clientID
 
-		updatedClient, err := service.UpdateClient(client, agentID)
+		updatedClient, err := service.UpdateClient(r.Context(), client, agentID)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
@@ -127,7 +128,7 @@ func DeleteClientHandler(service *ClientService) http.HandlerFunc {
 			return
 		}
 
-		err := service.DeleteClient(clientID)
+		err := service.DeleteClient(r.Context(), clientID, agentID)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
@@ -181,7 +182,13 @@ func GetAllClientsHandler(service *ClientService) http.HandlerFunc {
 			return
 		}
 
-		clients, err := service.GetAllClients()
+		q, err := listquery.Parse(r, ListSpec)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		clients, err := service.GetAllClients(q)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -209,7 +216,13 @@ func GetClientsByAgentHandler(service *ClientService) http.HandlerFunc {
 			return
 		}
 
-		clients, err := service.GetClientsByAgentID(requestedAgentID)
+		q, err := listquery.Parse(r, ListSpec)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		clients, err := service.GetClientsByAgentID(requestedAgentID, q)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -0,0 +1,228 @@
+package client
+
+import (
+	"backend/database/memstore"
+	"backend/models"
+	"backend/services/events"
+	"backend/services/interfaces"
+	"backend/services/listquery"
+	"fmt"
+	"strings"
+)
+
+var _ interfaces.ClientRepositoryInterface = (*MemoryClientRepository)(nil)
+
+// MemoryClientRepository is an in-memory implementation of interfaces.ClientRepositoryInterface
+type MemoryClientRepository struct {
+	store *memstore.Store
+}
+
+// NewMemoryClientRepository initializes a new MemoryClientRepository on the given store
+func NewMemoryClientRepository(store *memstore.Store) *MemoryClientRepository {
+	return &MemoryClientRepository{store: store}
+}
+
+// EmailExists checks if an email already exists in the store
+func (r *MemoryClientRepository) EmailExists(email string) (bool, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	for _, client := range r.store.Clients {
+		if strings.EqualFold(client.Email, email) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// PhoneExists checks if a phone number already exists in the store
+func (r *MemoryClientRepository) PhoneExists(phone string) (bool, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	for _, client := range r.store.Clients {
+		if client.Phone == phone {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// AgentExists checks that the user exists and has the agent role
+func (r *MemoryClientRepository) AgentExists(AgentID int) (bool, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	user, ok := r.store.Users[AgentID]
+	return ok && strings.EqualFold(user.Role, "agent"), nil
+}
+
+// CreateClient stores a new client, assigns it to the agent and records ClientCreated
+func (r *MemoryClientRepository) CreateClient(client models.Client, AgentID int, meta models.EventMeta) (models.Client, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	r.store.ClientCounter++
+	client.ClientID = fmt.Sprintf("client%d", r.store.ClientCounter)
+	client.VerificationStatus = "unverified"
+
+	r.store.Clients[client.ClientID] = client
+
+	agentID := AgentID
+	r.store.AgentClients[client.ClientID] = &agentID
+
+	if err := events.AppendLocked(r.store, meta, events.ClientCreated{Client: client}); err != nil {
+		return models.Client{}, err
+	}
+	return client, nil
+}
+
+// GetClientByID retrieves a client by their ID
+func (r *MemoryClientRepository) GetClientByID(clientID string) (models.Client, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	client, ok := r.store.Clients[clientID]
+	if !ok {
+		return models.Client{}, fmt.Errorf("client with ID %v not found", clientID)
+	}
+	return client, nil
+}
+
+// UpdateClient updates an existing client's information and records ClientUpdated
+func (r *MemoryClientRepository) UpdateClient(client models.Client, meta models.EventMeta) (models.Client, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	current, ok := r.store.Clients[client.ClientID]
+	if !ok {
+		return models.Client{}, fmt.Errorf("client with ID %v not found", client.ClientID)
+	}
+
+	for id, other := range r.store.Clients {
+		if id == client.ClientID {
+			continue
+		}
+		if current.Email != client.Email && strings.EqualFold(other.Email, client.Email) {
+			return models.Client{}, fmt.Errorf("email address already exists")
+		}
+		if current.Phone != client.Phone && other.Phone == client.Phone {
+			return models.Client{}, fmt.Errorf("phone number already exists")
+		}
+	}
+
+	// Verification status is not editable through an update
+	client.VerificationStatus = current.VerificationStatus
+	r.store.Clients[client.ClientID] = client
+
+	if err := events.AppendLocked(r.store, meta, events.ClientUpdated{Before: current, After: client, RevertOf: meta.RevertOf}); err != nil {
+		return models.Client{}, err
+	}
+	return client, nil
+}
+
+// RevertClient sets the fields in restore, provided each field in expected still holds its
+// value, and records ClientUpdated. When a field has changed, nothing is written and the
+// conflicts are returned.
+func (r *MemoryClientRepository) RevertClient(clientID string, expected, restore map[string]string, meta models.EventMeta) (models.Client, []models.RevertConflict, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	before, ok := r.store.Clients[clientID]
+	if !ok {
+		return models.Client{}, nil, fmt.Errorf("client with ID %v not found", clientID)
+	}
+
+	conflicts, err := clientConflicts(before, expected)
+	if err != nil || len(conflicts) > 0 {
+		return before, conflicts, err
+	}
+
+	after := before
+	if err := setClientFields(&after, restore); err != nil {
+		return models.Client{}, nil, err
+	}
+
+	for id, other := range r.store.Clients {
+		if id == clientID {
+			continue
+		}
+		if before.Email != after.Email && strings.EqualFold(other.Email, after.Email) {
+			return models.Client{}, nil, fmt.Errorf("email address already exists")
+		}
+		if before.Phone != after.Phone && other.Phone == after.Phone {
+			return models.Client{}, nil, fmt.Errorf("phone number already exists")
+		}
+	}
+
+	r.store.Clients[clientID] = after
+	if err := events.AppendLocked(r.store, meta, events.ClientUpdated{Before: before, After: after, RevertOf: meta.RevertOf}); err != nil {
+		return models.Client{}, nil, err
+	}
+	return after, nil, nil
+}
+
+// DeleteClient removes a client's profile and its agent assignment and records ClientDeleted
+func (r *MemoryClientRepository) DeleteClient(clientID string, meta models.EventMeta) error {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	client, ok := r.store.Clients[clientID]
+	if !ok {
+		return fmt.Errorf("no rows affected; client with ID %s not found", clientID)
+	}
+	delete(r.store.Clients, clientID)
+	delete(r.store.AgentClients, clientID)
+	return events.AppendLocked(r.store, meta, events.ClientDeleted{Client: client})
+}
+
+// VerifyClient updates a client's verification status
+func (r *MemoryClientRepository) VerifyClient(clientID string) error {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	client, ok := r.store.Clients[clientID]
+	if !ok {
+		return fmt.Errorf("client with ID %v not found", clientID)
+	}
+	if client.VerificationStatus == "verified" {
+		return fmt.Errorf("client %s is already verified", clientID)
+	}
+
+	client.VerificationStatus = "verified"
+	r.store.Clients[clientID] = client
+	return nil
+}
+
+// ListClients retrieves one page of clients matching the query
+func (r *MemoryClientRepository) ListClients(q listquery.Query) ([]models.Client, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	var clients []models.Client
+	for _, client := range r.store.Clients {
+		clients = append(clients, client)
+	}
+
+	return listquery.Apply(clients, q, func(client models.Client, column string) interface{} {
+		if column == AgentColumn {
+			if agentID := r.store.AgentClients[client.ClientID]; agentID != nil {
+				return *agentID
+			}
+			return nil
+		}
+		return ListField(client, column)
+	}), nil
+}
+
+// IsClientOwnedByAgent checks if the client is assigned to the given agent
+func (r *MemoryClientRepository) IsClientOwnedByAgent(clientID string, agentID int) (bool, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	assigned, ok := r.store.AgentClients[clientID]
+	if !ok {
+		return false, fmt.Errorf("client not found")
+	}
+	return assigned != nil && *assigned == agentID, nil
+}
@@ -1,13 +1,16 @@
 package client
 
 import (
-	"backend/database"
-	"backend/services/observer"
 	"database/sql"
 	"fmt"
-	"log"
+	"sort"
+	"strings"
+	"time"
 
 	"backend/models"
+	"backend/services/events"
+	"backend/services/listquery"
+	"backend/services/metrics"
 )
 
 // // Client struct represents a client in the system
@@ -27,68 +30,21 @@ import (
 // 	VerificationStatus string `json:"verification_status"`
 // }
 
-// ClientRepository struct for interacting with the database
+// ClientRepository is the MySQL implementation of interfaces.ClientRepositoryInterface
 type ClientRepository struct {
-	ObserverManager *observer.ObserverManager
+	db *sql.DB
 }
 
 // NewClientRepository initializes a new ClientRepository
-func NewClientRepository(observerManager *observer.ObserverManager) *ClientRepository {
-	repo := &ClientRepository{ObserverManager: observerManager}
-	repo.InitClientTables() // Ensure tables exist when the repository is created
-	return repo
+func NewClientRepository(db *sql.DB) *ClientRepository {
+	return &ClientRepository{db: db}
 }
-
-
-// InitClientTables creates the client table if it doesn't exist
-func (r *ClientRepository) InitClientTables() {
-	// Create client table
-	query := `
-	CREATE TABLE IF NOT EXISTS client (
-		client_id VARCHAR(50) PRIMARY KEY,
-		first_name CHAR(50) NOT NULL,
-		last_name CHAR(50) NOT NULL,
-		dob DATE NOT NULL,
-		gender VARCHAR(20) NOT NULL,
-		email VARCHAR(100) UNIQUE NOT NULL,
-		phone VARCHAR(15) UNIQUE NOT NULL,
-		address VARCHAR(100) NOT NULL,
-		city VARCHAR(50) NOT NULL,
-		state VARCHAR(50) NOT NULL,
-		country VARCHAR(50) NOT NULL,
-		postal_code VARCHAR(10) NOT NULL,
-		verification_status VARCHAR(20) DEFAULT 'unverified'
-	);`
-	_, err := database.DB.Exec(query)
-	if err != nil {
-		log.Fatal("❌ Error creating client table:", err)
-	}
-
-	counterTable := `
-	CREATE TABLE IF NOT EXISTS counter (
-		id INT PRIMARY KEY AUTO_INCREMENT,
-		name VARCHAR(50) UNIQUE NOT NULL,
-		value INT NOT NULL
-	);`
-	_, err = database.DB.Exec(counterTable)
-	if err != nil {
-		log.Fatal("❌ Error creating counter table:", err)
-	}
-
-	// Initialize client counter if not exists
-	_, err = database.DB.Exec("INSERT IGNORE INTO counter (name, value) VALUES ('client', 0);")
-	if err != nil {
-		log.Fatal("❌ Error initializing client counter:", err)
-	}
-
-	fmt.Println("✅ Client table checked/created!")
-}
-
 // EmailExists checks if an email already exists in the database
 func (r *ClientRepository) EmailExists(email string) (bool, error) {
+	defer metrics.ObserveQuery("ClientRepository", "EmailExists", time.Now())
 	var count int
 	query := `SELECT COUNT(*) FROM client WHERE email = ?`
-	err := database.DB.QueryRow(query, email).Scan(&count)
+	err := r.db.QueryRow(query, email).Scan(&count)
 	if err != nil {
 		return false, fmt.Errorf("failed to check email uniqueness: %v", err)
 	}
@@ -97,21 +53,23 @@ func (r *ClientRepository) EmailExists(email string) (bool, error) {
 
 // PhoneExists checks if a phone number already exists in the database
 func (r *ClientRepository) PhoneExists(phone string) (bool, error) {
+	defer metrics.ObserveQuery("ClientRepository", "PhoneExists", time.Now())
 	var count int
 	query := `SELECT COUNT(*) FROM client WHERE phone = ?`
-	err := database.DB.QueryRow(query, phone).Scan(&count)
+	err := r.db.QueryRow(query, phone).Scan(&count)
 	if err != nil {
 		return false, fmt.Errorf("failed to check phone uniqueness: %v", err)
 	}
 	return count > 0, nil
 }
 
-// CreateClient inserts a new client into the database
-func (r *ClientRepository) CreateClient(client models.Client, AgentID int) (models.Client, error) {
+// CreateClient inserts a new client, assigns it to the agent and records ClientCreated in one transaction
+func (r *ClientRepository) CreateClient(client models.Client, AgentID int, meta models.EventMeta) (models.Client, error) {
+	defer metrics.ObserveQuery("ClientRepository", "CreateClient", time.Now())
 	var currentValue int
 
 	// Begin a transaction to ensure atomicity
-	tx, err := database.DB.Begin()
+	tx, err := r.db.Begin()
 	if err != nil {
 		return models.Client{}, fmt.Errorf("failed to begin transaction: %v", err)
 	}
@@ -158,30 +116,35 @@ func (r *ClientRepository) CreateClient(client models.Client, AgentID int) (mode
 		return models.Client{}, fmt.Errorf("failed to insert client: %v", err)
 	}
 
-	// Commit the transaction
-	if err = tx.Commit(); err != nil {
-		return models.Client{}, fmt.Errorf("failed to commit transaction: %v", err)
-	}
-
 	// ✅ Insert into agent_client with agent_id
 	agentClientQuery := `
 	INSERT INTO agent_client 
 	(client_id, id) 
 	VALUES (?, ?)`
 
-	_, err = database.DB.Exec(agentClientQuery, client.ClientID, AgentID)
+	_, err = tx.Exec(agentClientQuery, client.ClientID, AgentID)
 	if err != nil {
 		return models.Client{}, fmt.Errorf("failed to insert into agent_client: %v", err)
 	}
 
+	if err = events.AppendTx(tx, meta, events.ClientCreated{Client: client}); err != nil {
+		return models.Client{}, err
+	}
+
+	// Commit the transaction
+	if err = tx.Commit(); err != nil {
+		return models.Client{}, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
 	return client, nil
 }
 
 func (r *ClientRepository) AgentExists(AgentID int) (bool, error) {
+	defer metrics.ObserveQuery("ClientRepository", "AgentExists", time.Now())
 	query := `SELECT 1 FROM users WHERE id = ? AND role = 'agent'`
 	// check with agent exisit
 	var exists int
-	err := database.DB.QueryRow(query, AgentID).Scan(&exists)
+	err := r.db.QueryRow(query, AgentID).Scan(&exists)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return false, nil
@@ -193,17 +156,10 @@ func (r *ClientRepository) AgentExists(AgentID int) (bool, error) {
 
 // GetClientByID retrieves a client by their ID
 func (r *ClientRepository) GetClientByID(clientID string) (models.Client, error) {
+	defer metrics.ObserveQuery("ClientRepository", "GetClientByID", time.Now())
 	query := `SELECT * FROM client WHERE client_id = ?`
 
-	var client models.Client
-	err := database.DB.QueryRow(query, clientID).Scan(
-		&client.ClientID, &client.FirstName, &client.LastName,
-		&client.DOB, &client.Gender, &client.Email,
-		&client.Phone, &client.Address, &client.City,
-		&client.State, &client.Country, &client.PostalCode,
-		&client.VerificationStatus,
-	)
-
+	client, err := scanClient(r.db.QueryRow(query, clientID))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return models.Client{}, fmt.Errorf("client with ID %v not found", clientID)
@@ -214,8 +170,23 @@ func (r *ClientRepository) GetClientByID(clientID string) (models.Client, error)
 	return client, nil
 }
 
-// UpdateClient updates an existing client's information
-func (r *ClientRepository) UpdateClient(client models.Client) (models.Client, error) {
+// scanClient reads a SELECT * FROM client row
+func scanClient(row *sql.Row) (models.Client, error) {
+	var client models.Client
+	err := row.Scan(
+		&client.ClientID, &client.FirstName, &client.LastName,
+		&client.DOB, &client.Gender, &client.Email,
+		&client.Phone, &client.Address, &client.City,
+		&client.State, &client.Country, &client.PostalCode,
+		&client.VerificationStatus,
+	)
+	return client, err
+}
+
+// UpdateClient updates an existing client's information and records ClientUpdated with the
+// row as it was before and after, in one transaction
+func (r *ClientRepository) UpdateClient(client models.Client, meta models.EventMeta) (models.Client, error) {
+	defer metrics.ObserveQuery("ClientRepository", "UpdateClient", time.Now())
 	// Check if client exists
 	_, err := r.GetClientByID(client.ClientID)
 	if err != nil {
@@ -224,7 +195,7 @@ func (r *ClientRepository) UpdateClient(client models.Client) (models.Client, er
 
 	// Check email uniqueness if changed
 	var currentEmail string
-	err = database.DB.QueryRow("SELECT email FROM client WHERE client_id = ?", client.ClientID).Scan(&currentEmail)
+	err = r.db.QueryRow("SELECT email FROM client WHERE client_id = ?", client.ClientID).Scan(&currentEmail)
 	if err != nil {
 		return models.Client{}, fmt.Errorf("failed to retrieve current email: %v", err)
 	}
@@ -241,7 +212,7 @@ func (r *ClientRepository) UpdateClient(client models.Client) (models.Client, er
 
 	// Check phone uniqueness if changed
 	var currentPhone string
-	err = database.DB.QueryRow("SELECT phone FROM client WHERE client_id = ?", client.ClientID).Scan(&currentPhone)
+	err = r.db.QueryRow("SELECT phone FROM client WHERE client_id = ?", client.ClientID).Scan(&currentPhone)
 	if err != nil {
 		return models.Client{}, fmt.Errorf("failed to retrieve current phone: %v", err)
 	}
@@ -256,6 +227,17 @@ func (r *ClientRepository) UpdateClient(client models.Client) (models.Client, er
 		}
 	}
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Client{}, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	before, err := scanClient(tx.QueryRow(`SELECT * FROM client WHERE client_id = ? FOR UPDATE`, client.ClientID))
+	if err != nil {
+		return models.Client{}, fmt.Errorf("failed to retrieve client: %v", err)
+	}
+
 	query := `
     UPDATE client 
     SET first_name = ?, last_name = ?, dob = ?, gender = ?,
@@ -263,7 +245,7 @@ func (r *ClientRepository) UpdateClient(client models.Client) (models.Client, er
         state = ?, country = ?, postal_code = ?
     WHERE client_id = ?`
 
-	_, err = database.DB.Exec(query,
+	_, err = tx.Exec(query,
 		client.FirstName, client.LastName, client.DOB, client.Gender,
 		client.Email, client.Phone, client.Address, client.City,
 		client.State, client.Country, client.PostalCode, client.ClientID,
@@ -274,28 +256,135 @@ func (r *ClientRepository) UpdateClient(client models.Client) (models.Client, er
 	}
 
 	// Retrieve the updated client to return
-	return r.GetClientByID(client.ClientID)
+	after, err := scanClient(tx.QueryRow(`SELECT * FROM client WHERE client_id = ?`, client.ClientID))
+	if err != nil {
+		return models.Client{}, fmt.Errorf("failed to retrieve client: %v", err)
+	}
+
+	if err := events.AppendTx(tx, meta, events.ClientUpdated{Before: before, After: after, RevertOf: meta.RevertOf}); err != nil {
+		return models.Client{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Client{}, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return after, nil
 }
 
-// DeleteClient removes a client's profile from the database
-func (r *ClientRepository) DeleteClient(clientID string) error {
-	query := `DELETE FROM client WHERE client_id = ?`
+// RevertClient sets the fields in restore, provided each field in expected still holds its
+// value, and records ClientUpdated in the same transaction. The row stays locked from the check
+// to the write; when a field has changed, nothing is written and the conflicts are returned.
+func (r *ClientRepository) RevertClient(clientID string, expected, restore map[string]string, meta models.EventMeta) (models.Client, []models.RevertConflict, error) {
+	defer metrics.ObserveQuery("ClientRepository", "RevertClient", time.Now())
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Client{}, nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
 
-	result, err := database.DB.Exec(query, clientID)
+	before, err := scanClient(tx.QueryRow(`SELECT * FROM client WHERE client_id = ? FOR UPDATE`, clientID))
+	if err == sql.ErrNoRows {
+		return models.Client{}, nil, fmt.Errorf("client with ID %v not found", clientID)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to delete client: %v", err)
+		return models.Client{}, nil, fmt.Errorf("failed to retrieve client: %v", err)
+	}
+
+	conflicts, err := clientConflicts(before, expected)
+	if err != nil || len(conflicts) > 0 {
+		return before, conflicts, err
+	}
+
+	after := before
+	if err := setClientFields(&after, restore); err != nil {
+		return models.Client{}, nil, err
+	}
+
+	var taken int
+	if after.Email != before.Email {
+		err := tx.QueryRow(`SELECT COUNT(*) FROM client WHERE email = ? AND client_id <> ?`, after.Email, clientID).Scan(&taken)
+		if err != nil {
+			return models.Client{}, nil, fmt.Errorf("failed to check email: %v", err)
+		}
+		if taken > 0 {
+			return models.Client{}, nil, fmt.Errorf("email address already exists")
+		}
+	}
+	if after.Phone != before.Phone {
+		err := tx.QueryRow(`SELECT COUNT(*) FROM client WHERE phone = ? AND client_id <> ?`, after.Phone, clientID).Scan(&taken)
+		if err != nil {
+			return models.Client{}, nil, fmt.Errorf("failed to check phone: %v", err)
+		}
+		if taken > 0 {
+			return models.Client{}, nil, fmt.Errorf("phone number already exists")
+		}
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
+	// Only the restored columns are written; setClientFields has checked they are client columns
+	columns := make([]string, 0, len(restore))
+	for column := range restore {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	set := make([]string, len(columns))
+	args := make([]interface{}, 0, len(columns)+1)
+	for i, column := range columns {
+		set[i] = column + " = ?"
+		args = append(args, restore[column])
+	}
+	args = append(args, clientID)
+
+	if _, err := tx.Exec(`UPDATE client SET `+strings.Join(set, ", ")+` WHERE client_id = ?`, args...); err != nil {
+		return models.Client{}, nil, fmt.Errorf("failed to revert client: %v", err)
+	}
+
+	if err := events.AppendTx(tx, meta, events.ClientUpdated{Before: before, After: after, RevertOf: meta.RevertOf}); err != nil {
+		return models.Client{}, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Client{}, nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return after, nil, nil
+}
+
+// DeleteClient removes a client's profile from the database and records ClientDeleted in the
+// same transaction
+func (r *ClientRepository) DeleteClient(clientID string, meta models.EventMeta) error {
+	defer metrics.ObserveQuery("ClientRepository", "DeleteClient", time.Now())
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	client, err := scanClient(tx.QueryRow(`SELECT * FROM client WHERE client_id = ? FOR UPDATE`, clientID))
+	if err == sql.ErrNoRows {
 		return fmt.Errorf("no rows affected; client with ID %s not found", clientID)
 	}
+	if err != nil {
+		return fmt.Errorf("failed to retrieve client: %v", err)
+	}
+
+	query := `DELETE FROM client WHERE client_id = ?`
 
+	if _, err := tx.Exec(query, clientID); err != nil {
+		return fmt.Errorf("failed to delete client: %v", err)
+	}
+
+	if err := events.AppendTx(tx, meta, events.ClientDeleted{Client: client}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
 	return nil
 }
 
 // VerifyClient updates a client's verification status
 func (r *ClientRepository) VerifyClient(clientID string) error {
+	defer metrics.ObserveQuery("ClientRepository", "VerifyClient", time.Now())
 	// Check if client exists
 	_, err := r.GetClientByID(clientID)
 	if err != nil {
@@ -306,7 +395,7 @@ func (r *ClientRepository) VerifyClient(clientID string) error {
 	SELECT verification_status FROM client WHERE client_id = ?`
 
 	var currentStatus string
-	err = database.DB.QueryRow(query, clientID).Scan(&currentStatus)
+	err = r.db.QueryRow(query, clientID).Scan(&currentStatus)
 	if err != nil {
 		return fmt.Errorf("failed to retrieve verification status: %v", err)
 	}
@@ -320,7 +409,7 @@ func (r *ClientRepository) VerifyClient(clientID string) error {
     SET verification_status = 'verified' 
     WHERE client_id = ?`
 
-	_, err = database.DB.Exec(updateQuery, clientID)
+	_, err = r.db.Exec(updateQuery, clientID)
 	if err != nil {
 		return fmt.Errorf("failed to update verification status: %v", err)
 	}
@@ -329,16 +418,67 @@ func (r *ClientRepository) VerifyClient(clientID string) error {
 }
 
 
-// GetAllClients retrieves all clients from the database
-func (r *ClientRepository) GetAllClients() ([]models.Client, error) {
-	query := `SELECT * FROM client`
-	
-	rows, err := database.DB.Query(query)
+// ListSpec lists the sort keys and filters accepted by client listings
+var ListSpec = listquery.Spec{
+	IDColumn: "c.client_id",
+	SortColumns: map[string]string{
+		"client_id":  "c.client_id",
+		"first_name": "c.first_name",
+		"last_name":  "c.last_name",
+		"country":    "c.country",
+	},
+	DefaultSort: "client_id",
+	Filters: map[string]listquery.Filter{
+		"country":             {Column: "c.country", Op: "="},
+		"state":               {Column: "c.state", Op: "="},
+		"city":                {Column: "c.city", Op: "="},
+		"gender":              {Column: "c.gender", Op: "="},
+		"verification_status": {Column: "c.verification_status", Op: "="},
+	},
+}
+
+// AgentColumn is the column holding the assigned agent, for scoping a listing to one agent
+const AgentColumn = "ac.id"
+
+// ListField returns a client's value for a ListSpec column
+func ListField(client models.Client, column string) interface{} {
+	switch column {
+	case "c.client_id":
+		return client.ClientID
+	case "c.first_name":
+		return client.FirstName
+	case "c.last_name":
+		return client.LastName
+	case "c.gender":
+		return client.Gender
+	case "c.city":
+		return client.City
+	case "c.state":
+		return client.State
+	case "c.country":
+		return client.Country
+	case "c.verification_status":
+		return client.VerificationStatus
+	}
+	return nil
+}
+
+// ListClients retrieves one page of clients matching the query
+func (r *ClientRepository) ListClients(q listquery.Query) ([]models.Client, error) {
+	defer metrics.ObserveQuery("ClientRepository", "ListClients", time.Now())
+	clause, args := q.SQL()
+	query := `
+		SELECT c.client_id, c.first_name, c.last_name, c.dob, c.gender, c.email,
+			c.phone, c.address, c.city, c.state, c.country, c.postal_code, c.verification_status
+		FROM client c
+		LEFT JOIN agent_client ac ON c.client_id = ac.client_id` + clause
+
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve clients: %v", err)
 	}
 	defer rows.Close()
-	
+
 	var clients []models.Client
 	for rows.Next() {
 		var client models.Client
@@ -354,47 +494,26 @@ func (r *ClientRepository) GetAllClients() ([]models.Client, error) {
 		}
 		clients = append(clients, client)
 	}
-	
+
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating client rows: %v", err)
 	}
-	
+
 	return clients, nil
 }
 
-// GetClientsByAgentID retrieves all clients associated with a specific agent
-func (r *ClientRepository) GetClientsByAgentID(agentID int) ([]models.Client, error) {
-	query := `
-		SELECT c.* FROM client c
-		JOIN agent_client ac ON c.client_id = ac.client_id
-		WHERE ac.id = ?
-	`
-	
-	rows, err := database.DB.Query(query, agentID)
+// IsClientOwnedByAgent checks if the client is assigned to the given agent
+func (r *ClientRepository) IsClientOwnedByAgent(clientID string, agentID int) (bool, error) {
+	defer metrics.ObserveQuery("ClientRepository", "IsClientOwnedByAgent", time.Now())
+	query := `SELECT id FROM agent_client WHERE client_id = ?`
+
+	var dbAgentID sql.NullInt64
+	err := r.db.QueryRow(query, clientID).Scan(&dbAgentID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve clients for agent %d: %v", agentID, err)
-	}
-	defer rows.Close()
-	
-	var clients []models.Client
-	for rows.Next() {
-		var client models.Client
-		err := rows.Scan(
-			&client.ClientID, &client.FirstName, &client.LastName,
-			&client.DOB, &client.Gender, &client.Email,
-			&client.Phone, &client.Address, &client.City,
-			&client.State, &client.Country, &client.PostalCode,
-			&client.VerificationStatus,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("error scanning client row: %v", err)
+		if err == sql.ErrNoRows {
+			return false, fmt.Errorf("client not found")
 		}
-		clients = append(clients, client)
-	}
-	
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating client rows: %v", err)
+		return false, err
 	}
-	
-	return clients, nil
-}
\ No newline at end of file
+	return dbAgentID.Valid && int(dbAgentID.Int64) == agentID, nil
+}
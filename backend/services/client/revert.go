@@ -0,0 +1,91 @@
+package client
+
+import (
+	"backend/models"
+	"backend/services/events"
+	"context"
+	"fmt"
+	"sort"
+)
+
+// clientField returns the field of client an update may set under its JSON name, or nil
+func clientField(client *models.Client, name string) *string {
+	switch name {
+	case "first_name":
+		return &client.FirstName
+	case "last_name":
+		return &client.LastName
+	case "dob":
+		return &client.DOB
+	case "gender":
+		return &client.Gender
+	case "email":
+		return &client.Email
+	case "phone":
+		return &client.Phone
+	case "address":
+		return &client.Address
+	case "city":
+		return &client.City
+	case "state":
+		return &client.State
+	case "country":
+		return &client.Country
+	case "postal_code":
+		return &client.PostalCode
+	}
+	return nil
+}
+
+// setClientFields sets the fields in values, by JSON name
+func setClientFields(client *models.Client, values map[string]string) error {
+	for name, value := range values {
+		field := clientField(client, name)
+		if field == nil {
+			return fmt.Errorf("client field %s cannot be changed by an update", name)
+		}
+		*field = value
+	}
+	return nil
+}
+
+// clientConflicts returns the fields in expected whose value client no longer holds, by name
+func clientConflicts(client models.Client, expected map[string]string) ([]models.RevertConflict, error) {
+	var conflicts []models.RevertConflict
+	for name, value := range expected {
+		field := clientField(&client, name)
+		if field == nil {
+			return nil, fmt.Errorf("client field %s cannot be changed by an update", name)
+		}
+		if *field != value {
+			conflicts = append(conflicts, models.RevertConflict{Field: name, Expected: value, Current: *field})
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Field < conflicts[j].Field })
+	return conflicts, nil
+}
+
+// RevertClient puts back the fields in restore, provided every field in expected still holds
+// its value. The check and the write are one step in the repository, so a change made since
+// the revert was planned is reported as a conflict rather than overwritten; on a conflict
+// nothing is written.
+func (s *ClientService) RevertClient(ctx context.Context, clientID string, expected, restore map[string]string, agentID int) (models.Client, []models.RevertConflict, error) {
+	current, err := s.GetClient(clientID)
+	if err != nil {
+		return models.Client{}, nil, err
+	}
+
+	target := current
+	if err := setClientFields(&target, restore); err != nil {
+		return models.Client{}, nil, err
+	}
+	if err := validateClient(target); err != nil {
+		return models.Client{}, nil, err
+	}
+
+	reverted, conflicts, err := s.repo.RevertClient(clientID, expected, restore, events.NewMeta(ctx, agentID))
+	if err != nil {
+		return models.Client{}, nil, fmt.Errorf("failed to revert client: %v", err)
+	}
+	return reverted, conflicts, nil
+}
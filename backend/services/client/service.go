@@ -1,10 +1,11 @@
 package client
 
 import (
-	"backend/database"
 	"backend/models"
+	"backend/services/events"
 	"backend/services/interfaces"
-	"backend/services/observer"
+	"backend/services/listquery"
+	"context"
 	"database/sql"
 	"fmt"
 	"regexp"
@@ -23,32 +24,21 @@ var validGenders = map[string]bool{
 
 // ClientService struct to interact with the repository layer
 type ClientService struct {
-	repo            *ClientRepository
-	ObserverManager *observer.ObserverManager
+	repo            interfaces.ClientRepositoryInterface
 	AccountService interfaces.AccountServiceInterface
 	AgentClientService interfaces.AgentClientServiceInterface
 }
 
 // ✅ IsClientOwnedByAgent checks if the client belongs to the given agent
 func (s *ClientService) IsClientOwnedByAgent(clientID string, agentID int) (bool, error) {
-	var dbAgentID int
-	query := "SELECT agent_id FROM clients WHERE client_id = ?"
-	err := database.DB.QueryRow(query, clientID).Scan(&dbAgentID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return false, fmt.Errorf("client not found")
-		}
-		return false, err
-	}
-	return dbAgentID == agentID, nil
+	return s.repo.IsClientOwnedByAgent(clientID, agentID)
 }
 
 
 // NewClientService initializes the client service
-func NewClientService(repo *ClientRepository, observerManager *observer.ObserverManager) *ClientService {
+func NewClientService(repo interfaces.ClientRepositoryInterface) *ClientService {
 	return &ClientService{
-		repo:            repo,
-		ObserverManager: observerManager, // Pass the ObserverManager here
+		repo: repo,
 	}
 }
 
@@ -62,7 +52,7 @@ func (s *ClientService) SetAgentClientService(agentClientService interfaces.Agen
 }
 
 // CreateClient processes user creation request
-func (s *ClientService) CreateClient(client models.Client, AgentID int) (models.Client, error) {
+func (s *ClientService) CreateClient(ctx context.Context, client models.Client, AgentID int) (models.Client, error) {
 	// ✅ Check if agent exists
 	exists, err := s.repo.AgentExists(AgentID)
 	if err != nil {
@@ -90,15 +80,12 @@ func (s *ClientService) CreateClient(client models.Client, AgentID int) (models.
 	}
 	// -------------------------------------------------------------------------------------------
 
-	// Call repository function to insert client
-	createdClient, err := s.repo.CreateClient(client, AgentID)
+	// Call repository function to insert client; ClientCreated is delivered from the outbox
+	createdClient, err := s.repo.CreateClient(client, AgentID, events.NewMeta(ctx, AgentID))
 	if err != nil {
 		return models.Client{}, fmt.Errorf("failed to create client: %v", err)
 	}
 
-
-	s.ObserverManager.NotifyClientCreate(AgentID, createdClient.ClientID, &createdClient)
-
 	return createdClient, nil
 }
 
@@ -117,26 +104,21 @@ func (s *ClientService) GetClient(clientID string) (models.Client, error) {
 }
 
 // UpdateClient updates client information
-func (s *ClientService) UpdateClient(client models.Client, AgentID int) (models.Client, error) {
+func (s *ClientService) UpdateClient(ctx context.Context, client models.Client, AgentID int) (models.Client, error) {
 	if err := validateClient(client); err != nil {
 		return models.Client{}, err
 	}
 
-	updatedClient, err := s.repo.UpdateClient(client)
+	updatedClient, err := s.repo.UpdateClient(client, events.NewMeta(ctx, AgentID))
 	if err != nil {
 		return models.Client{}, fmt.Errorf("failed to update client: %v", err)
 	}
 
-	// Notify observers after client update
-	if s.ObserverManager != nil {
-		s.ObserverManager.NotifyClientUpdate(AgentID, client.ClientID, &client, &updatedClient)
-	}
-
 	return updatedClient, nil
 }
 
-// DeleteClient removes a client profile
-func (s *ClientService) DeleteClient(clientID string) error {
+// DeleteClient removes a client profile on behalf of agentID
+func (s *ClientService) DeleteClient(ctx context.Context, clientID string, agentID int) error {
 	if clientID == "" {
 		return fmt.Errorf("client ID cannot be empty")
 	}
@@ -166,16 +148,11 @@ func (s *ClientService) DeleteClient(clientID string) error {
 		return fmt.Errorf("found active accounts: %v", strings.Join(existing_acc_ids, ", "))
 	}
 
-	err := s.repo.DeleteClient(clientID)
+	err := s.repo.DeleteClient(clientID, events.NewMeta(ctx, agentID))
 	if err != nil {
 		return fmt.Errorf("failed to delete client: %v", err)
 	}
 
-	// Notify observers after client deletion
-	if s.ObserverManager != nil {
-		s.ObserverManager.NotifyClientDelete(0, clientID, nil)
-	}
-
 	return nil
 }
 
@@ -294,34 +271,35 @@ func isValidPhone(phone string) bool {
 	return phoneRegex.MatchString(phone)
 }
 
-// GetAllClients retrieves a list of all clients
-func (s *ClientService) GetAllClients() ([]models.Client, error) {
-	clients, err := s.repo.GetAllClients()
+// GetAllClients retrieves one page of all clients
+func (s *ClientService) GetAllClients(q listquery.Query) (listquery.Page[models.Client], error) {
+	clients, err := s.repo.ListClients(q)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve clients: %v", err)
+		return listquery.Page[models.Client]{}, fmt.Errorf("failed to retrieve clients: %v", err)
 	}
 	
-	return clients, nil
+	return listquery.NewPage(clients, q, ListField)
 }
 
-// GetClientsByAgentID retrieves clients assigned to a specific agent
-func (s *ClientService) GetClientsByAgentID(agentID int) ([]models.Client, error) {
+// GetClientsByAgentID retrieves one page of the clients assigned to a specific agent
+func (s *ClientService) GetClientsByAgentID(agentID int, q listquery.Query) (listquery.Page[models.Client], error) {
 	// Check if agent exists
 	exists, err := s.repo.AgentExists(agentID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check agent existence: %v", err)
+		return listquery.Page[models.Client]{}, fmt.Errorf("failed to check agent existence: %v", err)
 	}
 
 	if !exists {
-		return nil, fmt.Errorf("agent with ID %d not found", agentID)
+		return listquery.Page[models.Client]{}, fmt.Errorf("agent with ID %d not found", agentID)
 	}
 	
-	clients, err := s.repo.GetClientsByAgentID(agentID)
+	q = q.Where(AgentColumn, "=", agentID)
+	clients, err := s.repo.ListClients(q)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve clients for agent %d: %v", agentID, err)
+		return listquery.Page[models.Client]{}, fmt.Errorf("failed to retrieve clients for agent %d: %v", agentID, err)
 	}
 	
-	return clients, nil
+	return listquery.NewPage(clients, q, ListField)
 }
 
 
@@ -0,0 +1,182 @@
+package client
+
+import (
+	"backend/database/memstore"
+	"backend/models"
+	"context"
+	"strings"
+	"testing"
+)
+
+func validClient() models.Client {
+	return models.Client{
+		FirstName:  "Jane",
+		LastName:   "Tan",
+		DOB:        "1990-04-12",
+		Gender:     "Female",
+		Email:      "jane.tan@example.com",
+		Phone:      "+6591234567",
+		Address:    "12 Orchard Road",
+		City:       "Singapore",
+		State:      "Central",
+		Country:    "Singapore",
+		PostalCode: "238801",
+	}
+}
+
+func newTestService(t *testing.T) (*ClientService, *memstore.Store) {
+	t.Helper()
+	store := memstore.New()
+	store.SeedUser(models.User{ID: 1, Role: "Agent"})
+	store.SeedUser(models.User{ID: 2, Role: "Admin"})
+	return NewClientService(NewMemoryClientRepository(store)), store
+}
+
+func TestCreateClientValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *models.Client)
+		wantErr string
+	}{
+		{"short first name", func(c *models.Client) { c.FirstName = "J" }, "first name must be 2-50 characters"},
+		{"digits in last name", func(c *models.Client) { c.LastName = "Tan2" }, "last name can only contain letters and spaces"},
+		{"bad date", func(c *models.Client) { c.DOB = "12/04/1990" }, "invalid date format"},
+		{"under 18", func(c *models.Client) { c.DOB = "2020-01-01" }, "age must be at least 18 years"},
+		{"unknown gender", func(c *models.Client) { c.Gender = "Unknown" }, "invalid gender"},
+		{"bad email", func(c *models.Client) { c.Email = "jane.tan" }, "invalid email format"},
+		{"phone without +", func(c *models.Client) { c.Phone = "6591234567" }, "phone must start with +"},
+		{"short address", func(c *models.Client) { c.Address = "12" }, "address must be between 5 and 100 characters"},
+		{"city with digits", func(c *models.Client) { c.City = "S1ngapore" }, "city contains invalid characters"},
+		{"short postal code", func(c *models.Client) { c.PostalCode = "12" }, "postal code must be between 4 and 10 characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, store := newTestService(t)
+			client := validClient()
+			tt.modify(&client)
+
+			_, err := service.CreateClient(context.Background(), client, 1)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("CreateClient error = %v, want %q", err, tt.wantErr)
+			}
+			if len(store.Clients) != 0 {
+				t.Errorf("invalid client was stored: %v", store.Clients)
+			}
+		})
+	}
+}
+
+func TestCreateClientAssignsAgent(t *testing.T) {
+	service, store := newTestService(t)
+
+	created, err := service.CreateClient(context.Background(), validClient(), 1)
+	if err != nil {
+		t.Fatalf("CreateClient: %v", err)
+	}
+	if created.ClientID == "" || created.VerificationStatus != "unverified" {
+		t.Errorf("created client = %+v, want an ID and unverified status", created)
+	}
+	if agentID := store.AgentClients[created.ClientID]; agentID == nil || *agentID != 1 {
+		t.Errorf("client assigned to %v, want agent 1", agentID)
+	}
+	if len(store.OutboxEvents) != 1 {
+		t.Errorf("outbox holds %d events, want ClientCreated", len(store.OutboxEvents))
+	}
+}
+
+func TestCreateClientRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		agentID int
+		modify  func(c *models.Client)
+		wantErr string
+	}{
+		{"unknown agent", 9, func(*models.Client) {}, "agent's id not found"},
+		{"admin is not an agent", 2, func(*models.Client) {}, "agent's id not found"},
+		{"duplicate email", 1, func(c *models.Client) { c.Phone = "+6598765432" }, "email address already exists"},
+		{"duplicate phone", 1, func(c *models.Client) { c.Email = "other@example.com" }, "phone number already exists"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := newTestService(t)
+			if _, err := service.CreateClient(context.Background(), validClient(), 1); err != nil {
+				t.Fatalf("CreateClient: %v", err)
+			}
+
+			client := validClient()
+			tt.modify(&client)
+			_, err := service.CreateClient(context.Background(), client, tt.agentID)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("CreateClient error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdateClientValidates(t *testing.T) {
+	service, store := newTestService(t)
+	created, err := service.CreateClient(context.Background(), validClient(), 1)
+	if err != nil {
+		t.Fatalf("CreateClient: %v", err)
+	}
+
+	invalid := created
+	invalid.Email = "not-an-email"
+	if _, err := service.UpdateClient(context.Background(), invalid, 1); err == nil {
+		t.Fatal("UpdateClient accepted an invalid email")
+	}
+	if store.Clients[created.ClientID].Email != created.Email {
+		t.Errorf("invalid update was stored")
+	}
+
+	changed := created
+	changed.City = "Jurong East"
+	updated, err := service.UpdateClient(context.Background(), changed, 1)
+	if err != nil {
+		t.Fatalf("UpdateClient: %v", err)
+	}
+	if updated.City != "Jurong East" || store.Clients[created.ClientID].City != "Jurong East" {
+		t.Errorf("updated city = %q, want Jurong East", updated.City)
+	}
+}
+
+func TestRevertClientWritesOnlyRestoredFields(t *testing.T) {
+	service, store := newTestService(t)
+	created, err := service.CreateClient(context.Background(), validClient(), 1)
+	if err != nil {
+		t.Fatalf("CreateClient: %v", err)
+	}
+
+	// The city changed, then another agent changed the address
+	changed := created
+	changed.City = "Jurong East"
+	changed.Address = "1 Jurong Gateway Road"
+	if _, err := service.UpdateClient(context.Background(), changed, 1); err != nil {
+		t.Fatalf("UpdateClient: %v", err)
+	}
+
+	expected := map[string]string{"city": "Jurong East"}
+	restore := map[string]string{"city": "Singapore"}
+	reverted, conflicts, err := service.RevertClient(context.Background(), created.ClientID, expected, restore, 2)
+	if err != nil || len(conflicts) != 0 {
+		t.Fatalf("RevertClient = %v, %v", conflicts, err)
+	}
+	if reverted.City != "Singapore" || reverted.Address != "1 Jurong Gateway Road" {
+		t.Errorf("reverted client = %+v, want the city restored and the later address kept", reverted)
+	}
+
+	// The city no longer holds the value the revert expects: nothing is written
+	_, conflicts, err = service.RevertClient(context.Background(), created.ClientID, expected, restore, 2)
+	if err != nil || len(conflicts) != 1 || conflicts[0].Field != "city" || conflicts[0].Current != "Singapore" {
+		t.Fatalf("RevertClient conflicts = %+v, %v; want city", conflicts, err)
+	}
+	if events := len(store.OutboxEvents); events != 3 {
+		t.Errorf("outbox holds %d events, want the conflicting revert to record none", events)
+	}
+
+	if _, _, err := service.RevertClient(context.Background(), created.ClientID, nil, map[string]string{"verification_status": "verified"}, 2); err == nil {
+		t.Error("RevertClient set a field an update cannot change")
+	}
+}
@@ -0,0 +1,53 @@
+package communicationlogs
+
+import (
+	"backend/database/memstore"
+	"backend/models"
+	"backend/services/interfaces"
+	"fmt"
+	"time"
+)
+
+var _ interfaces.CommunicationLogRepositoryInterface = (*MemoryCommunicationLogRepository)(nil)
+
+// MemoryCommunicationLogRepository is an in-memory implementation of interfaces.CommunicationLogRepositoryInterface
+type MemoryCommunicationLogRepository struct {
+	store *memstore.Store
+}
+
+// NewMemoryCommunicationLogRepository initializes a new MemoryCommunicationLogRepository on the given store
+func NewMemoryCommunicationLogRepository(store *memstore.Store) *MemoryCommunicationLogRepository {
+	return &MemoryCommunicationLogRepository{store: store}
+}
+
+// InsertCommunicationLog stores a new communication log
+func (r *MemoryCommunicationLogRepository) InsertCommunicationLog(logID int, clientID string, agentID int, emailSubject, emailStatus, requestID string) error {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	r.store.CommunicationLogs = append(r.store.CommunicationLogs, models.CommunicationLog{
+		ID:           r.store.NextCommunicationLogID,
+		LogID:        logID,
+		ClientID:     clientID,
+		AgentID:      agentID,
+		EmailSubject: emailSubject,
+		EmailStatus:  emailStatus,
+		Timestamp:    time.Now().UTC().Format("2006-01-02 15:04:05"),
+		RequestID:    requestID,
+	})
+	r.store.NextCommunicationLogID++
+	return nil
+}
+
+// GetCommunicationLogByLogID retrieves a specific communication log by log ID
+func (r *MemoryCommunicationLogRepository) GetCommunicationLogByLogID(logID int) (models.CommunicationLog, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	for _, log := range r.store.CommunicationLogs {
+		if log.LogID == logID {
+			return log, nil
+		}
+	}
+	return models.CommunicationLog{}, fmt.Errorf("no communication log found with log_id %d", logID)
+}
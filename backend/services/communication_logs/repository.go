@@ -1,49 +1,31 @@
 package communicationlogs
 
 import (
-	"backend/database"
 	"backend/models"
+	"backend/services/metrics"
 	"database/sql"
 	"fmt"
+	"time"
 )
 
-// CommunicationLogRepository handles database operations
-type CommunicationLogRepository struct{}
-
-// NewCommunicationLogRepository initializes the repository
-func NewCommunicationLogRepository() *CommunicationLogRepository {
-	repo := &CommunicationLogRepository{}
-	repo.InitTable()
-	return repo
+// CommunicationLogRepository is the MySQL implementation of interfaces.CommunicationLogRepositoryInterface
+type CommunicationLogRepository struct {
+	db *sql.DB
 }
 
-// InitTable ensures the communication_logs table exists
-func (r *CommunicationLogRepository) InitTable() {
-	query := `
-	CREATE TABLE IF NOT EXISTS communication_logs (
-		id INT AUTO_INCREMENT PRIMARY KEY,
-		log_id INT NOT NULL,
-		client_id VARCHAR(255) NOT NULL,  -- client_id is now a string
-		agent_id INT NOT NULL,
-		email_subject VARCHAR(255) NOT NULL,
-		email_status ENUM('Sent', 'Failed') NOT NULL,
-		timestamp DATETIME DEFAULT CURRENT_TIMESTAMP
-	);`
-	_, err := database.DB.Exec(query)
-	if err != nil {
-		fmt.Println("Error creating communication_logs table:", err)
-	} else {
-		fmt.Println("communication_logs table checked/created!")
-	}
+// NewCommunicationLogRepository initializes the repository
+func NewCommunicationLogRepository(db *sql.DB) *CommunicationLogRepository {
+	return &CommunicationLogRepository{db: db}
 }
 
 // InsertCommunicationLog inserts a new communication log
-func (r *CommunicationLogRepository) InsertCommunicationLog(logID int, clientID string, agentID int, emailSubject, emailStatus string) error {
+func (r *CommunicationLogRepository) InsertCommunicationLog(logID int, clientID string, agentID int, emailSubject, emailStatus, requestID string) error {
+	defer metrics.ObserveQuery("CommunicationLogRepository", "InsertCommunicationLog", time.Now())
 	query := `
 		INSERT INTO communication_logs 
-		(log_id, client_id, agent_id, email_subject, email_status) 
-		VALUES (?, ?, ?, ?, ?)`
-	_, err := database.DB.Exec(query, logID, clientID, agentID, emailSubject, emailStatus)
+		(log_id, client_id, agent_id, email_subject, email_status, request_id) 
+		VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(query, logID, clientID, agentID, emailSubject, emailStatus, requestID)
 	if err != nil {
 		return fmt.Errorf("failed to insert communication log: %v", err)
 	}
@@ -52,11 +34,12 @@ func (r *CommunicationLogRepository) InsertCommunicationLog(logID int, clientID
 
 // GetCommunicationLogByLogID retrieves a specific communication log by log ID
 func (r *CommunicationLogRepository) GetCommunicationLogByLogID(logID int) (models.CommunicationLog, error) {
-	query := "SELECT id, log_id, client_id, agent_id, email_subject, email_status, timestamp FROM communication_logs WHERE log_id = ?"
-	row := database.DB.QueryRow(query, logID) // Use QueryRow for single result
+	defer metrics.ObserveQuery("CommunicationLogRepository", "GetCommunicationLogByLogID", time.Now())
+	query := "SELECT id, log_id, client_id, agent_id, email_subject, email_status, timestamp, request_id FROM communication_logs WHERE log_id = ?"
+	row := r.db.QueryRow(query, logID) // Use QueryRow for single result
 
 	var log models.CommunicationLog
-	if err := row.Scan(&log.ID, &log.LogID, &log.ClientID, &log.AgentID, &log.EmailSubject, &log.EmailStatus, &log.Timestamp); err != nil {
+	if err := row.Scan(&log.ID, &log.LogID, &log.ClientID, &log.AgentID, &log.EmailSubject, &log.EmailStatus, &log.Timestamp, &log.RequestID); err != nil {
 		if err == sql.ErrNoRows {
 			return log, fmt.Errorf("no communication log found with log_id %d", logID)
 		}
@@ -2,20 +2,28 @@ package communicationlogs
 
 import (
 	"backend/models"
+	"backend/services/interfaces"
+	"backend/services/logging"
+	"backend/services/metrics"
+	"context"
 	"fmt"
+	"log/slog"
 )
 
 // CommunicationLogService handles log operations
 type CommunicationLogService struct {
-	repo *CommunicationLogRepository
+	repo   interfaces.CommunicationLogRepositoryInterface
+	logger *slog.Logger
 }
 
 // NewCommunicationLogService initializes the service
-func NewCommunicationLogService(repo *CommunicationLogRepository) *CommunicationLogService {
-	return &CommunicationLogService{repo: repo}
+func NewCommunicationLogService(repo interfaces.CommunicationLogRepositoryInterface, logger *slog.Logger) *CommunicationLogService {
+	return &CommunicationLogService{repo: repo, logger: logger}
 }
 
-func (s *CommunicationLogService) LogCommunication(agentClientLog models.AgentClientLog) error {
+// LogCommunication emails the client about a logged change and records the email under the
+// change's request ID
+func (s *CommunicationLogService) LogCommunication(ctx context.Context, agentClientLog models.AgentClientLog) error {
 	// Extract values from the AgentClientLog
 	clientID := agentClientLog.ClientID
 	agentID := agentClientLog.AgentID
@@ -47,7 +55,7 @@ func (s *CommunicationLogService) LogCommunication(agentClientLog models.AgentCl
 	*/
 
 	// For testing, just log the details
-	fmt.Printf("Test log: %s - %s\n", emailSubject, clientID)
+	s.logger.InfoContext(ctx, "communication email", "subject", emailSubject, "client_id", clientID, "log_id", agentClientLog.ID)
 
 	// Create CommunicationLog object with logID
 	logID := agentClientLog.ID
@@ -69,9 +77,15 @@ func (s *CommunicationLogService) LogCommunication(agentClientLog models.AgentCl
 
 	// Just simulate success here for testing
 	emailStatus = "Sent"
+	metrics.ObserveEmail(emailStatus)
 
 	// Insert into CommunicationLog repository
-	return s.repo.InsertCommunicationLog(logID, clientID, agentID, emailSubject, emailStatus)
+	// The email belongs to the change that was logged; fall back to the current request
+	requestID := agentClientLog.RequestID
+	if requestID == "" {
+		requestID = logging.RequestID(ctx)
+	}
+	return s.repo.InsertCommunicationLog(logID, clientID, agentID, emailSubject, emailStatus, requestID)
 }
 
 // GetClientCommunicationLogsByLogID to get communication by logID
@@ -3,30 +3,36 @@ package communication_observer
 import (
 	"backend/models"
 	communicationlogs "backend/services/communication_logs"
+	"backend/services/events"
+	"context"
 	"fmt"
+	"log/slog"
 )
 
-type CommunicationObserver struct {
+// Subscriber is the outbox subscriber name of the CommunicationSubscriber
+const Subscriber = "communication"
+
+// CommunicationSubscriber emails the client whenever a change to their profile is logged
+type CommunicationSubscriber struct {
 	LogService *communicationlogs.CommunicationLogService
+	Logger     *slog.Logger
+}
+
+// Register subscribes to ClientChangeLogged
+func (cs *CommunicationSubscriber) Register(dispatcher *events.Dispatcher) {
+	dispatcher.Subscribe(Subscriber, cs.Handle, events.TypeClientChangeLogged)
 }
 
-func (co *CommunicationObserver) NotifyCreate(agentID int, clientID string, object interface{}) {
-	log, ok := object.(models.AgentClientLog) // ✅ safe type assertion
+// Handle sends and records the communication for one logged change
+func (cs *CommunicationSubscriber) Handle(ctx context.Context, event events.Event, meta models.EventMeta) error {
+	logged, ok := event.(events.ClientChangeLogged) // ✅ safe type assertion
 	if !ok {
-		fmt.Println("❌ CommunicationObserver: expected AgentClientLog, got something else")
-		return
+		return fmt.Errorf("communication subscriber: expected ClientChangeLogged, got %s", event.Type())
 	}
 
-	err := co.LogService.LogCommunication(log)
+	err := cs.LogService.LogCommunication(ctx, logged.Log)
 	if err != nil {
-		fmt.Println("❌ Failed to log communication:", err)
+		cs.Logger.ErrorContext(ctx, "failed to log communication", "log_id", logged.Log.ID, "error", err)
 	}
-}
-
-func (co *CommunicationObserver) NotifyUpdate(agentID int, clientID string, before, after interface{}) {
-	// Leave blank or implement if needed
-}
-
-func (co *CommunicationObserver) NotifyDelete(agentID int, clientID string, object interface{}) {
-	// Leave blank or implement if needed
+	return err
 }
@@ -0,0 +1,69 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// GetDeadLettersHandler restricts to Admin. ?limit= caps the result (default 100, max 500).
+func GetDeadLettersHandler(dispatcher *Dispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userCtx := r.Context().Value("user").(map[string]interface{})
+		if userCtx["role"].(string) != "Admin" {
+			http.Error(w, "Unauthorized: only Admin can view dead-lettered events", http.StatusForbidden)
+			return
+		}
+
+		limit := 100
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > 500 {
+				http.Error(w, "limit must be between 1 and 500", http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+
+		deliveries, err := dispatcher.DeadLetters(limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(deliveries)
+	}
+}
+
+// RetryDeadLetterHandler restricts to Admin
+func RetryDeadLetterHandler(dispatcher *Dispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userCtx := r.Context().Value("user").(map[string]interface{})
+		if userCtx["role"].(string) != "Admin" {
+			http.Error(w, "Unauthorized: only Admin can retry events", http.StatusForbidden)
+			return
+		}
+
+		deliveryID, err := strconv.ParseInt(mux.Vars(r)["delivery_id"], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid delivery ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := dispatcher.Retry(deliveryID); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, ErrNotDeadLettered) {
+				status = http.StatusNotFound
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("Delivery queued for retry"))
+	}
+}
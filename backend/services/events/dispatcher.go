@@ -0,0 +1,334 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"backend/models"
+	"backend/services/interfaces"
+	"backend/services/logging"
+	"backend/services/metrics"
+)
+
+// ErrNotDeadLettered is returned when retrying a delivery that is missing or not dead-lettered
+var ErrNotDeadLettered = errors.New("delivery does not exist or is not dead-lettered")
+
+// Handler receives one event. Returning an error schedules a retry; handlers may therefore
+// see the same event more than once and must tolerate it.
+type Handler func(ctx context.Context, event Event, meta models.EventMeta) error
+
+// DispatcherConfig controls polling and retries
+type DispatcherConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	// Lease is how long a claimed delivery stays hidden from other dispatchers
+	Lease time.Duration
+}
+
+// ConfigFromEnv reads OUTBOX_POLL_INTERVAL (default 1s) and OUTBOX_MAX_ATTEMPTS (default 8).
+// Retries back off exponentially from 1s up to 10m.
+func ConfigFromEnv() (DispatcherConfig, error) {
+	cfg := DispatcherConfig{
+		PollInterval: time.Second,
+		BatchSize:    50,
+		MaxAttempts:  8,
+		BaseBackoff:  time.Second,
+		MaxBackoff:   10 * time.Minute,
+		Lease:        time.Minute,
+	}
+
+	if raw := os.Getenv("OUTBOX_POLL_INTERVAL"); raw != "" {
+		interval, err := time.ParseDuration(raw)
+		if err != nil || interval <= 0 {
+			return DispatcherConfig{}, fmt.Errorf("OUTBOX_POLL_INTERVAL must be a positive duration such as 500ms, got %q", raw)
+		}
+		cfg.PollInterval = interval
+	}
+
+	if raw := os.Getenv("OUTBOX_MAX_ATTEMPTS"); raw != "" {
+		attempts, err := strconv.Atoi(raw)
+		if err != nil || attempts < 1 {
+			return DispatcherConfig{}, fmt.Errorf("OUTBOX_MAX_ATTEMPTS must be a positive integer, got %q", raw)
+		}
+		cfg.MaxAttempts = attempts
+	}
+
+	return cfg, nil
+}
+
+// Dispatcher delivers outbox events to subscribers. Each subscriber gets its own delivery per
+// event, so one failing subscriber is retried without repeating the others. Subscribers share
+// one worker unless subscribed with SubscribeIsolated.
+type Dispatcher struct {
+	repo   interfaces.OutboxRepositoryInterface
+	config DispatcherConfig
+	logger *slog.Logger
+
+	mu       sync.RWMutex
+	handlers map[string]map[string]Handler // event type -> subscriber -> handler
+	isolated []string                      // subscribers with a worker of their own
+
+	running sync.WaitGroup
+}
+
+// NewDispatcher initializes a Dispatcher
+func NewDispatcher(repo interfaces.OutboxRepositoryInterface, config DispatcherConfig, logger *slog.Logger) *Dispatcher {
+	return &Dispatcher{
+		repo:     repo,
+		config:   config,
+		logger:   logger,
+		handlers: make(map[string]map[string]Handler),
+	}
+}
+
+// Subscribe registers handler under a subscriber name for the given event types. The name is
+// stored with every delivery, so it must stay the same across releases.
+func (d *Dispatcher) Subscribe(subscriber string, handler Handler, eventTypes ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, eventType := range eventTypes {
+		if d.handlers[eventType] == nil {
+			d.handlers[eventType] = make(map[string]Handler)
+		}
+		d.handlers[eventType][subscriber] = handler
+	}
+}
+
+// SubscribeIsolated registers handler like Subscribe, but the subscriber's deliveries are run
+// by a worker of its own, for handlers that call out over the network: a slow one then holds
+// up neither the other subscribers nor shutdown. A batch of its deliveries runs concurrently
+// and is cancelled after half the lease, before the claims could be taken by another
+// dispatcher; cancelled deliveries are retried.
+func (d *Dispatcher) SubscribeIsolated(subscriber string, handler Handler, eventTypes ...string) {
+	d.Subscribe(subscriber, handler, eventTypes...)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !slices.Contains(d.isolated, subscriber) {
+		d.isolated = append(d.isolated, subscriber)
+	}
+}
+
+func (d *Dispatcher) isolatedSubscribers() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return slices.Clone(d.isolated)
+}
+
+func (d *Dispatcher) subscribersOf(eventType string) []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	subscribers := make([]string, 0, len(d.handlers[eventType]))
+	for subscriber := range d.handlers[eventType] {
+		subscribers = append(subscribers, subscriber)
+	}
+	sort.Strings(subscribers)
+	return subscribers
+}
+
+func (d *Dispatcher) handler(eventType, subscriber string) Handler {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.handlers[eventType][subscriber]
+}
+
+// Start polls the outbox in the background until ctx is cancelled. The shared worker finishes
+// the batch in progress first, while isolated subscribers' deliveries in flight are cancelled
+// and retried on the next start; use Wait to block until every worker has stopped.
+func (d *Dispatcher) Start(ctx context.Context) {
+	d.poll(ctx, func() (int, error) { return d.dispatchShared(context.WithoutCancel(ctx)) })
+	for _, subscriber := range d.isolatedSubscribers() {
+		d.poll(ctx, func() (int, error) { return d.dispatchIsolated(ctx, subscriber) })
+	}
+}
+
+// poll runs one worker in the background, calling dispatch until ctx is cancelled
+func (d *Dispatcher) poll(ctx context.Context, dispatch func() (int, error)) {
+	d.running.Add(1)
+	go func() {
+		defer d.running.Done()
+
+		ticker := time.NewTicker(d.config.PollInterval)
+		defer ticker.Stop()
+		for {
+			claimed, err := dispatch()
+			if err != nil {
+				d.logger.Error("outbox dispatch failed", "error", err)
+			}
+			// A full batch means more is probably waiting
+			if err == nil && claimed == d.config.BatchSize && ctx.Err() == nil {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Wait blocks until the dispatcher has stopped, or ctx expires
+func (d *Dispatcher) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		d.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("event deliveries still in flight: %v", ctx.Err())
+	}
+}
+
+// DispatchOnce runs one round of every worker in turn: it fans out new events and attempts a
+// batch of due deliveries of the shared subscribers, then one of each isolated subscriber. It
+// reports how many deliveries it attempted.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	attempted, err := d.dispatchShared(ctx)
+	if err != nil {
+		return attempted, err
+	}
+	for _, subscriber := range d.isolatedSubscribers() {
+		claimed, err := d.dispatchIsolated(ctx, subscriber)
+		attempted += claimed
+		if err != nil {
+			return attempted, err
+		}
+	}
+	return attempted, nil
+}
+
+// dispatchShared fans out new events and delivers one batch of the shared subscribers in turn
+func (d *Dispatcher) dispatchShared(ctx context.Context) (int, error) {
+	if _, err := d.repo.FanOut(d.subscribersOf, d.config.BatchSize); err != nil {
+		return 0, err
+	}
+
+	deliveries, err := d.repo.ClaimDue(d.isolatedSubscribers(), true, d.config.BatchSize, d.config.Lease)
+	if err != nil {
+		return 0, err
+	}
+	for _, delivery := range deliveries {
+		d.deliver(ctx, delivery)
+	}
+	return len(deliveries), nil
+}
+
+// dispatchIsolated delivers one batch of an isolated subscriber concurrently, within half the
+// lease
+func (d *Dispatcher) dispatchIsolated(ctx context.Context, subscriber string) (int, error) {
+	if ctx.Err() != nil {
+		return 0, nil
+	}
+	deliveries, err := d.repo.ClaimDue([]string{subscriber}, false, d.config.BatchSize, d.config.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.config.Lease/2)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.deliver(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+	return len(deliveries), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery models.OutboxDelivery) {
+	meta := models.EventMeta{
+		AgentID:    delivery.Event.AgentID,
+		RequestID:  delivery.Event.RequestID,
+		EventID:    delivery.Event.ID,
+		OccurredAt: delivery.Event.CreatedAt,
+	}
+	ctx = logging.WithRequestID(ctx, meta.RequestID)
+
+	event, err := Decode(delivery.Event)
+	if err == nil {
+		if handler := d.handler(delivery.Event.Type, delivery.Subscriber); handler != nil {
+			err = call(ctx, handler, event, meta)
+		} else {
+			err = fmt.Errorf("no handler registered for subscriber %q", delivery.Subscriber)
+		}
+	}
+	metrics.ObserveDelivery(delivery.Subscriber, delivery.Event.Type, err)
+
+	if err == nil {
+		if err := d.repo.MarkDelivered(delivery.ID); err != nil {
+			d.logger.ErrorContext(ctx, "failed to record delivery", "delivery_id", delivery.ID, "error", err)
+		}
+		return
+	}
+
+	dead := delivery.Attempts >= d.config.MaxAttempts
+	if markErr := d.repo.MarkFailed(delivery.ID, err.Error(), d.backoff(delivery.Attempts), dead); markErr != nil {
+		d.logger.ErrorContext(ctx, "failed to record delivery failure", "delivery_id", delivery.ID, "error", markErr)
+	}
+
+	attrs := []any{
+		"delivery_id", delivery.ID,
+		"event_id", delivery.Event.ID,
+		"event_type", delivery.Event.Type,
+		"subscriber", delivery.Subscriber,
+		"attempt", delivery.Attempts,
+		"error", err,
+	}
+	if dead {
+		metrics.ObserveDeadLetter(delivery.Subscriber, delivery.Event.Type)
+		d.logger.ErrorContext(ctx, "event delivery dead-lettered", attrs...)
+	} else {
+		d.logger.WarnContext(ctx, "event delivery failed, will retry", attrs...)
+	}
+}
+
+// call runs a handler, turning a panic into an error so one bad event can't stop dispatching
+func call(ctx context.Context, handler Handler, event Event, meta models.EventMeta) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return handler(ctx, event, meta)
+}
+
+// backoff doubles the delay after every attempt, up to MaxBackoff
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.config.BaseBackoff
+	for i := 1; i < attempt && delay < d.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.config.MaxBackoff)
+}
+
+// DeadLetters lists the most recent dead-lettered deliveries
+func (d *Dispatcher) DeadLetters(limit int) ([]models.OutboxDelivery, error) {
+	return d.repo.ListDeliveries("dead", limit)
+}
+
+// Retry puts a dead-lettered delivery back in the queue with a fresh set of attempts
+func (d *Dispatcher) Retry(deliveryID int64) error {
+	return d.repo.Requeue(deliveryID)
+}
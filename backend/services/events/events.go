@@ -0,0 +1,140 @@
+package events
+
+import (
+	"backend/models"
+	"backend/services/logging"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Event is a typed domain event. It is stored in the outbox as JSON under its Type and
+// decoded back into the same type before it reaches subscribers.
+type Event interface {
+	Type() string
+	// ClientID is the client the event concerns
+	ClientID() string
+}
+
+// Event types
+const (
+	TypeClientCreated      = "ClientCreated"
+	TypeClientUpdated      = "ClientUpdated"
+	TypeClientDeleted      = "ClientDeleted"
+	TypeAccountCreated     = "AccountCreated"
+	TypeAccountUpdated     = "AccountUpdated"
+	TypeAccountDeleted     = "AccountDeleted"
+	TypeTransferCompleted  = "TransferCompleted"
+	TypeClientChangeLogged = "ClientChangeLogged"
+)
+
+// ClientCreated is raised when a client profile is created
+type ClientCreated struct {
+	Client models.Client `json:"client"`
+}
+
+// ClientUpdated is raised when a client profile changes. RevertOf is set when the change
+// reverts an earlier one, to the ID of that change's log entry.
+type ClientUpdated struct {
+	Before   models.Client `json:"before"`
+	After    models.Client `json:"after"`
+	RevertOf int           `json:"revert_of,omitempty"`
+}
+
+// ClientDeleted is raised when a client profile is deleted
+type ClientDeleted struct {
+	Client models.Client `json:"client"`
+}
+
+// AccountCreated is raised when an account is opened
+type AccountCreated struct {
+	Account models.Account `json:"account"`
+}
+
+// AccountUpdated is raised when an account's details or status change. RevertOf is set as
+// for ClientUpdated.
+type AccountUpdated struct {
+	Before   models.Account `json:"before"`
+	After    models.Account `json:"after"`
+	RevertOf int            `json:"revert_of,omitempty"`
+}
+
+// AccountDeleted is raised when an account is deleted
+type AccountDeleted struct {
+	Account models.Account `json:"account"`
+}
+
+// TransferCompleted is raised when a transfer between two accounts is posted
+type TransferCompleted struct {
+	Transfer models.Transfer `json:"transfer"`
+}
+
+// ClientChangeLogged is raised when a client change is written to the agent-client log
+type ClientChangeLogged struct {
+	Log models.AgentClientLog `json:"log"`
+}
+
+func (ClientCreated) Type() string      { return TypeClientCreated }
+func (ClientUpdated) Type() string      { return TypeClientUpdated }
+func (ClientDeleted) Type() string      { return TypeClientDeleted }
+func (AccountCreated) Type() string     { return TypeAccountCreated }
+func (AccountUpdated) Type() string     { return TypeAccountUpdated }
+func (AccountDeleted) Type() string     { return TypeAccountDeleted }
+func (TransferCompleted) Type() string  { return TypeTransferCompleted }
+func (ClientChangeLogged) Type() string { return TypeClientChangeLogged }
+
+func (e ClientCreated) ClientID() string      { return e.Client.ClientID }
+func (e ClientUpdated) ClientID() string      { return e.After.ClientID }
+func (e ClientDeleted) ClientID() string      { return e.Client.ClientID }
+func (e AccountCreated) ClientID() string     { return e.Account.ClientID }
+func (e AccountUpdated) ClientID() string     { return e.After.ClientID }
+func (e AccountDeleted) ClientID() string     { return e.Account.ClientID }
+func (e TransferCompleted) ClientID() string  { return e.Transfer.FromClientID }
+func (e ClientChangeLogged) ClientID() string { return e.Log.ClientID }
+
+// decoders decodes the payload of each event type
+var decoders = map[string]func(payload json.RawMessage) (Event, error){
+	TypeClientCreated:      decodeAs[ClientCreated],
+	TypeClientUpdated:      decodeAs[ClientUpdated],
+	TypeClientDeleted:      decodeAs[ClientDeleted],
+	TypeAccountCreated:     decodeAs[AccountCreated],
+	TypeAccountUpdated:     decodeAs[AccountUpdated],
+	TypeAccountDeleted:     decodeAs[AccountDeleted],
+	TypeTransferCompleted:  decodeAs[TransferCompleted],
+	TypeClientChangeLogged: decodeAs[ClientChangeLogged],
+}
+
+func decodeAs[T Event](payload json.RawMessage) (Event, error) {
+	var event T
+	err := json.Unmarshal(payload, &event)
+	return event, err
+}
+
+// Decode turns a stored outbox event back into its typed event
+func Decode(stored models.OutboxEvent) (Event, error) {
+	decode, ok := decoders[stored.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown event type %q", stored.Type)
+	}
+
+	event, err := decode(stored.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s event %d: %v", stored.Type, stored.ID, err)
+	}
+	return event, nil
+}
+
+// NewMeta records the agent behind a change, the request ID carried by ctx and, if ctx was
+// marked with WithRevertOf, the change it reverts
+func NewMeta(ctx context.Context, agentID int) models.EventMeta {
+	meta := models.EventMeta{AgentID: agentID, RequestID: logging.RequestID(ctx)}
+	meta.RevertOf, _ = ctx.Value(revertOfKey{}).(int)
+	return meta
+}
+
+type revertOfKey struct{}
+
+// WithRevertOf marks changes made under ctx as reverting the change logged as logID
+func WithRevertOf(ctx context.Context, logID int) context.Context {
+	return context.WithValue(ctx, revertOfKey{}, logID)
+}
@@ -0,0 +1,185 @@
+package events
+
+import (
+	"backend/database/memstore"
+	"backend/models"
+	"backend/services/interfaces"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+var _ interfaces.OutboxRepositoryInterface = (*MemoryOutboxRepository)(nil)
+
+// MemoryOutboxRepository is an in-memory implementation of interfaces.OutboxRepositoryInterface
+type MemoryOutboxRepository struct {
+	store *memstore.Store
+	now   func() time.Time
+}
+
+// NewMemoryOutboxRepository initializes a new MemoryOutboxRepository on the given store
+func NewMemoryOutboxRepository(store *memstore.Store) *MemoryOutboxRepository {
+	return &MemoryOutboxRepository{store: store, now: time.Now}
+}
+
+// AppendLocked is the in-memory AppendTx. The caller must hold store.Mu, so the events are
+// recorded under the same lock as the change they describe.
+func AppendLocked(store *memstore.Store, meta models.EventMeta, evs ...Event) error {
+	for _, event := range evs {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s event: %v", event.Type(), err)
+		}
+
+		store.OutboxEvents = append(store.OutboxEvents, models.OutboxEvent{
+			ID:        store.NextOutboxEventID,
+			Type:      event.Type(),
+			ClientID:  event.ClientID(),
+			AgentID:   meta.AgentID,
+			RequestID: meta.RequestID,
+			Payload:   payload,
+			CreatedAt: time.Now().UTC().Format("2006-01-02 15:04:05"),
+		})
+		store.NextOutboxEventID++
+	}
+	return nil
+}
+
+// FanOut takes events in append order
+func (r *MemoryOutboxRepository) FanOut(subscribersOf func(eventType string) []string, limit int) (int, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	now := r.now()
+	taken := 0
+	for r.store.OutboxFannedOut < len(r.store.OutboxEvents) && taken < limit {
+		event := r.store.OutboxEvents[r.store.OutboxFannedOut]
+		for _, subscriber := range subscribersOf(event.Type) {
+			r.store.OutboxDeliveries = append(r.store.OutboxDeliveries, models.OutboxDelivery{
+				ID:          r.store.NextOutboxDeliveryID,
+				Subscriber:  subscriber,
+				Status:      "pending",
+				AvailableAt: now.Format(time.RFC3339Nano),
+				Event:       event,
+			})
+			r.store.NextOutboxDeliveryID++
+		}
+		r.store.OutboxFannedOut++
+		taken++
+	}
+	return taken, nil
+}
+
+// ClaimDue takes due pending deliveries in ID order
+func (r *MemoryOutboxRepository) ClaimDue(subscribers []string, except bool, limit int, lease time.Duration) ([]models.OutboxDelivery, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	now := r.now()
+	var claimed []models.OutboxDelivery
+	for i := range r.store.OutboxDeliveries {
+		if len(claimed) == limit {
+			break
+		}
+		d := &r.store.OutboxDeliveries[i]
+		if d.Status != "pending" || r.availableAt(*d).After(now) || slices.Contains(subscribers, d.Subscriber) == except {
+			continue
+		}
+		d.Attempts++
+		d.AvailableAt = now.Add(lease).Format(time.RFC3339Nano)
+		claimed = append(claimed, *d)
+	}
+	return claimed, nil
+}
+
+// MarkDelivered closes a delivery
+func (r *MemoryOutboxRepository) MarkDelivered(deliveryID int64) error {
+	return r.update(deliveryID, func(d *models.OutboxDelivery) error {
+		d.Status = "delivered"
+		d.LastError = ""
+		return nil
+	})
+}
+
+// MarkFailed records a failed attempt
+func (r *MemoryOutboxRepository) MarkFailed(deliveryID int64, lastError string, retryIn time.Duration, dead bool) error {
+	return r.update(deliveryID, func(d *models.OutboxDelivery) error {
+		d.Status = "pending"
+		if dead {
+			d.Status = "dead"
+		}
+		d.LastError = lastError
+		d.AvailableAt = r.now().Add(retryIn).Format(time.RFC3339Nano)
+		return nil
+	})
+}
+
+// ListDeliveries returns the most recent deliveries with the given status
+func (r *MemoryOutboxRepository) ListDeliveries(status string, limit int) ([]models.OutboxDelivery, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	var deliveries []models.OutboxDelivery
+	for _, d := range r.store.OutboxDeliveries {
+		if d.Status == status {
+			deliveries = append(deliveries, d)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID > deliveries[j].ID })
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// Requeue only touches dead-lettered deliveries
+func (r *MemoryOutboxRepository) Requeue(deliveryID int64) error {
+	err := r.update(deliveryID, func(d *models.OutboxDelivery) error {
+		if d.Status != "dead" {
+			return ErrNotDeadLettered
+		}
+		d.Status = "pending"
+		d.Attempts = 0
+		d.AvailableAt = r.now().Format(time.RFC3339Nano)
+		return nil
+	})
+	if err != nil && err != ErrNotDeadLettered {
+		return ErrNotDeadLettered
+	}
+	return err
+}
+
+// GetEvent retrieves a stored event by ID
+func (r *MemoryOutboxRepository) GetEvent(eventID int64) (models.OutboxEvent, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	for _, event := range r.store.OutboxEvents {
+		if event.ID == eventID {
+			return event, nil
+		}
+	}
+	return models.OutboxEvent{}, fmt.Errorf("event with ID %d does not exist", eventID)
+}
+
+func (r *MemoryOutboxRepository) update(deliveryID int64, apply func(d *models.OutboxDelivery) error) error {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	for i := range r.store.OutboxDeliveries {
+		if r.store.OutboxDeliveries[i].ID == deliveryID {
+			return apply(&r.store.OutboxDeliveries[i])
+		}
+	}
+	return fmt.Errorf("delivery %d not found", deliveryID)
+}
+
+func (r *MemoryOutboxRepository) availableAt(d models.OutboxDelivery) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, d.AvailableAt)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
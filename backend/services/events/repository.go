@@ -0,0 +1,259 @@
+package events
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/models"
+	"backend/services/interfaces"
+	"backend/services/metrics"
+)
+
+var _ interfaces.OutboxRepositoryInterface = (*OutboxRepository)(nil)
+
+// OutboxRepository is the MySQL implementation of interfaces.OutboxRepositoryInterface
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+// NewOutboxRepository initializes a new OutboxRepository
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// AppendTx writes events to the outbox inside the caller's transaction, so they are only
+// delivered if the change they describe commits
+func AppendTx(tx *sql.Tx, meta models.EventMeta, evs ...Event) error {
+	for _, event := range evs {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s event: %v", event.Type(), err)
+		}
+
+		_, err = tx.Exec(
+			`INSERT INTO outbox_events (event_type, client_id, agent_id, request_id, payload) VALUES (?, ?, ?, ?, ?)`,
+			event.Type(), event.ClientID(), meta.AgentID, meta.RequestID, payload,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to append %s event: %v", event.Type(), err)
+		}
+	}
+	return nil
+}
+
+const deliveryColumns = `SELECT d.id, d.subscriber, d.status, d.attempts, COALESCE(d.last_error, ''), d.available_at,
+		e.id, e.event_type, e.client_id, e.agent_id, e.request_id, e.payload, e.created_at
+	FROM outbox_deliveries d
+	JOIN outbox_events e ON e.id = d.event_id`
+
+// FanOut locks a batch of undispatched events, skipping those another dispatcher holds
+func (r *OutboxRepository) FanOut(subscribersOf func(eventType string) []string, limit int) (int, error) {
+	defer metrics.ObserveQuery("OutboxRepository", "FanOut", time.Now())
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(
+		`SELECT id, event_type FROM outbox_events WHERE dispatched_at IS NULL ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED`,
+		limit,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read outbox: %v", err)
+	}
+
+	var pending []models.OutboxEvent
+	for rows.Next() {
+		var event models.OutboxEvent
+		if err := rows.Scan(&event.ID, &event.Type); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan outbox event: %v", err)
+		}
+		pending = append(pending, event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read outbox: %v", err)
+	}
+
+	for _, event := range pending {
+		for _, subscriber := range subscribersOf(event.Type) {
+			if _, err := tx.Exec(
+				`INSERT IGNORE INTO outbox_deliveries (event_id, subscriber) VALUES (?, ?)`,
+				event.ID, subscriber,
+			); err != nil {
+				return 0, fmt.Errorf("failed to create delivery: %v", err)
+			}
+		}
+		if _, err := tx.Exec(`UPDATE outbox_events SET dispatched_at = NOW() WHERE id = ?`, event.ID); err != nil {
+			return 0, fmt.Errorf("failed to mark event dispatched: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit fan-out: %v", err)
+	}
+	return len(pending), nil
+}
+
+// ClaimDue pushes available_at past the lease, so a crashed dispatcher's claims come due again
+func (r *OutboxRepository) ClaimDue(subscribers []string, except bool, limit int, lease time.Duration) ([]models.OutboxDelivery, error) {
+	defer metrics.ObserveQuery("OutboxRepository", "ClaimDue", time.Now())
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := deliveryColumns + ` WHERE d.status = 'pending' AND d.available_at <= NOW()`
+	args := make([]interface{}, 0, len(subscribers)+1)
+	if len(subscribers) > 0 {
+		in := ` IN (?` + strings.Repeat(", ?", len(subscribers)-1) + `)`
+		if except {
+			in = ` NOT` + in
+		}
+		query += ` AND d.subscriber` + in
+		for _, subscriber := range subscribers {
+			args = append(args, subscriber)
+		}
+	} else if !except {
+		return nil, nil
+	}
+	args = append(args, limit)
+
+	rows, err := tx.Query(query+` ORDER BY d.id LIMIT ? FOR UPDATE OF d SKIP LOCKED`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim deliveries: %v", err)
+	}
+	deliveries, err := scanDeliveries(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]interface{}, 0, len(deliveries)+1)
+	ids = append(ids, int(lease.Seconds()))
+	for i := range deliveries {
+		deliveries[i].Attempts++
+		ids = append(ids, deliveries[i].ID)
+	}
+	_, err = tx.Exec(
+		`UPDATE outbox_deliveries SET attempts = attempts + 1, available_at = DATE_ADD(NOW(), INTERVAL ? SECOND)
+		WHERE id IN (?`+strings.Repeat(", ?", len(deliveries)-1)+`)`,
+		ids...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim deliveries: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit claim: %v", err)
+	}
+	return deliveries, nil
+}
+
+// MarkDelivered closes a delivery
+func (r *OutboxRepository) MarkDelivered(deliveryID int64) error {
+	defer metrics.ObserveQuery("OutboxRepository", "MarkDelivered", time.Now())
+	_, err := r.db.Exec(
+		`UPDATE outbox_deliveries SET status = 'delivered', delivered_at = NOW(), last_error = NULL WHERE id = ?`,
+		deliveryID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark delivery %d delivered: %v", deliveryID, err)
+	}
+	return nil
+}
+
+// MarkFailed records a failed attempt
+func (r *OutboxRepository) MarkFailed(deliveryID int64, lastError string, retryIn time.Duration, dead bool) error {
+	defer metrics.ObserveQuery("OutboxRepository", "MarkFailed", time.Now())
+	status := "pending"
+	if dead {
+		status = "dead"
+	}
+
+	_, err := r.db.Exec(
+		`UPDATE outbox_deliveries SET status = ?, last_error = ?, available_at = DATE_ADD(NOW(), INTERVAL ? SECOND)
+		WHERE id = ?`,
+		status, lastError, int(retryIn.Seconds()), deliveryID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark delivery %d failed: %v", deliveryID, err)
+	}
+	return nil
+}
+
+// ListDeliveries returns the most recent deliveries with the given status
+func (r *OutboxRepository) ListDeliveries(status string, limit int) ([]models.OutboxDelivery, error) {
+	defer metrics.ObserveQuery("OutboxRepository", "ListDeliveries", time.Now())
+	rows, err := r.db.Query(deliveryColumns+` WHERE d.status = ? ORDER BY d.id DESC LIMIT ?`, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deliveries: %v", err)
+	}
+	return scanDeliveries(rows)
+}
+
+// Requeue only touches dead-lettered deliveries
+func (r *OutboxRepository) Requeue(deliveryID int64) error {
+	defer metrics.ObserveQuery("OutboxRepository", "Requeue", time.Now())
+	result, err := r.db.Exec(
+		`UPDATE outbox_deliveries SET status = 'pending', attempts = 0, available_at = NOW()
+		WHERE id = ? AND status = 'dead'`,
+		deliveryID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to requeue delivery %d: %v", deliveryID, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotDeadLettered
+	}
+	return nil
+}
+
+// GetEvent retrieves a stored event by ID
+func (r *OutboxRepository) GetEvent(eventID int64) (models.OutboxEvent, error) {
+	defer metrics.ObserveQuery("OutboxRepository", "GetEvent", time.Now())
+	var event models.OutboxEvent
+	var payload []byte
+	err := r.db.QueryRow(
+		`SELECT id, event_type, client_id, agent_id, request_id, payload, created_at FROM outbox_events WHERE id = ?`,
+		eventID,
+	).Scan(&event.ID, &event.Type, &event.ClientID, &event.AgentID, &event.RequestID, &payload, &event.CreatedAt)
+	if err == sql.ErrNoRows {
+		return models.OutboxEvent{}, fmt.Errorf("event with ID %d does not exist", eventID)
+	}
+	if err != nil {
+		return models.OutboxEvent{}, fmt.Errorf("failed to retrieve event: %v", err)
+	}
+	event.Payload = json.RawMessage(payload)
+	return event, nil
+}
+
+func scanDeliveries(rows *sql.Rows) ([]models.OutboxDelivery, error) {
+	defer rows.Close()
+
+	var deliveries []models.OutboxDelivery
+	for rows.Next() {
+		var d models.OutboxDelivery
+		var payload []byte
+		if err := rows.Scan(
+			&d.ID, &d.Subscriber, &d.Status, &d.Attempts, &d.LastError, &d.AvailableAt,
+			&d.Event.ID, &d.Event.Type, &d.Event.ClientID, &d.Event.AgentID, &d.Event.RequestID, &payload, &d.Event.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan delivery: %v", err)
+		}
+		d.Event.Payload = json.RawMessage(payload)
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read deliveries: %v", err)
+	}
+	return deliveries, nil
+}
@@ -1,10 +1,32 @@
 // interfaces/account_interface.go
 package interfaces
 
-import "backend/models"
+import (
+	"backend/models"
+	"backend/services/listquery"
+	"context"
+)
 
 // AccountServiceInterface defines the methods that an AccountService must implement
 type AccountServiceInterface interface {
 	GetAccountByClientId(clientID string) ([]models.Account, error)
+	GetAccount(accountID int) (models.Account, error)
+	UpdateAccount(ctx context.Context, accountID int, update models.Account) (models.Account, error)
+	ChangeAccountStatus(ctx context.Context, accountID int, status string, reason string) (models.Account, error)
+	RevertAccount(ctx context.Context, accountID int, expected, restore map[string]string) (models.Account, []models.RevertConflict, error)
 	// Add other methods as needed
-}
\ No newline at end of file
+}
+// AccountRepositoryInterface defines the storage operations the AccountService depends on
+type AccountRepositoryInterface interface {
+	// CreateAccount, UpdateAccount and DeleteAccount record their domain event in the same
+	// transaction as the change
+	CreateAccount(account models.Account, meta models.EventMeta) (models.Account, error)
+	UpdateAccount(account models.Account, meta models.EventMeta) (models.Account, error)
+	// RevertAccount writes the fields in restore only if every field in expected still holds
+	// its value, checking and writing in one transaction; otherwise it returns the conflicts
+	RevertAccount(accountID int, expected, restore map[string]string, meta models.EventMeta) (models.Account, []models.RevertConflict, error)
+	DeleteAccount(accountID int, meta models.EventMeta) error
+	GetAccountByID(accountID int) (models.Account, error)
+	GetAccountByClientId(clientID string) ([]models.Account, error)
+	ListAccounts(q listquery.Query) ([]models.Account, error)
+}
@@ -5,4 +5,17 @@ import "backend/models"
 // AgentClientServiceInterface defines the methods that the AgentClientService must implement
 type AgentClientServiceInterface interface {
     GetUnassignedClients() ([]models.AgentClient, error)
+    GetAgentIDByClientID(clientID string) (int, error)
+}
+
+// AgentClientRepositoryInterface defines the storage operations the AgentClientService depends on
+type AgentClientRepositoryInterface interface {
+	ClientExists(clientID string) (bool, error)
+	IsAgentNull(clientID string) (bool, error)
+	UpdateAgentToClient(clientID string, newID int) error
+	GetUnassignedClients() ([]models.AgentClient, error)
+	GetAllAgents() ([]models.Agent, error)
+	IsAgent(userID int) (bool, error)
+	GetAgentIDByClientID(clientID string) (int, error)
+	GetAgentClientCount() (map[int]int, error)
 }
@@ -0,0 +1,20 @@
+package interfaces
+
+import (
+	"backend/models"
+	"backend/services/listquery"
+)
+
+// AgentClientLogRepositoryInterface defines the storage operations the AgentClientLogService depends on
+type AgentClientLogRepositoryInterface interface {
+	// CreateAgentClientLog records ClientChangeLogged in the same transaction as the log.
+	// Both return ErrAlreadyLogged when source names an event that is already logged.
+	CreateAgentClientLog(agentID int, clientID string, action string, modifiedFields map[string]interface{}, meta models.EventMeta, source models.LogSource) (models.AgentClientLog, error)
+	LogAccountChange(agentID int, clientID string, action string, bankAccountInfo map[string]interface{}, requestID string, source models.LogSource) (models.AgentClientLog, error)
+	ListLogs(q listquery.Query) ([]models.AgentClientLog, error)
+	// RedactLog appends a Redact entry and blanks the target's content, leaving the hash chain intact
+	RedactLog(logID int, agentID int, reason string, requestID string) (models.AgentClientLog, error)
+	ListChain(afterID int, limit int) ([]models.LogChainEntry, error)
+	ChainHead() (models.LogChainHead, error)
+	SealLegacy() (int, error)
+}
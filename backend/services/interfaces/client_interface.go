@@ -1,10 +1,35 @@
 package interfaces
 
-import "backend/models"
+import (
+	"backend/models"
+	"backend/services/listquery"
+	"context"
+)
 
 // ClientServiceInterface defines the methods that a ClientService must implement
 type ClientServiceInterface interface {
 	GetClient(clientID string) (models.Client, error)
+	UpdateClient(ctx context.Context, client models.Client, agentID int) (models.Client, error)
+	RevertClient(ctx context.Context, clientID string, expected, restore map[string]string, agentID int) (models.Client, []models.RevertConflict, error)
 	// Add other methods as needed
 }
 
+
+// ClientRepositoryInterface defines the storage operations the ClientService depends on
+type ClientRepositoryInterface interface {
+	EmailExists(email string) (bool, error)
+	PhoneExists(phone string) (bool, error)
+	AgentExists(agentID int) (bool, error)
+	// CreateClient, UpdateClient and DeleteClient record their domain event in the same
+	// transaction as the change
+	CreateClient(client models.Client, agentID int, meta models.EventMeta) (models.Client, error)
+	GetClientByID(clientID string) (models.Client, error)
+	UpdateClient(client models.Client, meta models.EventMeta) (models.Client, error)
+	// RevertClient writes the fields in restore only if every field in expected still holds
+	// its value, checking and writing in one transaction; otherwise it returns the conflicts
+	RevertClient(clientID string, expected, restore map[string]string, meta models.EventMeta) (models.Client, []models.RevertConflict, error)
+	DeleteClient(clientID string, meta models.EventMeta) error
+	VerifyClient(clientID string) error
+	ListClients(q listquery.Query) ([]models.Client, error)
+	IsClientOwnedByAgent(clientID string, agentID int) (bool, error)
+}
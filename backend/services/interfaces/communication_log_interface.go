@@ -0,0 +1,9 @@
+package interfaces
+
+import "backend/models"
+
+// CommunicationLogRepositoryInterface defines the storage operations the CommunicationLogService depends on
+type CommunicationLogRepositoryInterface interface {
+	InsertCommunicationLog(logID int, clientID string, agentID int, emailSubject, emailStatus, requestID string) error
+	GetCommunicationLogByLogID(logID int) (models.CommunicationLog, error)
+}
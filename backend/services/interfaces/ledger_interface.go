@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"backend/models"
+	"backend/services/listquery"
+)
+
+// LedgerServiceInterface defines the ledger operations other services depend on
+type LedgerServiceInterface interface {
+	HasPostings(accountID int) (bool, error)
+}
+
+// LedgerRepositoryInterface defines the storage operations the LedgerService depends on
+type LedgerRepositoryInterface interface {
+	PostEntry(entry models.JournalEntry) (models.JournalEntry, error)
+	GetAccountBalance(accountID int) (models.AccountBalance, error)
+	GetClientBalances(clientID string) ([]models.AccountBalance, error)
+	ListPostings(q listquery.Query) ([]models.Posting, error)
+}
@@ -0,0 +1,25 @@
+package interfaces
+
+import (
+	"backend/models"
+	"time"
+)
+
+// OutboxRepositoryInterface defines the storage operations the event Dispatcher depends on
+type OutboxRepositoryInterface interface {
+	// FanOut creates a pending delivery per subscriber for up to limit events that have not
+	// been fanned out yet, and reports how many events it took
+	FanOut(subscribersOf func(eventType string) []string, limit int) (int, error)
+	// ClaimDue takes up to limit pending deliveries that are due for the given subscribers, or
+	// with except for every other subscriber, counts the attempt and hides them from other
+	// dispatchers for lease
+	ClaimDue(subscribers []string, except bool, limit int, lease time.Duration) ([]models.OutboxDelivery, error)
+	MarkDelivered(deliveryID int64) error
+	// MarkFailed records the error and makes the delivery due again after retryIn, or
+	// dead-letters it
+	MarkFailed(deliveryID int64, lastError string, retryIn time.Duration, dead bool) error
+	ListDeliveries(status string, limit int) ([]models.OutboxDelivery, error)
+	// Requeue makes a dead-lettered delivery pending again with a fresh attempt count
+	Requeue(deliveryID int64) error
+	GetEvent(eventID int64) (models.OutboxEvent, error)
+}
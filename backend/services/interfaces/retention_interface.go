@@ -0,0 +1,22 @@
+package interfaces
+
+import "backend/models"
+
+// RetentionRepositoryInterface defines the storage operations the retention Archiver depends on
+type RetentionRepositoryInterface interface {
+	// ExpiredAgentClientLogs lists sealed logs of a log_type written before cutoff, oldest first,
+	// skipping clients under legal hold
+	ExpiredAgentClientLogs(logType string, cutoff string, limit int) ([]models.AgentClientLog, error)
+	ExpiredCommunicationLogs(cutoff string, limit int) ([]models.CommunicationLog, error)
+	// PurgeAgentClientLogs records the archive and replaces the logs with hash chain tombstones in
+	// one transaction. It fails, purging nothing, if a log is gone, was redacted or otherwise
+	// differs from the archived copy, or its client was put on hold.
+	PurgeAgentClientLogs(archive models.LogArchive, logs []models.AgentClientLog) (models.LogArchive, error)
+	PurgeCommunicationLogs(archive models.LogArchive, logIDs []int) (models.LogArchive, error)
+	GetArchive(archiveID int64) (models.LogArchive, error)
+	ListArchives() ([]models.LogArchive, error)
+	ListTombstones(archiveID int64) ([]models.LogTombstone, error)
+	PlaceHold(hold models.LegalHold) (models.LegalHold, error)
+	ReleaseHold(clientID string) error
+	ListHolds() ([]models.LegalHold, error)
+}
@@ -0,0 +1,12 @@
+package interfaces
+
+import "backend/models"
+
+// TransferRepositoryInterface defines the storage operations the TransferService depends on
+type TransferRepositoryInterface interface {
+	// CreateTransfer posts a transfer atomically. It reports replayed when the idempotency key
+	// matched an earlier identical request, in which case the earlier transfer is returned.
+	// A new transfer records TransferCompleted in the same transaction.
+	CreateTransfer(transfer models.Transfer, meta models.EventMeta) (created models.Transfer, replayed bool, err error)
+	GetTransfer(transferID int64) (models.Transfer, error)
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"backend/models"
+	"backend/services/listquery"
+)
+
+// UserRepositoryInterface defines the storage operations the UserService depends on
+type UserRepositoryInterface interface {
+	CreateUser(firstName, lastName, email, role string) (models.User, error)
+	GetUserByEmail(email string) (models.User, error)
+	GetUserByID(userID string) (models.User, error)
+	DisableUser(userID string) error
+	UpdateUser(userID string, user models.User) error
+	InsertUserFromCognito(email, role string) (models.User, error)
+	ListUsers(q listquery.Query) ([]models.User, error)
+}
@@ -0,0 +1,19 @@
+package interfaces
+
+import "backend/models"
+
+// WebhookRepositoryInterface defines the storage operations the WebhookService depends on
+type WebhookRepositoryInterface interface {
+	CreateSubscription(subscription models.WebhookSubscription) (models.WebhookSubscription, error)
+	GetSubscription(subscriptionID int64) (models.WebhookSubscription, error)
+	ListSubscriptions() ([]models.WebhookSubscription, error)
+	// ListActiveSubscriptions returns the active subscriptions to an event type
+	ListActiveSubscriptions(eventType string) ([]models.WebhookSubscription, error)
+	DeactivateSubscription(subscriptionID int64) error
+	RecordDelivery(delivery models.WebhookDelivery) (models.WebhookDelivery, error)
+	GetDelivery(deliveryID int64) (models.WebhookDelivery, error)
+	ListDeliveries(subscriptionID int64, limit int) ([]models.WebhookDelivery, error)
+	// DeliveryState reports how many attempts were made to deliver an event to a subscription
+	// and whether one of them succeeded
+	DeliveryState(subscriptionID, eventID int64) (attempts int, delivered bool, err error)
+}
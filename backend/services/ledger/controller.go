@@ -0,0 +1,91 @@
+package ledger
+
+import (
+	"backend/services/listquery"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// GetAccountBalanceHandler restricts to Admin or Agent
+func GetAccountBalanceHandler(service *LedgerService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userCtx := r.Context().Value("user").(map[string]interface{})
+		role := userCtx["role"].(string)
+		if role != "Admin" && role != "Agent" {
+			http.Error(w, "Unauthorized: only Admin or Agent can view balances", http.StatusForbidden)
+			return
+		}
+
+		accountID, err := strconv.Atoi(mux.Vars(r)["account_id"])
+		if err != nil {
+			http.Error(w, "Invalid account ID", http.StatusBadRequest)
+			return
+		}
+
+		balance, err := service.GetAccountBalance(accountID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(balance)
+	}
+}
+
+// GetAccountPostingsHandler restricts to Admin or Agent
+func GetAccountPostingsHandler(service *LedgerService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userCtx := r.Context().Value("user").(map[string]interface{})
+		role := userCtx["role"].(string)
+		if role != "Admin" && role != "Agent" {
+			http.Error(w, "Unauthorized: only Admin or Agent can view postings", http.StatusForbidden)
+			return
+		}
+
+		accountID, err := strconv.Atoi(mux.Vars(r)["account_id"])
+		if err != nil {
+			http.Error(w, "Invalid account ID", http.StatusBadRequest)
+			return
+		}
+
+		q, err := listquery.Parse(r, PostingListSpec)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		postings, err := service.GetAccountPostings(accountID, q)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(postings)
+	}
+}
+
+// GetClientBalanceHandler restricts to Admin or Agent
+func GetClientBalanceHandler(service *LedgerService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userCtx := r.Context().Value("user").(map[string]interface{})
+		role := userCtx["role"].(string)
+		if role != "Admin" && role != "Agent" {
+			http.Error(w, "Unauthorized: only Admin or Agent can view balances", http.StatusForbidden)
+			return
+		}
+
+		balance, err := service.GetClientBalance(mux.Vars(r)["clientId"])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(balance)
+	}
+}
@@ -0,0 +1,91 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"backend/models"
+)
+
+// Ledger accounts that are not client accounts
+const (
+	// ExternalCash is the counterparty for money entering or leaving the bank, e.g. ingested deposits
+	ExternalCash = "external:cash"
+	// OpeningEquity is the counterparty for opening balances
+	OpeningEquity = "equity:opening"
+)
+
+// Sources of journal entries
+const (
+	SourceOpening = "opening"
+)
+
+// ErrDuplicateEntry is returned when an entry with the same source and source_ref was already posted
+var ErrDuplicateEntry = errors.New("journal entry already posted")
+
+// AccountLedger returns the ledger account name of a client account
+func AccountLedger(accountID int) string {
+	return fmt.Sprintf("account:%d", accountID)
+}
+
+// OpeningEntry is the entry posting a new account's initial deposit against opening equity.
+// ok is false when there is no deposit to post.
+func OpeningEntry(account models.Account) (entry models.JournalEntry, ok bool) {
+	amount := models.MoneyFromFloat(account.InitialDeposit)
+	if amount == 0 {
+		return models.JournalEntry{}, false
+	}
+
+	accountID := account.AccountID
+	return models.JournalEntry{
+		Source:      SourceOpening,
+		SourceRef:   strconv.Itoa(accountID),
+		Description: "Opening balance",
+		Postings: []models.Posting{
+			{LedgerAccount: AccountLedger(accountID), AccountID: &accountID, Currency: account.Currency, Amount: amount},
+			{LedgerAccount: OpeningEquity, Currency: account.Currency, Amount: -amount},
+		},
+	}, true
+}
+
+// Validate checks that an entry can be posted: it needs a source, at least two non-zero
+// postings, and its postings must sum to zero in every currency
+func Validate(entry models.JournalEntry) error {
+	if entry.Source == "" || entry.SourceRef == "" {
+		return fmt.Errorf("journal entry needs a source and source_ref")
+	}
+	if len(entry.Postings) < 2 {
+		return fmt.Errorf("journal entry needs at least two postings")
+	}
+
+	totals := make(map[string]models.Money)
+	for _, posting := range entry.Postings {
+		if posting.LedgerAccount == "" || posting.Currency == "" {
+			return fmt.Errorf("posting needs a ledger account and currency")
+		}
+		if posting.Amount == 0 {
+			return fmt.Errorf("posting to %s has a zero amount", posting.LedgerAccount)
+		}
+		totals[posting.Currency] += posting.Amount
+	}
+	for currency, total := range totals {
+		if total != 0 {
+			return fmt.Errorf("journal entry is unbalanced by %s %s", total, currency)
+		}
+	}
+	return nil
+}
+
+// lockOrder sorts postings by ledger account and currency so concurrent entries lock balances in the same order
+func lockOrder(postings []models.Posting) []models.Posting {
+	ordered := append([]models.Posting{}, postings...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].LedgerAccount != ordered[j].LedgerAccount {
+			return ordered[i].LedgerAccount < ordered[j].LedgerAccount
+		}
+		return ordered[i].Currency < ordered[j].Currency
+	})
+	return ordered
+}
@@ -0,0 +1,113 @@
+package ledger
+
+import (
+	"backend/database/memstore"
+	"backend/models"
+	"backend/services/interfaces"
+	"backend/services/listquery"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var _ interfaces.LedgerRepositoryInterface = (*MemoryLedgerRepository)(nil)
+
+// MemoryLedgerRepository is an in-memory implementation of interfaces.LedgerRepositoryInterface
+type MemoryLedgerRepository struct {
+	store *memstore.Store
+}
+
+// NewMemoryLedgerRepository initializes a new MemoryLedgerRepository on the given store
+func NewMemoryLedgerRepository(store *memstore.Store) *MemoryLedgerRepository {
+	return &MemoryLedgerRepository{store: store}
+}
+
+// PostEntry stores a journal entry and applies its postings to the running balances
+func (r *MemoryLedgerRepository) PostEntry(entry models.JournalEntry) (models.JournalEntry, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	return r.PostEntryLocked(entry)
+}
+
+// PostEntryLocked is PostEntry for callers that already hold the store's lock, so the entry
+// is written together with the caller's other changes
+func (r *MemoryLedgerRepository) PostEntryLocked(entry models.JournalEntry) (models.JournalEntry, error) {
+	if err := Validate(entry); err != nil {
+		return models.JournalEntry{}, err
+	}
+
+	for _, existing := range r.store.JournalEntries {
+		if existing.Source == entry.Source && existing.SourceRef == entry.SourceRef {
+			return models.JournalEntry{}, ErrDuplicateEntry
+		}
+	}
+
+	now := time.Now().UTC().Format("2006-01-02 15:04:05")
+	entry.ID = r.store.NextEntryID
+	entry.CreatedAt = now
+	r.store.NextEntryID++
+
+	postings := lockOrder(entry.Postings)
+	for i, posting := range postings {
+		key := posting.LedgerAccount + "|" + posting.Currency
+		r.store.LedgerBalances[key] += posting.Amount
+
+		posting.ID = r.store.NextPostingID
+		posting.EntryID = entry.ID
+		posting.BalanceAfter = r.store.LedgerBalances[key]
+		posting.CreatedAt = now
+		r.store.NextPostingID++
+		postings[i] = posting
+	}
+
+	entry.Postings = postings
+	r.store.JournalEntries = append(r.store.JournalEntries, entry)
+	return entry, nil
+}
+
+// GetAccountBalance retrieves the ledger balance of an active account in its own currency
+func (r *MemoryLedgerRepository) GetAccountBalance(accountID int) (models.AccountBalance, error) {
+	balances := r.accountBalances(func(account models.Account) bool { return account.AccountID == accountID })
+	if len(balances) == 0 {
+		return models.AccountBalance{}, fmt.Errorf("account with ID %d does not exist", accountID)
+	}
+	return balances[0], nil
+}
+
+// GetClientBalances retrieves the ledger balance of every active account of a client
+func (r *MemoryLedgerRepository) GetClientBalances(clientID string) ([]models.AccountBalance, error) {
+	return r.accountBalances(func(account models.Account) bool { return account.ClientID == clientID }), nil
+}
+
+func (r *MemoryLedgerRepository) accountBalances(keep func(models.Account) bool) []models.AccountBalance {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	var balances []models.AccountBalance
+	for _, account := range r.store.Accounts {
+		if !account.IsActive || !keep(account) {
+			continue
+		}
+		balances = append(balances, models.AccountBalance{
+			AccountID: account.AccountID,
+			ClientID:  account.ClientID,
+			Currency:  account.Currency,
+			Balance:   r.store.LedgerBalances[AccountLedger(account.AccountID)+"|"+account.Currency],
+		})
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].AccountID < balances[j].AccountID })
+	return balances
+}
+
+// ListPostings retrieves one page of postings matching the query
+func (r *MemoryLedgerRepository) ListPostings(q listquery.Query) ([]models.Posting, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	var postings []models.Posting
+	for _, entry := range r.store.JournalEntries {
+		postings = append(postings, entry.Postings...)
+	}
+	return listquery.Apply(postings, q, PostingField), nil
+}
@@ -0,0 +1,274 @@
+package ledger
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"backend/models"
+	"backend/services/listquery"
+
+	"backend/services/metrics"
+	"github.com/go-sql-driver/mysql"
+	"time"
+)
+
+// LedgerRepository is the MySQL implementation of interfaces.LedgerRepositoryInterface
+type LedgerRepository struct {
+	db *sql.DB
+}
+
+// NewLedgerRepository initializes a new LedgerRepository
+func NewLedgerRepository(db *sql.DB) *LedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+// PostEntry writes a journal entry and its postings in its own transaction
+func (r *LedgerRepository) PostEntry(entry models.JournalEntry) (models.JournalEntry, error) {
+	defer metrics.ObserveQuery("LedgerRepository", "PostEntry", time.Now())
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.JournalEntry{}, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	posted, err := PostEntryTx(tx, entry)
+	if err != nil {
+		tx.Rollback()
+		return models.JournalEntry{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.JournalEntry{}, fmt.Errorf("failed to commit journal entry: %v", err)
+	}
+	return posted, nil
+}
+
+// PostEntryTx writes a journal entry inside the caller's transaction, so the postings commit
+// or roll back together with the caller's other writes. Each posting locks its balance row,
+// applies the amount and records the resulting running balance.
+func PostEntryTx(tx *sql.Tx, entry models.JournalEntry) (models.JournalEntry, error) {
+	if err := Validate(entry); err != nil {
+		return models.JournalEntry{}, err
+	}
+
+	result, err := tx.Exec(
+		`INSERT INTO journal_entries (source, source_ref, description) VALUES (?, ?, ?)`,
+		entry.Source, entry.SourceRef, entry.Description,
+	)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return models.JournalEntry{}, ErrDuplicateEntry
+		}
+		return models.JournalEntry{}, fmt.Errorf("failed to insert journal entry: %v", err)
+	}
+
+	entryID, err := result.LastInsertId()
+	if err != nil {
+		return models.JournalEntry{}, fmt.Errorf("failed to get journal entry ID: %v", err)
+	}
+	entry.ID = entryID
+
+	postings := lockOrder(entry.Postings)
+	for i, posting := range postings {
+		balance, err := lockBalance(tx, posting)
+		if err != nil {
+			return models.JournalEntry{}, err
+		}
+
+		balance += posting.Amount
+		_, err = tx.Exec(
+			`UPDATE ledger_balances SET balance = ? WHERE ledger_account = ? AND currency = ?`,
+			balance, posting.LedgerAccount, posting.Currency,
+		)
+		if err != nil {
+			return models.JournalEntry{}, fmt.Errorf("failed to update balance for %s: %v", posting.LedgerAccount, err)
+		}
+
+		result, err := tx.Exec(`
+			INSERT INTO ledger_postings (entry_id, ledger_account, account_id, currency, amount, balance_after)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			entryID, posting.LedgerAccount, posting.AccountID, posting.Currency, posting.Amount, balance,
+		)
+		if err != nil {
+			return models.JournalEntry{}, fmt.Errorf("failed to insert posting for %s: %v", posting.LedgerAccount, err)
+		}
+
+		postingID, err := result.LastInsertId()
+		if err != nil {
+			return models.JournalEntry{}, fmt.Errorf("failed to get posting ID: %v", err)
+		}
+
+		posting.ID = postingID
+		posting.EntryID = entryID
+		posting.BalanceAfter = balance
+		postings[i] = posting
+	}
+
+	entry.Postings = postings
+	return entry, nil
+}
+
+// LockBalancesTx locks the balance rows of postings inside tx, in the order PostEntryTx takes
+// them, and returns the balances in the order of postings. A caller that must check a balance
+// before posting locks it this way, so that its locks cannot interleave with another entry's.
+func LockBalancesTx(tx *sql.Tx, postings []models.Posting) ([]models.Money, error) {
+	locked := make(map[string]models.Money, len(postings))
+	for _, posting := range lockOrder(postings) {
+		balance, err := lockBalance(tx, posting)
+		if err != nil {
+			return nil, err
+		}
+		locked[posting.LedgerAccount+"/"+posting.Currency] = balance
+	}
+
+	balances := make([]models.Money, len(postings))
+	for i, posting := range postings {
+		balances[i] = locked[posting.LedgerAccount+"/"+posting.Currency]
+	}
+	return balances, nil
+}
+
+// lockBalance makes sure a posting's balance row exists, then locks it and reads the balance
+func lockBalance(tx *sql.Tx, posting models.Posting) (models.Money, error) {
+	_, err := tx.Exec(`
+		INSERT INTO ledger_balances (ledger_account, currency, account_id, balance)
+		VALUES (?, ?, ?, 0)
+		ON DUPLICATE KEY UPDATE ledger_account = ledger_account`,
+		posting.LedgerAccount, posting.Currency, posting.AccountID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create balance for %s: %v", posting.LedgerAccount, err)
+	}
+
+	var balance models.Money
+	err = tx.QueryRow(
+		`SELECT balance FROM ledger_balances WHERE ledger_account = ? AND currency = ? FOR UPDATE`,
+		posting.LedgerAccount, posting.Currency,
+	).Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock balance for %s: %v", posting.LedgerAccount, err)
+	}
+	return balance, nil
+}
+
+// GetAccountBalance retrieves the ledger balance of an active account in its own currency
+func (r *LedgerRepository) GetAccountBalance(accountID int) (models.AccountBalance, error) {
+	defer metrics.ObserveQuery("LedgerRepository", "GetAccountBalance", time.Now())
+	balances, err := r.accountBalances(`a.account_id = ?`, accountID)
+	if err != nil {
+		return models.AccountBalance{}, err
+	}
+	if len(balances) == 0 {
+		return models.AccountBalance{}, fmt.Errorf("account with ID %d does not exist", accountID)
+	}
+	return balances[0], nil
+}
+
+// GetClientBalances retrieves the ledger balance of every active account of a client
+func (r *LedgerRepository) GetClientBalances(clientID string) ([]models.AccountBalance, error) {
+	defer metrics.ObserveQuery("LedgerRepository", "GetClientBalances", time.Now())
+	return r.accountBalances(`a.client_id = ?`, clientID)
+}
+
+// accountBalances joins active accounts to their balance rows; accounts with no postings have a zero balance
+func (r *LedgerRepository) accountBalances(where string, arg interface{}) ([]models.AccountBalance, error) {
+	query := `
+		SELECT a.account_id, a.client_id, a.currency, COALESCE(b.balance, 0)
+		FROM account a
+		LEFT JOIN ledger_balances b
+			ON b.ledger_account = CONCAT('account:', a.account_id) AND b.currency = a.currency
+		WHERE a.is_active = TRUE AND ` + where + `
+		ORDER BY a.account_id`
+
+	rows, err := r.db.Query(query, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve balances: %v", err)
+	}
+	defer rows.Close()
+
+	var balances []models.AccountBalance
+	for rows.Next() {
+		var balance models.AccountBalance
+		if err := rows.Scan(&balance.AccountID, &balance.ClientID, &balance.Currency, &balance.Balance); err != nil {
+			return nil, fmt.Errorf("error scanning balance row: %v", err)
+		}
+		balances = append(balances, balance)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating balance rows: %v", err)
+	}
+
+	return balances, nil
+}
+
+// PostingListSpec lists the sort keys and filters accepted by posting listings
+var PostingListSpec = listquery.Spec{
+	IDColumn: "id",
+	SortColumns: map[string]string{
+		"id": "id",
+	},
+	DefaultSort: "-id",
+	Filters: map[string]listquery.Filter{
+		"currency": {Column: "currency", Op: "="},
+		"from":     {Column: "created_at", Op: ">="},
+		"to":       {Column: "created_at", Op: "<="},
+	},
+}
+
+// PostingField returns a posting's value for a PostingListSpec column
+func PostingField(posting models.Posting, column string) interface{} {
+	switch column {
+	case "id":
+		return posting.ID
+	case "account_id":
+		if posting.AccountID == nil {
+			return nil
+		}
+		return *posting.AccountID
+	case "currency":
+		return posting.Currency
+	case "created_at":
+		return posting.CreatedAt
+	}
+	return nil
+}
+
+// ListPostings retrieves one page of postings matching the query
+func (r *LedgerRepository) ListPostings(q listquery.Query) ([]models.Posting, error) {
+	defer metrics.ObserveQuery("LedgerRepository", "ListPostings", time.Now())
+	clause, args := q.SQL()
+	query := `SELECT id, entry_id, ledger_account, account_id, currency, amount, balance_after, created_at
+		FROM ledger_postings` + clause
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve postings: %v", err)
+	}
+	defer rows.Close()
+
+	var postings []models.Posting
+	for rows.Next() {
+		var posting models.Posting
+		var accountID sql.NullInt64
+		err := rows.Scan(
+			&posting.ID, &posting.EntryID, &posting.LedgerAccount, &accountID,
+			&posting.Currency, &posting.Amount, &posting.BalanceAfter, &posting.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning posting row: %v", err)
+		}
+		if accountID.Valid {
+			id := int(accountID.Int64)
+			posting.AccountID = &id
+		}
+		postings = append(postings, posting)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating posting rows: %v", err)
+	}
+
+	return postings, nil
+}
@@ -0,0 +1,74 @@
+package ledger
+
+import (
+	"backend/models"
+	"backend/services/interfaces"
+	"backend/services/listquery"
+)
+
+// LedgerService handles journal entries and balances
+type LedgerService struct {
+	repo interfaces.LedgerRepositoryInterface
+}
+
+// NewLedgerService initializes the ledger service
+func NewLedgerService(repo interfaces.LedgerRepositoryInterface) *LedgerService {
+	return &LedgerService{repo: repo}
+}
+
+// PostEntry validates and posts a balanced journal entry
+func (s *LedgerService) PostEntry(entry models.JournalEntry) (models.JournalEntry, error) {
+	if err := Validate(entry); err != nil {
+		return models.JournalEntry{}, err
+	}
+	return s.repo.PostEntry(entry)
+}
+
+// HasPostings reports whether anything has been posted to an account
+func (s *LedgerService) HasPostings(accountID int) (bool, error) {
+	q := listquery.All("id")
+	q.Limit = 1
+	postings, err := s.repo.ListPostings(q.Where("account_id", "=", accountID))
+	if err != nil {
+		return false, err
+	}
+	return len(postings) > 0, nil
+}
+
+// GetAccountBalance retrieves the current balance of an account
+func (s *LedgerService) GetAccountBalance(accountID int) (models.AccountBalance, error) {
+	return s.repo.GetAccountBalance(accountID)
+}
+
+// GetClientBalance retrieves the balances of a client's accounts, totalled per currency
+func (s *LedgerService) GetClientBalance(clientID string) (models.ClientBalance, error) {
+	balances, err := s.repo.GetClientBalances(clientID)
+	if err != nil {
+		return models.ClientBalance{}, err
+	}
+
+	clientBalance := models.ClientBalance{
+		ClientID: clientID,
+		Accounts: []models.AccountBalance{},
+		Totals:   make(map[string]models.Money),
+	}
+	for _, balance := range balances {
+		clientBalance.Accounts = append(clientBalance.Accounts, balance)
+		clientBalance.Totals[balance.Currency] += balance.Balance
+	}
+	return clientBalance, nil
+}
+
+// GetAccountPostings retrieves one page of an account's postings with their running balance
+func (s *LedgerService) GetAccountPostings(accountID int, q listquery.Query) (listquery.Page[models.Posting], error) {
+	if _, err := s.repo.GetAccountBalance(accountID); err != nil {
+		return listquery.Page[models.Posting]{}, err
+	}
+
+	q = q.Where("account_id", "=", accountID)
+	postings, err := s.repo.ListPostings(q)
+	if err != nil {
+		return listquery.Page[models.Posting]{}, err
+	}
+	return listquery.NewPage(postings, q, PostingField)
+}
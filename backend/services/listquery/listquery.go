@@ -0,0 +1,218 @@
+package listquery
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// Filter maps a query parameter onto a column and comparison operator
+type Filter struct {
+	Column string
+	Op     string // "=", ">=" or "<="
+}
+
+// Spec describes which sort keys and filters a listing accepts
+type Spec struct {
+	// IDColumn is the unique column used to break ties between equal sort values
+	IDColumn string
+	// SortColumns maps the public sort key to its column
+	SortColumns map[string]string
+	DefaultSort string
+	// Filters maps the public query parameter to its filter
+	Filters map[string]Filter
+}
+
+// Condition is a single WHERE predicate
+type Condition struct {
+	Column string
+	Op     string // "=", ">=", "<=" or "IN"
+	Value  interface{}
+}
+
+// Cursor marks the last row of a page: its sort value and its ID
+type Cursor struct {
+	Value interface{} `json:"v"`
+	ID    interface{} `json:"id"`
+}
+
+// Query is a parsed list request
+type Query struct {
+	Limit      int // zero means no limit
+	Cursor     *Cursor
+	SortColumn string
+	IDColumn   string
+	Desc       bool
+	Conditions []Condition
+}
+
+// Page is the response envelope for every list endpoint
+type Page[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// Parse reads limit, cursor, sort and the spec's filters from the request's query string
+func Parse(r *http.Request, spec Spec) (Query, error) {
+	params := r.URL.Query()
+
+	q := Query{Limit: DefaultLimit, IDColumn: spec.IDColumn}
+
+	if raw := params.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return Query{}, fmt.Errorf("limit must be a positive integer")
+		}
+		if limit > MaxLimit {
+			limit = MaxLimit
+		}
+		q.Limit = limit
+	}
+
+	sortKey := params.Get("sort")
+	if sortKey == "" {
+		sortKey = spec.DefaultSort
+	}
+	if strings.HasPrefix(sortKey, "-") {
+		q.Desc = true
+		sortKey = strings.TrimPrefix(sortKey, "-")
+	}
+	column, ok := spec.SortColumns[sortKey]
+	if !ok {
+		return Query{}, fmt.Errorf("unsupported sort field %q", sortKey)
+	}
+	q.SortColumn = column
+
+	if raw := params.Get("cursor"); raw != "" {
+		cursor, err := decodeCursor(raw)
+		if err != nil {
+			return Query{}, err
+		}
+		q.Cursor = &cursor
+	}
+
+	for param, filter := range spec.Filters {
+		if value := params.Get(param); value != "" {
+			q.Conditions = append(q.Conditions, Condition{Column: filter.Column, Op: filter.Op, Value: value})
+		}
+	}
+
+	return q, nil
+}
+
+// All returns an unlimited query sorted ascending by the ID column, for internal callers
+func All(idColumn string) Query {
+	return Query{SortColumn: idColumn, IDColumn: idColumn}
+}
+
+// Where returns a copy of the query with an extra condition, e.g. the scope a handler enforces
+func (q Query) Where(column, op string, value interface{}) Query {
+	q.Conditions = append(append([]Condition{}, q.Conditions...), Condition{Column: column, Op: op, Value: value})
+	return q
+}
+
+// SQL renders the WHERE clause (including the leading keyword, if any), ORDER BY and LIMIT
+// for the query. One extra row is requested so the caller can tell whether a next page exists.
+func (q Query) SQL() (string, []interface{}) {
+	var predicates []string
+	var args []interface{}
+
+	for _, condition := range q.Conditions {
+		if condition.Op == "IN" {
+			values, _ := condition.Value.([]string)
+			placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+			predicates = append(predicates, fmt.Sprintf("%s IN (%s)", condition.Column, placeholders))
+			for _, value := range values {
+				args = append(args, value)
+			}
+			continue
+		}
+		predicates = append(predicates, fmt.Sprintf("%s %s ?", condition.Column, condition.Op))
+		args = append(args, condition.Value)
+	}
+
+	if q.Cursor != nil {
+		op := ">"
+		if q.Desc {
+			op = "<"
+		}
+		if q.SortColumn == q.IDColumn {
+			predicates = append(predicates, fmt.Sprintf("%s %s ?", q.IDColumn, op))
+			args = append(args, q.Cursor.ID)
+		} else {
+			predicates = append(predicates, fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))",
+				q.SortColumn, op, q.SortColumn, q.IDColumn, op))
+			args = append(args, q.Cursor.Value, q.Cursor.Value, q.Cursor.ID)
+		}
+	}
+
+	clause := ""
+	if len(predicates) > 0 {
+		clause = " WHERE " + strings.Join(predicates, " AND ")
+	}
+
+	direction := "ASC"
+	if q.Desc {
+		direction = "DESC"
+	}
+	clause += fmt.Sprintf(" ORDER BY %s %s", q.SortColumn, direction)
+	if q.SortColumn != q.IDColumn {
+		clause += fmt.Sprintf(", %s %s", q.IDColumn, direction)
+	}
+
+	if q.Limit > 0 {
+		clause += " LIMIT ?"
+		args = append(args, q.Limit+1)
+	}
+
+	return clause, args
+}
+
+// NewPage trims the extra row fetched by SQL and builds the next cursor from the last row kept.
+// field returns a row's value for a column.
+func NewPage[T any](rows []T, q Query, field func(row T, column string) interface{}) (Page[T], error) {
+	page := Page[T]{Data: rows}
+	if page.Data == nil {
+		page.Data = []T{}
+	}
+
+	if q.Limit > 0 && len(rows) > q.Limit {
+		page.Data = rows[:q.Limit]
+		last := page.Data[len(page.Data)-1]
+		cursor, err := encodeCursor(Cursor{Value: field(last, q.SortColumn), ID: field(last, q.IDColumn)})
+		if err != nil {
+			return Page[T]{}, err
+		}
+		page.NextCursor = cursor
+	}
+
+	return page, nil
+}
+
+func encodeCursor(cursor Cursor) (string, error) {
+	raw, err := json.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeCursor(encoded string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID == nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	return cursor, nil
+}
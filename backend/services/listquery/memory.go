@@ -0,0 +1,128 @@
+package listquery
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Apply evaluates the query against rows held in memory, mirroring what SQL renders:
+// it filters, applies the cursor, sorts and returns at most Limit+1 rows.
+// field returns a row's value for a column.
+func Apply[T any](rows []T, q Query, field func(row T, column string) interface{}) []T {
+	var kept []T
+	for _, row := range rows {
+		if q.matches(func(column string) interface{} { return field(row, column) }) {
+			kept = append(kept, row)
+		}
+	}
+
+	sort.SliceStable(kept, func(i, j int) bool {
+		return q.before(field(kept[i], q.SortColumn), field(kept[i], q.IDColumn),
+			field(kept[j], q.SortColumn), field(kept[j], q.IDColumn))
+	})
+
+	if q.Limit > 0 && len(kept) > q.Limit+1 {
+		kept = kept[:q.Limit+1]
+	}
+	return kept
+}
+
+// matches reports whether a row, read through get, satisfies the conditions and lies after the cursor
+func (q Query) matches(get func(column string) interface{}) bool {
+	for _, condition := range q.Conditions {
+		value := get(condition.Column)
+		switch condition.Op {
+		case "=":
+			if compare(value, condition.Value) != 0 {
+				return false
+			}
+		case ">=":
+			if compare(value, condition.Value) < 0 {
+				return false
+			}
+		case "<=":
+			if compare(value, condition.Value) > 0 {
+				return false
+			}
+		case "IN":
+			values, _ := condition.Value.([]string)
+			found := false
+			for _, candidate := range values {
+				if compare(value, candidate) == 0 {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+
+	if q.Cursor != nil {
+		return q.before(q.Cursor.Value, q.Cursor.ID, get(q.SortColumn), get(q.IDColumn))
+	}
+	return true
+}
+
+// before reports whether the row (sortA, idA) comes before (sortB, idB) in the query's order
+func (q Query) before(sortA, idA, sortB, idB interface{}) bool {
+	c := 0
+	if q.SortColumn != q.IDColumn {
+		c = compare(sortA, sortB)
+	}
+	if c == 0 {
+		c = compare(idA, idB)
+	}
+	if q.Desc {
+		return c > 0
+	}
+	return c < 0
+}
+
+// compare orders two values the way MySQL would for the column types we list on:
+// numerically when both sides are numbers, otherwise as strings.
+func compare(a, b interface{}) int {
+	fa, aNumeric := toFloat(a)
+	fb, bNumeric := toFloat(b)
+	if aNumeric && bNumeric {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+
+	sa, sb := fmt.Sprint(a), fmt.Sprint(b)
+	switch {
+	case sa < sb:
+		return -1
+	case sa > sb:
+		return 1
+	}
+	return 0
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// Matches reports whether a single row satisfies the query's conditions and cursor, for
+// callers that test rows one at a time as they arrive
+func Matches[T any](row T, q Query, field func(row T, column string) interface{}) bool {
+	return q.matches(func(column string) interface{} { return field(row, column) })
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by route template, method and status code.",
+	}, []string{"route", "method", "code"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by route template and method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	dbQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Database latency by repository and method.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"repository", "method"})
+
+	eventDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "event_deliveries_total",
+		Help: "Outbox event delivery attempts, by subscriber and event type.",
+	}, []string{"subscriber", "event_type"})
+
+	eventDeliveryFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "event_delivery_failures_total",
+		Help: "Outbox event delivery attempts that failed, by subscriber and event type.",
+	}, []string{"subscriber", "event_type"})
+
+	eventDeadLetters = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "event_dead_letters_total",
+		Help: "Outbox event deliveries that ran out of attempts, by subscriber and event type.",
+	}, []string{"subscriber", "event_type"})
+
+	webhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "webhook_deliveries_total",
+		Help: "Webhook delivery attempts, by event type and outcome.",
+	}, []string{"event_type", "outcome"})
+
+	emails = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "emails_total",
+		Help: "Communication emails by delivery status.",
+	}, []string{"status"})
+
+	logsArchived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "logs_archived_total",
+		Help: "Expired logs archived and purged by the retention job, by log type.",
+	}, []string{"log_type"})
+)
+
+// Handler serves the registered metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveRequest records a served request under its route template, not the raw path, so ids
+// in the URL don't create a series per client
+func ObserveRequest(route, method string, code int, elapsed time.Duration) {
+	httpRequests.WithLabelValues(route, method, strconv.Itoa(code)).Inc()
+	httpDuration.WithLabelValues(route, method).Observe(elapsed.Seconds())
+}
+
+// ObserveQuery records how long a repository method took since start. Call it deferred:
+//
+//	defer metrics.ObserveQuery("ClientRepository", "GetClientByID", time.Now())
+func ObserveQuery(repository, method string, start time.Time) {
+	dbQueryDuration.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+}
+
+// ObserveDelivery counts an event delivery attempt and whether it failed
+func ObserveDelivery(subscriber, eventType string, err error) {
+	eventDeliveries.WithLabelValues(subscriber, eventType).Inc()
+	if err != nil {
+		eventDeliveryFailures.WithLabelValues(subscriber, eventType).Inc()
+	}
+}
+
+// ObserveDeadLetter counts a delivery given up on after its last attempt
+func ObserveDeadLetter(subscriber, eventType string) {
+	eventDeadLetters.WithLabelValues(subscriber, eventType).Inc()
+}
+
+// ObserveWebhook counts a webhook delivery attempt as succeeded or failed
+func ObserveWebhook(eventType string, succeeded bool) {
+	outcome := "failed"
+	if succeeded {
+		outcome = "succeeded"
+	}
+	webhookDeliveries.WithLabelValues(eventType, outcome).Inc()
+}
+
+// ObserveArchive counts logs of a type archived and purged in one batch
+func ObserveArchive(logType string, count int) {
+	logsArchived.WithLabelValues(logType).Add(float64(count))
+}
+
+// ObserveEmail counts a communication email by its status (Sent, Failed, ...)
+func ObserveEmail(status string) {
+	emails.WithLabelValues(strings.ToLower(status)).Inc()
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"backend/services/logging"
+
+	"github.com/gorilla/mux"
+)
+
+// RequestIDHeader carries the correlation ID between callers, this API and its logs
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID limits caller-supplied IDs to something safe to log and store
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+// RequestIDMiddleware keeps the caller's X-Request-ID, or assigns one, puts it in the request
+// context and echoes it on the response, then logs the completed request under it
+func RequestIDMiddleware(logger *slog.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(RequestIDHeader)
+			if !validRequestID.MatchString(requestID) {
+				requestID = logging.NewRequestID()
+			}
+
+			ctx := logging.WithRequestID(r.Context(), requestID)
+			w.Header().Set(RequestIDHeader, requestID)
+
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+
+			logger.InfoContext(ctx, "request completed",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", recorder.status,
+				"duration_ms", time.Since(start).Milliseconds(),
+			)
+		})
+	}
+}
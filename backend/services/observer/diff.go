@@ -0,0 +1,235 @@
+package observer
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Change is a value's before and after at one path
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Diff is the set of changes between two values, keyed by JSON path ("phone",
+// "address.city", "holders[1].name"). Masked lists the paths whose values were masked, which
+// may lie inside a change recorded whole, such as "holders[2].phone" in an added "holders[2]".
+type Diff struct {
+	Changes map[string]Change
+	Masked  []string
+}
+
+// Masker replaces a sensitive value with what may be logged
+type Masker func(value interface{}) interface{}
+
+// MaskAll hides a value entirely; empty values stay empty
+func MaskAll(value interface{}) interface{} {
+	if value == nil || value == "" {
+		return value
+	}
+	return "****"
+}
+
+// MaskLast keeps only the last n characters of a value
+func MaskLast(n int) Masker {
+	return func(value interface{}) interface{} {
+		if value == nil || value == "" {
+			return value
+		}
+		text := []rune(fmt.Sprint(value))
+		if len(text) <= n {
+			return "****"
+		}
+		return "****" + string(text[len(text)-n:])
+	}
+}
+
+// Field is one field of a value under its JSON name. Its value is a scalar (a string, bool or
+// number), an Object for a nested struct or map, or a List for a nested slice.
+type Field struct {
+	Name  string
+	Value interface{}
+}
+
+// Object is a struct or map as its fields
+type Object []Field
+
+// List is a slice as its elements
+type List []interface{}
+
+// get returns the value of the named field, or nil
+func (o Object) get(name string) interface{} {
+	for _, field := range o {
+		if field.Name == name {
+			return field.Value
+		}
+	}
+	return nil
+}
+
+// DiffRules configure a Differ. Paths are JSON paths with slice indexes written as [], so
+// "holders[].phone" applies to every element; a rule on an object covers its contents.
+type DiffRules struct {
+	Mask   map[string]Masker // sensitive paths and how they are masked
+	Ignore []string          // volatile paths left out of diffs and snapshots
+}
+
+// Differ compares values of one type field by field, through an accessor that lists a value's
+// fields under their JSON names, so changes are named the way the API names them. Nested
+// objects and lists are walked and their leaves compared directly.
+type Differ[T any] struct {
+	fields func(T) Object
+	mask   map[string]Masker
+	ignore map[string]bool
+}
+
+// NewDiffer initializes a Differ with the accessor of T's fields and its rules
+func NewDiffer[T any](fields func(T) Object, rules DiffRules) *Differ[T] {
+	d := &Differ[T]{fields: fields, mask: rules.Mask, ignore: make(map[string]bool)}
+	for _, path := range rules.Ignore {
+		d.ignore[path] = true
+	}
+	return d
+}
+
+// Diff returns what changed from before to after
+func (d *Differ[T]) Diff(before, after T) Diff {
+	diff := Diff{Changes: make(map[string]Change)}
+	d.diff("", "", d.fields(before), d.fields(after), &diff)
+	sort.Strings(diff.Masked)
+	diff.Masked = slices.Compact(diff.Masked)
+	return diff
+}
+
+// Snapshot returns v as it may be logged, without the ignored paths and with the masks
+// applied, and the paths that were masked
+func (d *Differ[T]) Snapshot(v T) (map[string]interface{}, []string) {
+	var masked []string
+	snapshot, _ := d.snapshot("", "", d.fields(v), &masked).(map[string]interface{})
+	sort.Strings(masked)
+	return snapshot, masked
+}
+
+// diff compares the values at path; rule is path in the form rules are written in
+func (d *Differ[T]) diff(path, rule string, before, after interface{}, out *Diff) {
+	if d.ignore[rule] {
+		return
+	}
+	if mask, ok := d.mask[rule]; ok {
+		if !same(before, after) {
+			out.Changes[path] = Change{Before: mask(before), After: mask(after)}
+			out.Masked = append(out.Masked, path)
+		}
+		return
+	}
+
+	switch b := before.(type) {
+	case Object:
+		if a, ok := after.(Object); ok {
+			for _, name := range unionNames(b, a) {
+				d.diff(joinPath(path, name), joinPath(rule, name), b.get(name), a.get(name), out)
+			}
+			return
+		}
+	case List:
+		if a, ok := after.(List); ok {
+			for i := 0; i < len(b) || i < len(a); i++ {
+				d.diff(fmt.Sprintf("%s[%d]", path, i), rule+"[]", element(b, i), element(a, i), out)
+			}
+			return
+		}
+	}
+
+	// A value added, removed or changed in shape is recorded whole, with masks applied inside it
+	if !same(before, after) {
+		out.Changes[path] = Change{Before: d.snapshot(path, rule, before, &out.Masked), After: d.snapshot(path, rule, after, &out.Masked)}
+	}
+}
+
+func (d *Differ[T]) snapshot(path, rule string, value interface{}, masked *[]string) interface{} {
+	if mask, ok := d.mask[rule]; ok {
+		*masked = append(*masked, path)
+		return mask(value)
+	}
+
+	switch v := value.(type) {
+	case Object:
+		kept := make(map[string]interface{}, len(v))
+		for _, field := range v {
+			childRule := joinPath(rule, field.Name)
+			if !d.ignore[childRule] {
+				kept[field.Name] = d.snapshot(joinPath(path, field.Name), childRule, field.Value, masked)
+			}
+		}
+		return kept
+	case List:
+		kept := make([]interface{}, len(v))
+		for i, child := range v {
+			kept[i] = d.snapshot(fmt.Sprintf("%s[%d]", path, i), rule+"[]", child, masked)
+		}
+		return kept
+	}
+	return value
+}
+
+// same reports whether two field values are equal. Objects and lists are equal when their
+// contents are; scalars are compared directly.
+func same(a, b interface{}) bool {
+	switch x := a.(type) {
+	case Object:
+		y, ok := b.(Object)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for _, field := range x {
+			if !same(field.Value, y.get(field.Name)) {
+				return false
+			}
+		}
+		return true
+	case List:
+		y, ok := b.(List)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !same(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	switch b.(type) {
+	case Object, List:
+		return false
+	}
+	return a == b
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// unionNames is the names of the fields of a and b, sorted
+func unionNames(a, b Object) []string {
+	names := make([]string, 0, len(a)+len(b))
+	for _, field := range a {
+		names = append(names, field.Name)
+	}
+	for _, field := range b {
+		names = append(names, field.Name)
+	}
+	sort.Strings(names)
+	return slices.Compact(names)
+}
+
+func element(values List, i int) interface{} {
+	if i < len(values) {
+		return values[i]
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package observer
+
+import (
+	"backend/models"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// jsonNames lists the JSON names v encodes with
+func jsonNames(t *testing.T, v interface{}) []string {
+	t.Helper()
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func fieldNames(object Object) []string {
+	var names []string
+	for _, field := range object {
+		names = append(names, field.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestFieldAccessorsCoverModels(t *testing.T) {
+	if got, want := fieldNames(clientFields(models.Client{})), jsonNames(t, models.Client{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("clientFields lists %v, want the JSON fields %v", got, want)
+	}
+	if got, want := fieldNames(accountFields(models.Account{})), jsonNames(t, models.Account{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("accountFields lists %v, want the JSON fields %v", got, want)
+	}
+}
+
+type holder struct {
+	Name  string
+	Phone string
+}
+
+type jointAccount struct {
+	Branch    string
+	Holders   []holder
+	UpdatedAt string
+}
+
+func jointAccountFields(a jointAccount) Object {
+	holders := make(List, len(a.Holders))
+	for i, h := range a.Holders {
+		holders[i] = Object{{"name", h.Name}, {"phone", h.Phone}}
+	}
+	return Object{{"branch", a.Branch}, {"holders", holders}, {"updated_at", a.UpdatedAt}}
+}
+
+func TestDiffNestedFields(t *testing.T) {
+	differ := NewDiffer(jointAccountFields, DiffRules{
+		Mask:   map[string]Masker{"holders[].phone": MaskLast(4)},
+		Ignore: []string{"updated_at"},
+	})
+	before := jointAccount{
+		Branch:    "Orchard",
+		Holders:   []holder{{"Jane", "+6591234567"}, {"Wei", "+6598765432"}},
+		UpdatedAt: "2026-01-01",
+	}
+	after := jointAccount{
+		Branch:    "Orchard",
+		Holders:   []holder{{"Jane", "+6591234567"}, {"Wei Ming", "+6598760000"}, {"Ana", "+6590001111"}},
+		UpdatedAt: "2026-02-01",
+	}
+
+	diff := differ.Diff(before, after)
+	want := map[string]Change{
+		"holders[1].name":  {Before: "Wei", After: "Wei Ming"},
+		"holders[1].phone": {Before: "****5432", After: "****0000"},
+		"holders[2]":       {Before: nil, After: map[string]interface{}{"name": "Ana", "phone": "****1111"}},
+	}
+	if !reflect.DeepEqual(diff.Changes, want) {
+		t.Errorf("Changes = %v, want %v", diff.Changes, want)
+	}
+	if wantMasked := []string{"holders[1].phone", "holders[2].phone"}; !reflect.DeepEqual(diff.Masked, wantMasked) {
+		t.Errorf("Masked = %v, want %v", diff.Masked, wantMasked)
+	}
+
+	if diff := differ.Diff(before, before); len(diff.Changes) != 0 {
+		t.Errorf("an unchanged value has changes %v", diff.Changes)
+	}
+}
@@ -3,70 +3,171 @@ package observer
 import (
 	"backend/models"
 	"backend/services/agentclient_logs"
+	"backend/services/events"
+	"backend/services/interfaces"
+	"context"
 	"fmt"
+	"log/slog"
 )
 
-// LogObserver is the observer interface for logging actions
-type LogObserver interface {
-	NotifyCreate(agentID int, clientID string, object interface{})
-	NotifyUpdate(agentID int, clientID string, before, after interface{})
-	NotifyDelete(agentID int, clientID string, object interface{})
+// AuditSubscriber is the outbox subscriber name of the AuditLogSubscriber
+const AuditSubscriber = "audit_log"
+
+// AuditLogSubscriber writes client, account and transfer events to the agent-client log
+type AuditLogSubscriber struct {
+	LogService         *agentclient_logs.AgentClientLogService
+	AgentClientService interfaces.AgentClientServiceInterface
+	Logger             *slog.Logger
 }
 
-// ClientObserver listens for client-related changes and notifies the AgentClientLogService
-type ClientObserver struct {
-	LogService *agentclient_logs.AgentClientLogService
+// clientDiffer and accountDiffer decide what of a client or account reaches the audit log:
+// a phone number keeps only its last 4 digits, a date of birth is hidden, and is_active, which
+// follows deletion rather than anything an agent edits, is left out
+var (
+	clientDiffer = NewDiffer(clientFields, DiffRules{
+		Mask: map[string]Masker{"phone": MaskLast(4), "dob": MaskAll},
+	})
+	accountDiffer = NewDiffer(accountFields, DiffRules{
+		Ignore: []string{"is_active"},
+	})
+)
+
+// clientFields lists a client's fields under their JSON names
+func clientFields(c models.Client) Object {
+	return Object{
+		{"client_id", c.ClientID},
+		{"first_name", c.FirstName},
+		{"last_name", c.LastName},
+		{"dob", c.DOB},
+		{"gender", c.Gender},
+		{"email", c.Email},
+		{"phone", c.Phone},
+		{"address", c.Address},
+		{"city", c.City},
+		{"state", c.State},
+		{"country", c.Country},
+		{"postal_code", c.PostalCode},
+		{"verification_status", c.VerificationStatus},
+	}
 }
 
-func (co *ClientObserver) NotifyCreate(agentID int, clientID string, object interface{}) {
-	fmt.Println("Observer: Notifying client creation for client ID:", clientID)
-	client := object.(*models.Client) // Use client type from the client package
-	// Call the AgentClientLogService to create the log for client creation
-	co.LogService.LogAgentClientAction(agentID, clientID, "Create", map[string]interface{}{"details": client})
+// accountFields lists an account's fields under their JSON names
+func accountFields(a models.Account) Object {
+	return Object{
+		{"account_id", a.AccountID},
+		{"client_id", a.ClientID},
+		{"account_type", a.AccountType},
+		{"account_status", a.AccountStatus},
+		{"opening_date", a.OpeningDate},
+		{"initial_deposit", a.InitialDeposit},
+		{"currency", a.Currency},
+		{"branch_id", a.BranchID},
+		{"is_active", a.IsActive},
+		{"status_reason", a.StatusReason},
+	}
 }
 
-func (co *ClientObserver) NotifyUpdate(agentID int, clientID string, before, after interface{}) {
-	fmt.Println("Observer: Notifying client update for client ID:", clientID)
-	beforeClient := before.(*models.Client) // Use client type from the client package
-	afterClient := after.(*models.Client)   // Use client type from the client package
-	// Prepare the modified fields (before and after comparison)
-	changes := Compare(beforeClient, afterClient)
-	// Call the AgentClientLogService to create the log for client update
-	co.LogService.LogAgentClientAction(agentID, clientID, "Update", map[string]interface{}{"details": changes})
+// Register subscribes the audit log to the events it records
+func (s *AuditLogSubscriber) Register(dispatcher *events.Dispatcher) {
+	dispatcher.Subscribe(AuditSubscriber, s.Handle,
+		events.TypeClientCreated, events.TypeClientUpdated, events.TypeClientDeleted,
+		events.TypeAccountCreated, events.TypeAccountUpdated, events.TypeAccountDeleted,
+		events.TypeTransferCompleted,
+	)
 }
 
-func (co *ClientObserver) NotifyDelete(agentID int, clientID string, object interface{}) {
-	fmt.Println("Observer: Notifying client delete for client ID:", clientID)
-	client := object.(*models.Client) // Use client type from the client package
-	// Call the AgentClientLogService to create the log for client deletion
-	co.LogService.LogAgentClientAction(agentID, clientID, "Delete", map[string]interface{}{"details": client})
+// Handle logs one event under the agent that caused it. Events are delivered at least once, so
+// each log records the event ID and a redelivered event is not logged again.
+func (s *AuditLogSubscriber) Handle(ctx context.Context, event events.Event, meta models.EventMeta) error {
+	s.Logger.DebugContext(ctx, "audit log: recording event", "event_type", event.Type(), "client_id", event.ClientID())
+	source := models.LogSource{EventID: meta.EventID}
+
+	switch e := event.(type) {
+	case events.ClientCreated:
+		fields := snapshotFields(clientDiffer, e.Client)
+		return s.LogService.LogClientEvent(ctx, source, meta.AgentID, e.Client.ClientID, "Create", fields)
+	case events.ClientUpdated:
+		fields := diffFields(clientDiffer, e.Before, e.After)
+		return s.LogService.LogClientEvent(ctx, source, meta.AgentID, e.After.ClientID, revertAction(fields, e.RevertOf), fields)
+	case events.ClientDeleted:
+		fields := snapshotFields(clientDiffer, e.Client)
+		return s.LogService.LogClientEvent(ctx, source, meta.AgentID, e.Client.ClientID, "Delete", fields)
+	case events.AccountCreated:
+		fields := snapshotFields(accountDiffer, e.Account)
+		return s.LogService.LogAccountEvent(ctx, source, meta.AgentID, e.Account.ClientID, "Create", fields)
+	case events.AccountUpdated:
+		fields := diffFields(accountDiffer, e.Before, e.After)
+		// The account ID is kept beside the diff so the change can be reverted
+		fields[agentclient_logs.AccountIDField] = e.After.AccountID
+		return s.LogService.LogAccountEvent(ctx, source, meta.AgentID, e.After.ClientID, revertAction(fields, e.RevertOf), fields)
+	case events.AccountDeleted:
+		fields := snapshotFields(accountDiffer, e.Account)
+		return s.LogService.LogAccountEvent(ctx, source, meta.AgentID, e.Account.ClientID, "Delete", fields)
+	case events.TransferCompleted:
+		return s.logTransfer(ctx, meta.EventID, e.Transfer)
+	}
+	return fmt.Errorf("audit log: unexpected event %s", event.Type())
 }
 
-// AccountObserver listens for account-related changes and notifies the AgentClientLogService
-type AccountObserver struct {
-	LogService *agentclient_logs.AgentClientLogService
+// snapshotFields is the modified fields of a Create or Delete: the value as the differ lets it
+// be logged, and which of its fields are masked
+func snapshotFields[T any](differ *Differ[T], value T) map[string]interface{} {
+	details, masked := differ.Snapshot(value)
+	fields := map[string]interface{}{"details": details}
+	if len(masked) > 0 {
+		fields[agentclient_logs.MaskedField] = masked
+	}
+	return fields
 }
 
-func (ao *AccountObserver) NotifyCreate(agentID int, clientID string, object interface{}) {
-	fmt.Println("Observer: Notifying account creation for client ID:", clientID)
-	account := object.(*models.Account) // Use account type from the account package
-	// Call the AgentClientLogService to create the log for account creation
-	ao.LogService.LogAccountChange(agentID, clientID, "Create", map[string]interface{}{"details": account})
+// diffFields is the modified fields of an Update: the changes by JSON path, and which of them
+// are masked
+func diffFields[T any](differ *Differ[T], before, after T) map[string]interface{} {
+	diff := differ.Diff(before, after)
+	fields := map[string]interface{}{"details": diff.Changes}
+	if len(diff.Masked) > 0 {
+		fields[agentclient_logs.MaskedField] = diff.Masked
+	}
+	return fields
 }
 
-func (ao *AccountObserver) NotifyUpdate(agentID int, clientID string, before, after interface{}) {
-	fmt.Println("Observer: Notifying account update for client ID:", clientID)
-	beforeAccount := before.(*models.Account) // Use account type from the account package
-	afterAccount := after.(*models.Account)   // Use account type from the account package
-	// Prepare the modified fields (before and after comparison)
-	changes := Compare(beforeAccount, afterAccount)
-	// Call the AgentClientLogService to create the log for account update
-	ao.LogService.LogAccountChange(agentID, clientID, "Update", map[string]interface{}{"details": changes})
+// revertAction is the action an update is logged under: a change that reverts an earlier one
+// is a Revert linked to that entry
+func revertAction(fields map[string]interface{}, revertOf int) string {
+	if revertOf == 0 {
+		return "Update"
+	}
+	fields[agentclient_logs.RevertsField] = revertOf
+	return agentclient_logs.RevertAction
 }
 
-func (ao *AccountObserver) NotifyDelete(agentID int, clientID string, object interface{}) {
-	fmt.Println("Observer: Notifying account delete for client ID:", clientID)
-	account := object.(*models.Account) // Use account type from the account package
-	// Call the AgentClientLogService to create the log for account deletion
-	ao.LogService.LogAccountChange(agentID, clientID, "Delete", map[string]interface{}{"details": account})
+// logTransfer records the transfer against both clients, each under their own agent. Both
+// agents are looked up first so a lookup failure retries without logging either side, and each
+// side is logged once per event so a retry after logging only one side completes the other.
+func (s *AuditLogSubscriber) logTransfer(ctx context.Context, eventID int64, transfer models.Transfer) error {
+	sides := []struct {
+		clientID string
+		action   string
+		side     string
+		agentID  int
+	}{
+		{clientID: transfer.FromClientID, action: "Transfer Out", side: "from"},
+		{clientID: transfer.ToClientID, action: "Transfer In", side: "to"},
+	}
+	for i := range sides {
+		agentID, err := s.AgentClientService.GetAgentIDByClientID(sides[i].clientID)
+		if err != nil {
+			return fmt.Errorf("failed to find agent of client %s for transfer %d: %v", sides[i].clientID, transfer.ID, err)
+		}
+		sides[i].agentID = agentID
+	}
+
+	for _, side := range sides {
+		source := models.LogSource{EventID: eventID, Side: side.side}
+		err := s.LogService.LogAccountEvent(ctx, source, side.agentID, side.clientID, side.action, map[string]interface{}{"details": transfer})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
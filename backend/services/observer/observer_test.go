@@ -0,0 +1,176 @@
+package observer
+
+import (
+	"backend/database/memstore"
+	"backend/models"
+	"backend/services/agentClient"
+	"backend/services/agentclient_logs"
+	"backend/services/client"
+	"backend/services/events"
+	"backend/services/listquery"
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type auditFixture struct {
+	store      *memstore.Store
+	clients    *client.ClientService
+	logs       *agentclient_logs.AgentClientLogService
+	dispatcher *events.Dispatcher
+	subscriber *AuditLogSubscriber
+}
+
+func newAuditFixture(t *testing.T) auditFixture {
+	t.Helper()
+	store := memstore.New()
+	store.SeedUser(models.User{ID: 1, Role: "Agent"})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	agentClientService := agentClient.NewAgentClientService(agentClient.NewMemoryAgentClientRepository(store))
+	logService := agentclient_logs.NewAgentClientLogService(agentclient_logs.NewMemoryAgentClientLogRepository(store))
+	logService.AgentClientService = agentClientService
+
+	dispatcher := events.NewDispatcher(events.NewMemoryOutboxRepository(store), events.DispatcherConfig{
+		BatchSize:   10,
+		MaxAttempts: 3,
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+		Lease:       time.Minute,
+	}, logger)
+	subscriber := &AuditLogSubscriber{LogService: logService, AgentClientService: agentClientService, Logger: logger}
+	subscriber.Register(dispatcher)
+
+	return auditFixture{
+		store:      store,
+		clients:    client.NewClientService(client.NewMemoryClientRepository(store)),
+		logs:       logService,
+		dispatcher: dispatcher,
+		subscriber: subscriber,
+	}
+}
+
+func (f auditFixture) dispatch(t *testing.T) {
+	t.Helper()
+	if _, err := f.dispatcher.DispatchOnce(context.Background()); err != nil {
+		t.Fatalf("DispatchOnce: %v", err)
+	}
+}
+
+func (f auditFixture) clientLogs(t *testing.T, clientID string) []models.AgentClientLog {
+	t.Helper()
+	logs, err := f.logs.LogsAfter(0, 100, listquery.Condition{Column: "client_id", Op: "=", Value: clientID})
+	if err != nil {
+		t.Fatalf("LogsAfter: %v", err)
+	}
+	return logs
+}
+
+func TestAuditLogRecordsClientChanges(t *testing.T) {
+	f := newAuditFixture(t)
+	ctx := context.Background()
+
+	created, err := f.clients.CreateClient(ctx, models.Client{
+		FirstName: "Jane", LastName: "Tan", DOB: "1990-04-12", Gender: "Female",
+		Email: "jane.tan@example.com", Phone: "+6591234567", Address: "12 Orchard Road",
+		City: "Singapore", State: "Central", Country: "Singapore", PostalCode: "238801",
+	}, 1)
+	if err != nil {
+		t.Fatalf("CreateClient: %v", err)
+	}
+	if logs := f.clientLogs(t, created.ClientID); len(logs) != 0 {
+		t.Fatalf("logged before the event was delivered: %v", logs)
+	}
+	f.dispatch(t)
+
+	changed := created
+	changed.City = "Jurong East"
+	changed.Phone = "+6599998888"
+	if _, err := f.clients.UpdateClient(ctx, changed, 1); err != nil {
+		t.Fatalf("UpdateClient: %v", err)
+	}
+	f.dispatch(t)
+
+	logs := f.clientLogs(t, created.ClientID)
+	if len(logs) != 2 {
+		t.Fatalf("got %d logs, want Create and Update: %v", len(logs), logs)
+	}
+
+	create := logs[0]
+	if create.Action != "Create" || create.AgentID != 1 {
+		t.Errorf("first log = %s by %d, want Create by agent 1", create.Action, create.AgentID)
+	}
+	details, _ := create.ModifiedFields["details"].(map[string]interface{})
+	if details["phone"] != "****4567" || details["dob"] != "****" || details["city"] != "Singapore" {
+		t.Errorf("Create details = %v, want phone and dob masked", details)
+	}
+
+	update := logs[1]
+	changes, _ := update.ModifiedFields["details"].(map[string]interface{})
+	if update.Action != "Update" || len(changes) != 2 {
+		t.Fatalf("second log = %s with %v, want an Update of city and phone", update.Action, changes)
+	}
+	city, _ := changes["city"].(map[string]interface{})
+	if city["before"] != "Singapore" || city["after"] != "Jurong East" {
+		t.Errorf("city change = %v", city)
+	}
+	phone, _ := changes["phone"].(map[string]interface{})
+	if phone["before"] != "****4567" || phone["after"] != "****8888" {
+		t.Errorf("phone change = %v, want masked values", phone)
+	}
+}
+
+func TestAuditLogSkipsRedeliveredEvents(t *testing.T) {
+	f := newAuditFixture(t)
+	ctx := context.Background()
+	assign := func(clientID string) {
+		agentID := 1
+		f.store.AgentClients[clientID] = &agentID
+	}
+	assign("client1")
+	assign("client2")
+
+	created := events.ClientCreated{Client: models.Client{ClientID: "client1", City: "Singapore"}}
+	for i := 0; i < 2; i++ {
+		if err := f.subscriber.Handle(ctx, created, models.EventMeta{AgentID: 1, EventID: 7}); err != nil {
+			t.Fatalf("Handle ClientCreated: %v", err)
+		}
+	}
+	if logs := f.clientLogs(t, "client1"); len(logs) != 1 {
+		t.Fatalf("redelivered ClientCreated logged %d times", len(logs))
+	}
+
+	// A delivery that failed after logging the sender's side completes only the recipient's
+	transfer := models.Transfer{ID: 3, FromClientID: "client1", ToClientID: "client2", Currency: "SGD"}
+	outSide := models.LogSource{EventID: 8, Side: "from"}
+	if err := f.logs.LogAccountEvent(ctx, outSide, 1, "client1", "Transfer Out", map[string]interface{}{"details": transfer}); err != nil {
+		t.Fatalf("LogAccountEvent: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := f.subscriber.Handle(ctx, events.TransferCompleted{Transfer: transfer}, models.EventMeta{EventID: 8}); err != nil {
+			t.Fatalf("Handle TransferCompleted: %v", err)
+		}
+	}
+
+	for clientID, want := range map[string]string{"client1": "Transfer Out", "client2": "Transfer In"} {
+		var actions []string
+		for _, log := range f.clientLogs(t, clientID) {
+			if log.Action != "Create" {
+				actions = append(actions, log.Action)
+			}
+		}
+		if len(actions) != 1 || actions[0] != want {
+			t.Errorf("%s transfer logs = %v, want one %s", clientID, actions, want)
+		}
+	}
+
+	// A different event with the same content is logged
+	if err := f.subscriber.Handle(ctx, created, models.EventMeta{AgentID: 1, EventID: 9}); err != nil {
+		t.Fatalf("Handle ClientCreated: %v", err)
+	}
+	if logs := f.clientLogs(t, "client1"); len(logs) != 3 {
+		t.Errorf("client1 has %d logs after a new event, want 3", len(logs))
+	}
+}
@@ -0,0 +1,136 @@
+package retention
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// requireAdmin writes 403 and reports false unless the caller is an Admin
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	userCtx := r.Context().Value("user").(map[string]interface{})
+	if userCtx["role"].(string) != "Admin" {
+		http.Error(w, "Unauthorized: only Admin can manage log retention", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// ListArchivesHandler restricts to Admin
+func ListArchivesHandler(archiver *Archiver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(w, r) {
+			return
+		}
+
+		archives, err := archiver.ListArchives()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(archives)
+	}
+}
+
+// RestoreArchiveHandler restricts to Admin. It answers with the archived logs after checking
+// them against what was purged; the logs are not put back in the live tables.
+func RestoreArchiveHandler(archiver *Archiver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(w, r) {
+			return
+		}
+
+		archiveID, err := strconv.ParseInt(mux.Vars(r)["archive_id"], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid archive ID", http.StatusBadRequest)
+			return
+		}
+
+		restored, err := archiver.Restore(archiveID)
+		switch {
+		case errors.Is(err, ErrArchiveNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, ErrArchiveTampered):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(restored)
+	}
+}
+
+// ListHoldsHandler restricts to Admin
+func ListHoldsHandler(archiver *Archiver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(w, r) {
+			return
+		}
+
+		holds, err := archiver.ListHolds()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(holds)
+	}
+}
+
+// PlaceHoldHandler restricts to Admin. Placing a hold on a client already under one replaces its reason.
+func PlaceHoldHandler(archiver *Archiver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(w, r) {
+			return
+		}
+
+		var body struct {
+			Reason string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		userCtx := r.Context().Value("user").(map[string]interface{})
+		hold, err := archiver.PlaceHold(mux.Vars(r)["clientId"], body.Reason, userCtx["id"].(int))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(hold)
+	}
+}
+
+// ReleaseHoldHandler restricts to Admin
+func ReleaseHoldHandler(archiver *Archiver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(w, r) {
+			return
+		}
+
+		if err := archiver.ReleaseHold(mux.Vars(r)["clientId"]); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, ErrHoldNotFound) {
+				status = http.StatusNotFound
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Legal hold released"})
+	}
+}
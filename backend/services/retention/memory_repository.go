@@ -0,0 +1,241 @@
+package retention
+
+import (
+	"backend/database/memstore"
+	"backend/models"
+	"backend/services/interfaces"
+	"sort"
+	"time"
+)
+
+var _ interfaces.RetentionRepositoryInterface = (*MemoryRetentionRepository)(nil)
+
+// MemoryRetentionRepository is an in-memory implementation of interfaces.RetentionRepositoryInterface
+type MemoryRetentionRepository struct {
+	store *memstore.Store
+}
+
+// NewMemoryRetentionRepository initializes a new MemoryRetentionRepository on the given store
+func NewMemoryRetentionRepository(store *memstore.Store) *MemoryRetentionRepository {
+	return &MemoryRetentionRepository{store: store}
+}
+
+// ExpiredAgentClientLogs lists sealed logs of a log_type written before cutoff, oldest first,
+// skipping clients under legal hold
+func (r *MemoryRetentionRepository) ExpiredAgentClientLogs(logType string, cutoff string, limit int) ([]models.AgentClientLog, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	var logs []models.AgentClientLog
+	for _, log := range r.store.AgentClientLogs {
+		if len(logs) == limit {
+			break
+		}
+		if log.ModifiedFields["log_type"] != logType || log.Timestamp >= cutoff || log.Hash == "" {
+			continue
+		}
+		if _, held := r.store.LegalHolds[log.ClientID]; held {
+			continue
+		}
+		logs = append(logs, log)
+	}
+	return logs, nil
+}
+
+// ExpiredCommunicationLogs lists communication logs written before cutoff, oldest first,
+// skipping clients under legal hold
+func (r *MemoryRetentionRepository) ExpiredCommunicationLogs(cutoff string, limit int) ([]models.CommunicationLog, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	var logs []models.CommunicationLog
+	for _, log := range r.store.CommunicationLogs {
+		if len(logs) == limit {
+			break
+		}
+		if log.Timestamp >= cutoff {
+			continue
+		}
+		if _, held := r.store.LegalHolds[log.ClientID]; held {
+			continue
+		}
+		logs = append(logs, log)
+	}
+	return logs, nil
+}
+
+// PurgeAgentClientLogs records the archive and replaces the logs with hash chain tombstones
+func (r *MemoryRetentionRepository) PurgeAgentClientLogs(archive models.LogArchive, logs []models.AgentClientLog) (models.LogArchive, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	archived := make(map[int]models.AgentClientLog, len(logs))
+	for _, log := range logs {
+		archived[log.ID] = log
+	}
+	var kept []models.AgentClientLog
+	var purged []models.AgentClientLog
+	for _, log := range r.store.AgentClientLogs {
+		archivedLog, ok := archived[log.ID]
+		if !ok {
+			kept = append(kept, log)
+			continue
+		}
+		if _, held := r.store.LegalHolds[log.ClientID]; held {
+			return models.LogArchive{}, ErrBatchChanged
+		}
+		// Redacted, or otherwise changed, since it was archived
+		if log.ContentHash != archivedLog.ContentHash || !sameRedaction(log.RedactedBy, archivedLog.RedactedBy) {
+			return models.LogArchive{}, ErrBatchChanged
+		}
+		purged = append(purged, log)
+	}
+	if len(purged) != len(logs) {
+		return models.LogArchive{}, ErrBatchChanged
+	}
+
+	archive = r.recordLocked(archive)
+	for _, log := range purged {
+		r.store.LogTombstones = append(r.store.LogTombstones, models.LogTombstone{
+			LogID:       log.ID,
+			ArchiveID:   archive.ID,
+			ContentHash: log.ContentHash,
+			PrevHash:    log.PrevHash,
+			Hash:        log.Hash,
+		})
+	}
+	r.store.AgentClientLogs = kept
+	return archive, nil
+}
+
+// PurgeCommunicationLogs records the archive and deletes the logs
+func (r *MemoryRetentionRepository) PurgeCommunicationLogs(archive models.LogArchive, logIDs []int) (models.LogArchive, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	purge := idSet(logIDs)
+	var kept []models.CommunicationLog
+	purged := 0
+	for _, log := range r.store.CommunicationLogs {
+		if !purge[log.ID] {
+			kept = append(kept, log)
+			continue
+		}
+		if _, held := r.store.LegalHolds[log.ClientID]; held {
+			return models.LogArchive{}, ErrBatchChanged
+		}
+		purged++
+	}
+	if purged != len(logIDs) {
+		return models.LogArchive{}, ErrBatchChanged
+	}
+
+	archive = r.recordLocked(archive)
+	r.store.CommunicationLogs = kept
+	return archive, nil
+}
+
+// recordLocked stores an archive record. The caller must hold store.Mu.
+func (r *MemoryRetentionRepository) recordLocked(archive models.LogArchive) models.LogArchive {
+	archive.ID = r.store.NextLogArchiveID
+	archive.CreatedAt = time.Now().UTC().Format("2006-01-02 15:04:05")
+	r.store.NextLogArchiveID++
+	r.store.LogArchives = append(r.store.LogArchives, archive)
+	return archive
+}
+
+func idSet(ids []int) map[int]bool {
+	set := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// GetArchive retrieves an archive record
+func (r *MemoryRetentionRepository) GetArchive(archiveID int64) (models.LogArchive, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	for _, archive := range r.store.LogArchives {
+		if archive.ID == archiveID {
+			return archive, nil
+		}
+	}
+	return models.LogArchive{}, ErrArchiveNotFound
+}
+
+// ListArchives lists every archive, newest first
+func (r *MemoryRetentionRepository) ListArchives() ([]models.LogArchive, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	archives := make([]models.LogArchive, 0, len(r.store.LogArchives))
+	for i := len(r.store.LogArchives) - 1; i >= 0; i-- {
+		archives = append(archives, r.store.LogArchives[i])
+	}
+	return archives, nil
+}
+
+// ListTombstones lists the tombstones left by an archive, in log order
+func (r *MemoryRetentionRepository) ListTombstones(archiveID int64) ([]models.LogTombstone, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	var tombstones []models.LogTombstone
+	for _, tombstone := range r.store.LogTombstones {
+		if tombstone.ArchiveID == archiveID {
+			tombstones = append(tombstones, tombstone)
+		}
+	}
+	sort.Slice(tombstones, func(i, j int) bool { return tombstones[i].LogID < tombstones[j].LogID })
+	return tombstones, nil
+}
+
+// PlaceHold puts a client under legal hold, replacing the reason of an existing hold
+func (r *MemoryRetentionRepository) PlaceHold(hold models.LegalHold) (models.LegalHold, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	hold.PlacedAt = time.Now().UTC().Format("2006-01-02 15:04:05")
+	r.store.LegalHolds[hold.ClientID] = hold
+	return hold, nil
+}
+
+// ReleaseHold lifts a client's legal hold
+func (r *MemoryRetentionRepository) ReleaseHold(clientID string) error {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	if _, ok := r.store.LegalHolds[clientID]; !ok {
+		return ErrHoldNotFound
+	}
+	delete(r.store.LegalHolds, clientID)
+	return nil
+}
+
+// ListHolds lists every legal hold
+func (r *MemoryRetentionRepository) ListHolds() ([]models.LegalHold, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	holds := make([]models.LegalHold, 0, len(r.store.LegalHolds))
+	for _, hold := range r.store.LegalHolds {
+		holds = append(holds, hold)
+	}
+	sort.Slice(holds, func(i, j int) bool {
+		if holds[i].PlacedAt != holds[j].PlacedAt {
+			return holds[i].PlacedAt < holds[j].PlacedAt
+		}
+		return holds[i].ClientID < holds[j].ClientID
+	})
+	return holds, nil
+}
+
+// sameRedaction reports whether two logs were redacted by the same entry, or both not at all
+func sameRedaction(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
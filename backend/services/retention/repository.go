@@ -0,0 +1,373 @@
+package retention
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/models"
+	"backend/services/agentclient_logs"
+	"backend/services/interfaces"
+	"backend/services/metrics"
+)
+
+var _ interfaces.RetentionRepositoryInterface = (*RetentionRepository)(nil)
+
+// RetentionRepository is the MySQL implementation of interfaces.RetentionRepositoryInterface
+type RetentionRepository struct {
+	db *sql.DB
+}
+
+// NewRetentionRepository initializes a new RetentionRepository
+func NewRetentionRepository(db *sql.DB) *RetentionRepository {
+	return &RetentionRepository{db: db}
+}
+
+const archiveQuery = `SELECT id, log_type, file_name, sha256, log_count, first_log_id, last_log_id, created_at FROM log_archives`
+
+// notHeld excludes logs of clients under legal hold
+const notHeld = `client_id NOT IN (SELECT client_id FROM legal_holds)`
+
+// ExpiredAgentClientLogs lists sealed logs of a log_type written before cutoff, oldest first,
+// skipping clients under legal hold
+func (r *RetentionRepository) ExpiredAgentClientLogs(logType string, cutoff string, limit int) ([]models.AgentClientLog, error) {
+	defer metrics.ObserveQuery("RetentionRepository", "ExpiredAgentClientLogs", time.Now())
+	query := `SELECT id, agent_id, client_id, action, modified_fields, timestamp, request_id, content_hash, prev_hash, hash, redacted_by
+		FROM agent_client_logs
+		WHERE ` + agentclient_logs.LogTypeColumn + ` = ? AND timestamp < ? AND hash <> '' AND ` + notHeld + `
+		ORDER BY id LIMIT ?`
+
+	rows, err := r.db.Query(query, logType, cutoff, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve expired logs: %v", err)
+	}
+	defer rows.Close()
+
+	var logs []models.AgentClientLog
+	for rows.Next() {
+		var log models.AgentClientLog
+		var modifiedFieldsJSON string
+		var redactedBy sql.NullInt64
+		err := rows.Scan(&log.ID, &log.AgentID, &log.ClientID, &log.Action, &modifiedFieldsJSON, &log.Timestamp, &log.RequestID,
+			&log.ContentHash, &log.PrevHash, &log.Hash, &redactedBy)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(modifiedFieldsJSON), &log.ModifiedFields); err != nil {
+			return nil, fmt.Errorf("failed to decode modified fields: %v", err)
+		}
+		if redactedBy.Valid {
+			id := int(redactedBy.Int64)
+			log.RedactedBy = &id
+		}
+		logs = append(logs, log)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating log rows: %v", err)
+	}
+	return logs, nil
+}
+
+// ExpiredCommunicationLogs lists communication logs written before cutoff, oldest first,
+// skipping clients under legal hold
+func (r *RetentionRepository) ExpiredCommunicationLogs(cutoff string, limit int) ([]models.CommunicationLog, error) {
+	defer metrics.ObserveQuery("RetentionRepository", "ExpiredCommunicationLogs", time.Now())
+	query := `SELECT id, log_id, client_id, agent_id, email_subject, email_status, timestamp, request_id
+		FROM communication_logs
+		WHERE timestamp < ? AND ` + notHeld + `
+		ORDER BY id LIMIT ?`
+
+	rows, err := r.db.Query(query, cutoff, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve expired communication logs: %v", err)
+	}
+	defer rows.Close()
+
+	var logs []models.CommunicationLog
+	for rows.Next() {
+		var log models.CommunicationLog
+		if err := rows.Scan(&log.ID, &log.LogID, &log.ClientID, &log.AgentID, &log.EmailSubject, &log.EmailStatus, &log.Timestamp, &log.RequestID); err != nil {
+			return nil, err
+		}
+		logs = append(logs, log)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating communication log rows: %v", err)
+	}
+	return logs, nil
+}
+
+// PurgeAgentClientLogs records the archive and replaces the logs with hash chain tombstones
+func (r *RetentionRepository) PurgeAgentClientLogs(archive models.LogArchive, logs []models.AgentClientLog) (models.LogArchive, error) {
+	defer metrics.ObserveQuery("RetentionRepository", "PurgeAgentClientLogs", time.Now())
+	logIDs := make([]int, len(logs))
+	for i, log := range logs {
+		logIDs[i] = log.ID
+	}
+
+	return r.purge(archive, logIDs, func(tx *sql.Tx, archiveID int64, in string, args []interface{}) error {
+		if err := lockUnchangedTx(tx, in, args, logs); err != nil {
+			return err
+		}
+
+		result, err := tx.Exec(`INSERT INTO agent_client_log_tombstones (log_id, archive_id, content_hash, prev_hash, hash)
+			SELECT id, ?, content_hash, prev_hash, hash FROM agent_client_logs WHERE id IN (`+in+`) AND `+notHeld,
+			append([]interface{}{archiveID}, args...)...)
+		if err := expectRows(result, err, len(logIDs)); err != nil {
+			return fmt.Errorf("failed to write tombstones: %v", err)
+		}
+
+		result, err = tx.Exec(`DELETE FROM agent_client_logs WHERE id IN (`+in+`)`, args...)
+		if err := expectRows(result, err, len(logIDs)); err != nil {
+			return fmt.Errorf("failed to purge logs: %v", err)
+		}
+		return nil
+	})
+}
+
+// lockUnchangedTx locks the logs being purged, so none can be redacted until the purge commits,
+// and returns ErrBatchChanged if any is gone or no longer matches its archived copy
+func lockUnchangedTx(tx *sql.Tx, in string, args []interface{}, logs []models.AgentClientLog) error {
+	rows, err := tx.Query(`SELECT id, content_hash, redacted_by FROM agent_client_logs WHERE id IN (`+in+`) FOR UPDATE`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to lock logs: %v", err)
+	}
+	defer rows.Close()
+
+	archived := make(map[int]models.AgentClientLog, len(logs))
+	for _, log := range logs {
+		archived[log.ID] = log
+	}
+	locked := 0
+	for rows.Next() {
+		var id int
+		var contentHash string
+		var redactedBy sql.NullInt64
+		if err := rows.Scan(&id, &contentHash, &redactedBy); err != nil {
+			return fmt.Errorf("failed to lock logs: %v", err)
+		}
+		var redactor *int
+		if redactedBy.Valid {
+			redactionID := int(redactedBy.Int64)
+			redactor = &redactionID
+		}
+		if log := archived[id]; contentHash != log.ContentHash || !sameRedaction(redactor, log.RedactedBy) {
+			return ErrBatchChanged
+		}
+		locked++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to lock logs: %v", err)
+	}
+	if locked != len(logs) {
+		return ErrBatchChanged
+	}
+	return nil
+}
+
+// PurgeCommunicationLogs records the archive and deletes the logs
+func (r *RetentionRepository) PurgeCommunicationLogs(archive models.LogArchive, logIDs []int) (models.LogArchive, error) {
+	defer metrics.ObserveQuery("RetentionRepository", "PurgeCommunicationLogs", time.Now())
+	return r.purge(archive, logIDs, func(tx *sql.Tx, archiveID int64, in string, args []interface{}) error {
+		result, err := tx.Exec(`DELETE FROM communication_logs WHERE id IN (`+in+`) AND `+notHeld, args...)
+		if err := expectRows(result, err, len(logIDs)); err != nil {
+			return fmt.Errorf("failed to purge communication logs: %v", err)
+		}
+		return nil
+	})
+}
+
+// purge inserts the archive record and runs remove in the same transaction. Holds are locked
+// first so one placed mid-purge either waits for the purge or is seen by it.
+func (r *RetentionRepository) purge(archive models.LogArchive, logIDs []int, remove func(tx *sql.Tx, archiveID int64, in string, args []interface{}) error) (models.LogArchive, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.LogArchive{}, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	holds, err := tx.Query(`SELECT client_id FROM legal_holds FOR SHARE`)
+	if err != nil {
+		return models.LogArchive{}, fmt.Errorf("failed to lock legal holds: %v", err)
+	}
+	holds.Close()
+
+	result, err := tx.Exec(
+		`INSERT INTO log_archives (log_type, file_name, sha256, log_count, first_log_id, last_log_id) VALUES (?, ?, ?, ?, ?, ?)`,
+		archive.LogType, archive.FileName, archive.SHA256, archive.Count, archive.FirstLogID, archive.LastLogID,
+	)
+	if err != nil {
+		return models.LogArchive{}, fmt.Errorf("failed to record archive: %v", err)
+	}
+	archiveID, err := result.LastInsertId()
+	if err != nil {
+		return models.LogArchive{}, fmt.Errorf("failed to get archive ID: %v", err)
+	}
+
+	args := make([]interface{}, len(logIDs))
+	for i, id := range logIDs {
+		args[i] = id
+	}
+	in := strings.TrimSuffix(strings.Repeat("?, ", len(logIDs)), ", ")
+	if err := remove(tx, archiveID, in, args); err != nil {
+		return models.LogArchive{}, err
+	}
+
+	archived, err := scanArchive(tx.QueryRow(archiveQuery+` WHERE id = ?`, archiveID))
+	if err != nil {
+		return models.LogArchive{}, fmt.Errorf("failed to read archive: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return models.LogArchive{}, fmt.Errorf("failed to commit purge: %v", err)
+	}
+	return archived, nil
+}
+
+// expectRows turns a statement that touched fewer rows than expected into ErrBatchChanged
+func expectRows(result sql.Result, err error, expected int) error {
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if int(affected) != expected {
+		return ErrBatchChanged
+	}
+	return nil
+}
+
+// GetArchive retrieves an archive record
+func (r *RetentionRepository) GetArchive(archiveID int64) (models.LogArchive, error) {
+	defer metrics.ObserveQuery("RetentionRepository", "GetArchive", time.Now())
+	archive, err := scanArchive(r.db.QueryRow(archiveQuery+` WHERE id = ?`, archiveID))
+	if err == sql.ErrNoRows {
+		return models.LogArchive{}, ErrArchiveNotFound
+	}
+	if err != nil {
+		return models.LogArchive{}, fmt.Errorf("failed to retrieve archive: %v", err)
+	}
+	return archive, nil
+}
+
+// ListArchives lists every archive, newest first
+func (r *RetentionRepository) ListArchives() ([]models.LogArchive, error) {
+	defer metrics.ObserveQuery("RetentionRepository", "ListArchives", time.Now())
+	rows, err := r.db.Query(archiveQuery + ` ORDER BY id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve archives: %v", err)
+	}
+	defer rows.Close()
+
+	archives := []models.LogArchive{}
+	for rows.Next() {
+		archive, err := scanArchive(rows)
+		if err != nil {
+			return nil, err
+		}
+		archives = append(archives, archive)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating archive rows: %v", err)
+	}
+	return archives, nil
+}
+
+// ListTombstones lists the tombstones left by an archive, in log order
+func (r *RetentionRepository) ListTombstones(archiveID int64) ([]models.LogTombstone, error) {
+	defer metrics.ObserveQuery("RetentionRepository", "ListTombstones", time.Now())
+	rows, err := r.db.Query(`SELECT log_id, archive_id, content_hash, prev_hash, hash FROM agent_client_log_tombstones WHERE archive_id = ? ORDER BY log_id`, archiveID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve tombstones: %v", err)
+	}
+	defer rows.Close()
+
+	var tombstones []models.LogTombstone
+	for rows.Next() {
+		var tombstone models.LogTombstone
+		if err := rows.Scan(&tombstone.LogID, &tombstone.ArchiveID, &tombstone.ContentHash, &tombstone.PrevHash, &tombstone.Hash); err != nil {
+			return nil, err
+		}
+		tombstones = append(tombstones, tombstone)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tombstone rows: %v", err)
+	}
+	return tombstones, nil
+}
+
+// PlaceHold puts a client under legal hold, replacing the reason of an existing hold
+func (r *RetentionRepository) PlaceHold(hold models.LegalHold) (models.LegalHold, error) {
+	defer metrics.ObserveQuery("RetentionRepository", "PlaceHold", time.Now())
+	_, err := r.db.Exec(
+		`INSERT INTO legal_holds (client_id, reason, placed_by) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE reason = VALUES(reason), placed_by = VALUES(placed_by), placed_at = CURRENT_TIMESTAMP`,
+		hold.ClientID, hold.Reason, hold.PlacedBy,
+	)
+	if err != nil {
+		return models.LegalHold{}, fmt.Errorf("failed to place legal hold: %v", err)
+	}
+
+	err = r.db.QueryRow(`SELECT client_id, reason, placed_by, placed_at FROM legal_holds WHERE client_id = ?`, hold.ClientID).
+		Scan(&hold.ClientID, &hold.Reason, &hold.PlacedBy, &hold.PlacedAt)
+	if err != nil {
+		return models.LegalHold{}, fmt.Errorf("failed to read legal hold: %v", err)
+	}
+	return hold, nil
+}
+
+// ReleaseHold lifts a client's legal hold
+func (r *RetentionRepository) ReleaseHold(clientID string) error {
+	defer metrics.ObserveQuery("RetentionRepository", "ReleaseHold", time.Now())
+	result, err := r.db.Exec(`DELETE FROM legal_holds WHERE client_id = ?`, clientID)
+	if err != nil {
+		return fmt.Errorf("failed to release legal hold: %v", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrHoldNotFound
+	}
+	return nil
+}
+
+// ListHolds lists every legal hold
+func (r *RetentionRepository) ListHolds() ([]models.LegalHold, error) {
+	defer metrics.ObserveQuery("RetentionRepository", "ListHolds", time.Now())
+	rows, err := r.db.Query(`SELECT client_id, reason, placed_by, placed_at FROM legal_holds ORDER BY placed_at, client_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve legal holds: %v", err)
+	}
+	defer rows.Close()
+
+	holds := []models.LegalHold{}
+	for rows.Next() {
+		var hold models.LegalHold
+		if err := rows.Scan(&hold.ClientID, &hold.Reason, &hold.PlacedBy, &hold.PlacedAt); err != nil {
+			return nil, err
+		}
+		holds = append(holds, hold)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating legal hold rows: %v", err)
+	}
+	return holds, nil
+}
+
+// scanner is satisfied by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanArchive(row scanner) (models.LogArchive, error) {
+	var archive models.LogArchive
+	err := row.Scan(&archive.ID, &archive.LogType, &archive.FileName, &archive.SHA256, &archive.Count,
+		&archive.FirstLogID, &archive.LastLogID, &archive.CreatedAt)
+	return archive, err
+}
@@ -0,0 +1,405 @@
+package retention
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/database"
+	"backend/models"
+	"backend/services/interfaces"
+	"backend/services/metrics"
+)
+
+// Log types a retention policy can be set for
+const (
+	ClientLogs        = "client"
+	AccountLogs       = "bank_account"
+	CommunicationLogs = "communication"
+)
+
+// logTypes is the order the retention job works through the log types
+var logTypes = []string{ClientLogs, AccountLogs, CommunicationLogs}
+
+var (
+	ErrArchiveNotFound = errors.New("archive not found")
+	ErrArchiveTampered = errors.New("archive file does not match what was purged")
+	ErrHoldNotFound    = errors.New("client is not under legal hold")
+	// ErrBatchChanged means logs changed between being archived and purged; nothing was purged
+	// and the next run archives them again
+	ErrBatchChanged = errors.New("logs changed while being archived")
+)
+
+// Config controls the retention job
+type Config struct {
+	Interval  time.Duration
+	BatchSize int
+	// Dir is where archive files are written
+	Dir string
+	// Retention is how long each log type is kept; a type without a policy is kept forever
+	Retention map[string]time.Duration
+}
+
+// ConfigFromEnv reads RETENTION_CLIENT_LOGS, RETENTION_ACCOUNT_LOGS and
+// RETENTION_COMMUNICATION_LOGS (e.g. 2555d or 720h; unset keeps that type forever),
+// RETENTION_INTERVAL (default 24h) and ARCHIVE_DIR (default archives).
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Interval:  24 * time.Hour,
+		BatchSize: 1000,
+		Dir:       "archives",
+		Retention: make(map[string]time.Duration),
+	}
+
+	if raw := os.Getenv("RETENTION_INTERVAL"); raw != "" {
+		interval, err := time.ParseDuration(raw)
+		if err != nil || interval <= 0 {
+			return Config{}, fmt.Errorf("RETENTION_INTERVAL must be a positive duration such as 24h, got %q", raw)
+		}
+		cfg.Interval = interval
+	}
+
+	if dir := os.Getenv("ARCHIVE_DIR"); dir != "" {
+		cfg.Dir = dir
+	}
+
+	policies := map[string]string{
+		ClientLogs:        "RETENTION_CLIENT_LOGS",
+		AccountLogs:       "RETENTION_ACCOUNT_LOGS",
+		CommunicationLogs: "RETENTION_COMMUNICATION_LOGS",
+	}
+	for logType, name := range policies {
+		raw := os.Getenv(name)
+		if raw == "" {
+			continue
+		}
+		retention, err := parseRetention(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s must be a positive number of days such as 365d, or a duration such as 720h, got %q", name, raw)
+		}
+		cfg.Retention[logType] = retention
+	}
+
+	return cfg, nil
+}
+
+// parseRetention accepts a Go duration or a whole number of days ("90d")
+func parseRetention(raw string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid number of days %q", days)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	retention, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, err
+	}
+	if retention <= 0 {
+		return 0, fmt.Errorf("retention must be positive")
+	}
+	return retention, nil
+}
+
+// Archiver moves expired logs to compressed JSONL files and purges them. Logs of clients under
+// legal hold are never purged. Agent-client logs leave a tombstone so the hash chain still verifies.
+type Archiver struct {
+	repo   interfaces.RetentionRepositoryInterface
+	config Config
+	logger *slog.Logger
+
+	running sync.WaitGroup
+}
+
+// NewArchiver initializes an Archiver
+func NewArchiver(repo interfaces.RetentionRepositoryInterface, config Config, logger *slog.Logger) *Archiver {
+	return &Archiver{repo: repo, config: config, logger: logger}
+}
+
+// Start runs the retention job every Interval in the background until ctx is cancelled. The
+// batch in progress is finished first; use Wait to block until it is.
+func (a *Archiver) Start(ctx context.Context) {
+	if len(a.config.Retention) == 0 {
+		a.logger.Info("no log retention policy configured, keeping logs forever")
+		return
+	}
+
+	a.running.Add(1)
+	go func() {
+		defer a.running.Done()
+
+		ticker := time.NewTicker(a.config.Interval)
+		defer ticker.Stop()
+		for {
+			archived, err := a.RunOnce(ctx, time.Now())
+			if err != nil {
+				a.logger.Error("log retention run failed", "error", err)
+			} else if archived > 0 {
+				a.logger.Info("log retention run finished", "archived", archived)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Wait blocks until the retention job has stopped, or ctx expires
+func (a *Archiver) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		a.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("log retention still running: %v", ctx.Err())
+	}
+}
+
+// RunOnce archives and purges every log that had expired at now, a batch at a time, stopping
+// between batches if ctx is cancelled. It reports how many logs it purged.
+func (a *Archiver) RunOnce(ctx context.Context, now time.Time) (int, error) {
+	total := 0
+	for _, logType := range logTypes {
+		retention, ok := a.config.Retention[logType]
+		if !ok {
+			continue
+		}
+		// Log timestamps are in the database's zone, so the cutoff is too
+		cutoff := now.In(database.TimeZone).Add(-retention).Format("2006-01-02 15:04:05")
+
+		for ctx.Err() == nil {
+			archived, err := a.archiveBatch(logType, cutoff)
+			total += archived
+			if err != nil {
+				return total, fmt.Errorf("failed to archive %s logs: %v", logType, err)
+			}
+			if archived < a.config.BatchSize {
+				break
+			}
+		}
+	}
+	return total, nil
+}
+
+// archiveBatch writes one batch of expired logs to a file and purges them. If the purge fails
+// the file is removed, so every archive file on disk has a record.
+func (a *Archiver) archiveBatch(logType, cutoff string) (int, error) {
+	var rows []interface{}
+	var ids []int
+	var purge func(archive models.LogArchive) (models.LogArchive, error)
+	if logType == CommunicationLogs {
+		logs, err := a.repo.ExpiredCommunicationLogs(cutoff, a.config.BatchSize)
+		if err != nil {
+			return 0, err
+		}
+		for _, log := range logs {
+			rows = append(rows, log)
+			ids = append(ids, log.ID)
+		}
+		purge = func(archive models.LogArchive) (models.LogArchive, error) {
+			return a.repo.PurgeCommunicationLogs(archive, ids)
+		}
+	} else {
+		logs, err := a.repo.ExpiredAgentClientLogs(logType, cutoff, a.config.BatchSize)
+		if err != nil {
+			return 0, err
+		}
+		for _, log := range logs {
+			rows = append(rows, log)
+			ids = append(ids, log.ID)
+		}
+		// A log redacted after it was read would survive unredacted in the file, so the purge
+		// checks every log is still as archived
+		purge = func(archive models.LogArchive) (models.LogArchive, error) {
+			return a.repo.PurgeAgentClientLogs(archive, logs)
+		}
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	archive := models.LogArchive{
+		LogType:    logType,
+		FileName:   fmt.Sprintf("%s-%d-%d-%d.jsonl.gz", logType, ids[0], ids[len(ids)-1], time.Now().UnixNano()),
+		Count:      len(ids),
+		FirstLogID: ids[0],
+		LastLogID:  ids[len(ids)-1],
+	}
+	checksum, err := a.writeArchive(archive.FileName, rows)
+	if err != nil {
+		return 0, err
+	}
+	archive.SHA256 = checksum
+
+	recorded, err := purge(archive)
+	if err != nil {
+		os.Remove(filepath.Join(a.config.Dir, archive.FileName))
+		return 0, err
+	}
+
+	metrics.ObserveArchive(logType, recorded.Count)
+	a.logger.Info("archived expired logs", "archive_id", recorded.ID, "log_type", logType, "count", recorded.Count, "file", recorded.FileName)
+	return recorded.Count, nil
+}
+
+// writeArchive writes rows as gzipped JSON lines, syncs the file and returns its SHA-256. The
+// file only appears under its final name once it is complete.
+func (a *Archiver) writeArchive(name string, rows []interface{}) (string, error) {
+	if err := os.MkdirAll(a.config.Dir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create archive directory: %v", err)
+	}
+
+	file, err := os.CreateTemp(a.config.Dir, name+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to create archive file: %v", err)
+	}
+	defer os.Remove(file.Name()) // no-op once renamed
+	defer file.Close()
+
+	hash := sha256.New()
+	compressed := gzip.NewWriter(io.MultiWriter(file, hash))
+	encoder := json.NewEncoder(compressed)
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			return "", fmt.Errorf("failed to write archive: %v", err)
+		}
+	}
+	if err := compressed.Close(); err != nil {
+		return "", fmt.Errorf("failed to write archive: %v", err)
+	}
+	if err := file.Sync(); err != nil {
+		return "", fmt.Errorf("failed to sync archive: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to close archive: %v", err)
+	}
+	if err := os.Rename(file.Name(), filepath.Join(a.config.Dir, name)); err != nil {
+		return "", fmt.Errorf("failed to name archive: %v", err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ListArchives lists every archive, newest first
+func (a *Archiver) ListArchives() ([]models.LogArchive, error) {
+	return a.repo.ListArchives()
+}
+
+// Restore reads an archive back for investigation. The file must match the checksum recorded
+// when it was purged, and agent-client logs must match the tombstones they left in the chain.
+func (a *Archiver) Restore(archiveID int64) (models.RestoredArchive, error) {
+	archive, err := a.repo.GetArchive(archiveID)
+	if err != nil {
+		return models.RestoredArchive{}, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(a.config.Dir, filepath.Base(archive.FileName)))
+	if err != nil {
+		return models.RestoredArchive{}, fmt.Errorf("failed to read archive file: %v", err)
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != archive.SHA256 {
+		return models.RestoredArchive{}, ErrArchiveTampered
+	}
+
+	compressed, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return models.RestoredArchive{}, fmt.Errorf("failed to open archive: %v", err)
+	}
+	restored := models.RestoredArchive{Archive: archive}
+	lines := bufio.NewScanner(compressed)
+	lines.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for lines.Scan() {
+		if archive.LogType == CommunicationLogs {
+			var log models.CommunicationLog
+			if err := json.Unmarshal(lines.Bytes(), &log); err != nil {
+				return models.RestoredArchive{}, fmt.Errorf("failed to decode archive: %v", err)
+			}
+			restored.CommunicationLogs = append(restored.CommunicationLogs, log)
+		} else {
+			var log models.AgentClientLog
+			if err := json.Unmarshal(lines.Bytes(), &log); err != nil {
+				return models.RestoredArchive{}, fmt.Errorf("failed to decode archive: %v", err)
+			}
+			restored.AgentClientLogs = append(restored.AgentClientLogs, log)
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return models.RestoredArchive{}, fmt.Errorf("failed to read archive: %v", err)
+	}
+
+	count := len(restored.CommunicationLogs) + len(restored.AgentClientLogs)
+	if count != archive.Count {
+		return models.RestoredArchive{}, ErrArchiveTampered
+	}
+	if archive.LogType != CommunicationLogs {
+		if err := a.matchTombstones(archive.ID, restored.AgentClientLogs); err != nil {
+			return models.RestoredArchive{}, err
+		}
+	}
+	return restored, nil
+}
+
+// matchTombstones checks archived logs against the tombstones their purge left behind
+func (a *Archiver) matchTombstones(archiveID int64, logs []models.AgentClientLog) error {
+	tombstones, err := a.repo.ListTombstones(archiveID)
+	if err != nil {
+		return err
+	}
+	if len(tombstones) != len(logs) {
+		return ErrArchiveTampered
+	}
+
+	byID := make(map[int]models.LogTombstone, len(tombstones))
+	for _, tombstone := range tombstones {
+		byID[tombstone.LogID] = tombstone
+	}
+	for _, log := range logs {
+		tombstone, ok := byID[log.ID]
+		if !ok || tombstone.ContentHash != log.ContentHash || tombstone.PrevHash != log.PrevHash || tombstone.Hash != log.Hash {
+			return ErrArchiveTampered
+		}
+	}
+	return nil
+}
+
+// PlaceHold suspends purging of a client's logs until the hold is released
+func (a *Archiver) PlaceHold(clientID string, reason string, placedBy int) (models.LegalHold, error) {
+	if clientID == "" || reason == "" {
+		return models.LegalHold{}, fmt.Errorf("a client_id and reason are required to place a legal hold")
+	}
+	return a.repo.PlaceHold(models.LegalHold{ClientID: clientID, Reason: reason, PlacedBy: placedBy})
+}
+
+// ReleaseHold lets a client's logs be purged again once they expire
+func (a *Archiver) ReleaseHold(clientID string) error {
+	return a.repo.ReleaseHold(clientID)
+}
+
+// ListHolds lists every legal hold
+func (a *Archiver) ListHolds() ([]models.LegalHold, error) {
+	return a.repo.ListHolds()
+}
@@ -0,0 +1,87 @@
+package retention
+
+import (
+	"backend/database/memstore"
+	"backend/models"
+	"backend/services/agentclient_logs"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// redactingRepository redacts a log right after the archiver has read the batch holding it,
+// as an agent's redaction racing the retention job would
+type redactingRepository struct {
+	*MemoryRetentionRepository
+	logs   *agentclient_logs.MemoryAgentClientLogRepository
+	redact int
+}
+
+func (r *redactingRepository) ExpiredAgentClientLogs(logType string, cutoff string, limit int) ([]models.AgentClientLog, error) {
+	expired, err := r.MemoryRetentionRepository.ExpiredAgentClientLogs(logType, cutoff, limit)
+	if err != nil || r.redact == 0 {
+		return expired, err
+	}
+	if _, err := r.logs.RedactLog(r.redact, 1, "entered in error", ""); err != nil {
+		return nil, err
+	}
+	r.redact = 0
+	return expired, nil
+}
+
+func TestArchiveDiscardsBatchRedactedMidway(t *testing.T) {
+	store := memstore.New()
+	logs := agentclient_logs.NewMemoryAgentClientLogRepository(store)
+	for _, city := range []string{"Singapore", "Jurong East"} {
+		fields := map[string]interface{}{"details": map[string]interface{}{"city": city}}
+		if _, err := logs.CreateAgentClientLog(1, "client1", "Update", fields, models.EventMeta{AgentID: 1}, models.LogSource{}); err != nil {
+			t.Fatalf("CreateAgentClientLog: %v", err)
+		}
+	}
+
+	repo := &redactingRepository{MemoryRetentionRepository: NewMemoryRetentionRepository(store), logs: logs, redact: 1}
+	dir := t.TempDir()
+	archiver := NewArchiver(repo, Config{
+		BatchSize: 10,
+		Dir:       dir,
+		Retention: map[string]time.Duration{ClientLogs: time.Hour},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	later := time.Now().Add(2 * time.Hour)
+
+	// The first log is redacted after being archived: the batch is discarded, file and all
+	archived, err := archiver.RunOnce(context.Background(), later)
+	if err == nil || !strings.Contains(err.Error(), ErrBatchChanged.Error()) {
+		t.Fatalf("RunOnce = %d, %v; want ErrBatchChanged", archived, err)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("discarded batch left %d files", len(files))
+	}
+	if archives, _ := archiver.ListArchives(); len(archives) != 0 {
+		t.Errorf("discarded batch recorded archives: %v", archives)
+	}
+	if len(store.AgentClientLogs) != 3 || len(store.LogTombstones) != 0 {
+		t.Fatalf("discarded batch purged logs: %d left, %d tombstones", len(store.AgentClientLogs), len(store.LogTombstones))
+	}
+
+	// The next run archives the logs as redacted
+	archived, err = archiver.RunOnce(context.Background(), later)
+	if err != nil || archived != 2 {
+		t.Fatalf("RunOnce = %d, %v; want both client logs archived", archived, err)
+	}
+	archives, err := archiver.ListArchives()
+	if err != nil || len(archives) != 1 {
+		t.Fatalf("ListArchives = %v, %v", archives, err)
+	}
+	restored, err := archiver.Restore(archives[0].ID)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	redacted := restored.AgentClientLogs[0]
+	if redacted.RedactedBy == nil || redacted.ModifiedFields["details"] != nil {
+		t.Errorf("archived %v, want the log as redacted", redacted)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// camt053Parser reads ISO 20022 camt.053 bank-to-customer statements. Each entry (Ntry) is a
+// transaction: credits are deposits and debits withdrawals, with reversals swapping the two.
+// The account servicer's reference identifies an entry within the statement's account; entries
+// without one fall back to their transaction references or NtryRef.
+type camt053Parser struct{}
+
+func (camt053Parser) Name() string         { return "camt.053" }
+func (camt053Parser) Extensions() []string { return []string{".xml", ".camt", ".053"} }
+
+func (camt053Parser) Sniff(head []byte) bool {
+	return bytes.HasPrefix(head, []byte("<")) && bytes.Contains(head, []byte("BkToCstmrStmt"))
+}
+
+type camtDocument struct {
+	Statements []struct {
+		ID      string `xml:"Id"`
+		Account struct {
+			IBAN  string `xml:"IBAN"`
+			Other string `xml:"Othr>Id"`
+		} `xml:"Acct>Id"`
+		Entries []camtEntry `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtEntry struct {
+	NtryRef string `xml:"NtryRef"`
+	Amount  struct {
+		Value    string `xml:",chardata"`
+		Currency string `xml:"Ccy,attr"`
+	} `xml:"Amt"`
+	CreditDebit string `xml:"CdtDbtInd"`
+	Reversal    bool   `xml:"RvslInd"`
+	// camt.053.001.02 puts the code directly in Sts, later versions in Sts/Cd
+	Status struct {
+		Text string `xml:",chardata"`
+		Code string `xml:"Cd"`
+	} `xml:"Sts"`
+	BookingDate camtDate `xml:"BookgDt"`
+	ValueDate   camtDate `xml:"ValDt"`
+	AcctSvcrRef string   `xml:"AcctSvcrRef"`
+	Refs        []struct {
+		AcctSvcrRef string `xml:"AcctSvcrRef"`
+		EndToEndID  string `xml:"EndToEndId"`
+		TxID        string `xml:"TxId"`
+	} `xml:"NtryDtls>TxDtls>Refs"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+func (d camtDate) value() string {
+	if d.DateTime != "" {
+		return d.DateTime
+	}
+	return d.Date
+}
+
+// camtStatuses maps entry status codes to transaction statuses
+var camtStatuses = map[string]string{
+	"BOOK": "Completed",
+	"PDNG": "Pending",
+	"INFO": "Pending",
+	"FUTR": "Pending",
+}
+
+func (camt053Parser) Parse(r io.Reader, file SourceFile) (statement, error) {
+	var document camtDocument
+	if err := xml.NewDecoder(r).Decode(&document); err != nil {
+		return statement{}, fmt.Errorf("failed to parse camt.053: %v", err)
+	}
+	if len(document.Statements) == 0 {
+		return statement{}, fmt.Errorf("no statements found in camt.053 document")
+	}
+
+	parsed := statement{Header: normalisedHeader}
+	row := 0
+	for _, stmt := range document.Statements {
+		account := stmt.Account.IBAN
+		if account == "" {
+			account = stmt.Account.Other
+		}
+		source := statementSource("camt.053", account, file)
+
+		for _, entry := range stmt.Entries {
+			row++
+
+			candidates := []string{entry.AcctSvcrRef}
+			for _, refs := range entry.Refs {
+				candidates = append(candidates, refs.AcctSvcrRef, refs.TxID)
+			}
+			candidates = append(candidates, entry.NtryRef)
+
+			status := strings.TrimSpace(entry.Status.Code)
+			if status == "" {
+				status = strings.TrimSpace(entry.Status.Text)
+			}
+			if mapped, ok := camtStatuses[strings.ToUpper(status)]; ok {
+				status = mapped
+			}
+
+			date := entry.BookingDate.value()
+			if date == "" {
+				date = entry.ValueDate.value()
+			}
+
+			fields := statementFields{
+				ID:          firstReference(candidates),
+				ClientID:    file.ClientID,
+				Transaction: creditDebitType(strings.ToUpper(strings.TrimSpace(entry.CreditDebit)) == "CRDT", entry.Reversal),
+				Amount:      strings.TrimSpace(entry.Amount.Value),
+				Date:        strings.TrimSpace(date),
+				Status:      status,
+				Currency:    entry.Amount.Currency,
+			}
+			raw := fmt.Sprintf("Stmt %s Ntry %d (%s)", stmt.ID, row, entry.CreditDebit)
+			parsed.Rows = append(parsed.Rows, normaliseRow(row, source, fields, fields.record(raw)))
+		}
+	}
+
+	return parsed, nil
+}
+
+// firstReference returns the first usable reference; NONREF marks a reference as not given
+func firstReference(candidates []string) string {
+	for _, candidate := range candidates {
+		candidate = strings.TrimSpace(candidate)
+		if candidate != "" && !strings.EqualFold(candidate, "NONREF") {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// creditDebitType maps a statement's credit/debit indicator to a deposit or withdrawal
+func creditDebitType(credit, reversal bool) string {
+	if credit != reversal {
+		return "D"
+	}
+	return "W"
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// csvProfile maps a provider's CSV layout onto the transaction fields, so a new provider can
+// be onboarded by configuration. Profiles are loaded from the JSON file named by
+// CSV_PROFILES_PATH, e.g.
+//
+//	{"profiles": [{
+//	    "name": "partner-a",
+//	    "folder": "client1",
+//	    "prefix": "PA_",
+//	    "delimiter": ";",
+//	    "decimal_separator": ",",
+//	    "thousands_separator": ".",
+//	    "date_formats": ["02/01/2006 15:04", "02/01/2006"],
+//	    "columns": {"id": "Reference", "transaction": "Type", "amount": "Value", "date": "Booked", "status": "State"},
+//	    "transaction_codes": {"CR": "D", "DR": "W"},
+//	    "status_codes": {"OK": "Completed"}
+//	}]}
+//
+// Columns are matched by header name, case-insensitively, in any order. client_id, account_id
+// and currency are optional: without client_id, rows belong to the file's client directory.
+// Without a status column, default_status is used. Date formats use Go's reference time and
+// are tried before the standard formats.
+type csvProfile struct {
+	Name               string            `json:"name"`
+	Folder             string            `json:"folder"`
+	Prefix             string            `json:"prefix"`
+	Delimiter          string            `json:"delimiter"`
+	DecimalSeparator   string            `json:"decimal_separator"`
+	ThousandsSeparator string            `json:"thousands_separator"`
+	DateFormats        []string          `json:"date_formats"`
+	Columns            map[string]string `json:"columns"`
+	DefaultStatus      string            `json:"default_status"`
+	TransactionCodes   map[string]string `json:"transaction_codes"`
+	StatusCodes        map[string]string `json:"status_codes"`
+}
+
+// Fields a profile can map a column onto
+const (
+	columnID          = "id"
+	columnClientID    = "client_id"
+	columnTransaction = "transaction"
+	columnAmount      = "amount"
+	columnDate        = "date"
+	columnStatus      = "status"
+	columnAccountID   = "account_id"
+	columnCurrency    = "currency"
+)
+
+var profileColumns = []string{columnID, columnClientID, columnTransaction, columnAmount, columnDate, columnStatus, columnAccountID, columnCurrency}
+
+// defaultCSVProfile is the fetcher's own layout, used when no configured profile matches
+var defaultCSVProfile = csvProfile{
+	Name: "default",
+	Columns: map[string]string{
+		columnID:          "ID",
+		columnClientID:    "ClientID",
+		columnTransaction: "Transaction",
+		columnAmount:      "Amount",
+		columnDate:        "Date",
+		columnStatus:      "Status",
+		columnAccountID:   "AccountID",
+		columnCurrency:    "Currency",
+	},
+}
+
+// csvProfiles are the configured profiles, in matching order
+var csvProfiles []csvProfile
+
+// loadCSVProfiles reads and validates the profiles file
+func loadCSVProfiles(path string) ([]csvProfile, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV profiles: %v", err)
+	}
+
+	var config struct {
+		Profiles []csvProfile `json:"profiles"`
+	}
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse CSV profiles: %v", err)
+	}
+
+	for _, profile := range config.Profiles {
+		if err := profile.validate(); err != nil {
+			return nil, fmt.Errorf("CSV profile %q: %v", profile.Name, err)
+		}
+	}
+	return config.Profiles, nil
+}
+
+func (p csvProfile) validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if p.Delimiter != "" && utf8.RuneCountInString(p.Delimiter) != 1 {
+		return fmt.Errorf("delimiter must be a single character")
+	}
+	if p.DecimalSeparator != "" && p.DecimalSeparator != "." && p.DecimalSeparator != "," {
+		return fmt.Errorf("decimal_separator must be . or ,")
+	}
+	if p.ThousandsSeparator != "" && p.ThousandsSeparator == p.decimalSeparator() {
+		return fmt.Errorf("thousands_separator must differ from the decimal separator %q", p.decimalSeparator())
+	}
+
+	for field := range p.Columns {
+		if !contains(profileColumns, field) {
+			return fmt.Errorf("unknown column %q; expected one of %s", field, strings.Join(profileColumns, ", "))
+		}
+	}
+	for _, field := range []string{columnID, columnTransaction, columnAmount, columnDate} {
+		if p.Columns[field] == "" {
+			return fmt.Errorf("columns.%s is required", field)
+		}
+	}
+	if p.Columns[columnStatus] == "" && p.DefaultStatus == "" {
+		return fmt.Errorf("columns.status or default_status is required")
+	}
+	return nil
+}
+
+// matches reports whether the profile applies to a file. A profile with neither a folder
+// nor a prefix applies to every file.
+func (p csvProfile) matches(file SourceFile) bool {
+	if p.Folder != "" && p.Folder != file.ClientID {
+		return false
+	}
+	return p.Prefix == "" || strings.HasPrefix(file.Name, p.Prefix)
+}
+
+// source is what the IDs of the profile's files are unique within: a provider's own, or the
+// internal systems' for the default layout
+func (p csvProfile) source() string {
+	if p.Name == defaultCSVProfile.Name {
+		return sourceInternal
+	}
+	return "csv:" + p.Name
+}
+
+// profileFor returns the first configured profile matching the file, or the default layout
+func profileFor(file SourceFile) csvProfile {
+	for _, profile := range csvProfiles {
+		if profile.matches(file) {
+			return profile
+		}
+	}
+	return defaultCSVProfile
+}
+
+// parse reads a CSV file laid out as the profile describes
+func (p csvProfile) parse(r io.Reader, file SourceFile) (statement, error) {
+	// Create CSV reader
+	reader := csv.NewReader(r)
+	if p.Delimiter != "" {
+		reader.Comma, _ = utf8.DecodeRuneInString(p.Delimiter)
+	}
+
+	// Read header row
+	header, err := reader.Read()
+	if err != nil {
+		return statement{}, fmt.Errorf("failed to read CSV header: %v", err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	// Find each mapped column in the header
+	positions := make(map[string]int)
+	var missing []string
+	for _, field := range profileColumns {
+		name := p.Columns[field]
+		if name == "" {
+			continue
+		}
+		index := columnIndex(header, name)
+		if index < 0 {
+			optional := field == columnClientID || field == columnAccountID || field == columnCurrency || (field == columnStatus && p.DefaultStatus != "")
+			if !optional {
+				missing = append(missing, name)
+			}
+			continue
+		}
+		positions[field] = index
+	}
+	if len(missing) > 0 {
+		return statement{}, fmt.Errorf("CSV header %v is missing %s for profile %s", header, strings.Join(missing, ", "), p.Name)
+	}
+
+	value := func(record []string, field string) string {
+		index, ok := positions[field]
+		if !ok || index >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[index])
+	}
+
+	parsed := statement{Header: header}
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			parsed.Rows = append(parsed.Rows, statementRow{Row: row, Record: record, Reject: fmt.Sprintf("unreadable row: %v", err)})
+			continue
+		}
+
+		fields := statementFields{
+			ID:          value(record, columnID),
+			ClientID:    value(record, columnClientID),
+			Transaction: mapCode(p.TransactionCodes, value(record, columnTransaction)),
+			Amount:      p.normaliseAmount(value(record, columnAmount)),
+			Date:        p.normaliseDate(value(record, columnDate)),
+			Status:      mapCode(p.StatusCodes, value(record, columnStatus)),
+			AccountID:   value(record, columnAccountID),
+			Currency:    value(record, columnCurrency),
+		}
+		if fields.ClientID == "" {
+			fields.ClientID = file.ClientID
+		}
+		if fields.Status == "" {
+			fields.Status = p.DefaultStatus
+		}
+		parsed.Rows = append(parsed.Rows, normaliseRow(row, p.source(), fields, record))
+	}
+
+	return parsed, nil
+}
+
+// decimalSeparator is the profile's decimal separator, "." unless configured
+func (p csvProfile) decimalSeparator() string {
+	if p.DecimalSeparator == "" {
+		return "."
+	}
+	return p.DecimalSeparator
+}
+
+// normaliseAmount rewrites an amount with the profile's separators as "1234.56"
+func (p csvProfile) normaliseAmount(amount string) string {
+	if p.ThousandsSeparator != "" {
+		amount = strings.ReplaceAll(amount, p.ThousandsSeparator, "")
+	}
+	if p.decimalSeparator() == "," {
+		amount = strings.Replace(amount, ",", ".", 1)
+	}
+	return amount
+}
+
+// normaliseDate rewrites a date in one of the profile's formats as RFC 3339; dates in
+// none of them are left for the standard formats
+func (p csvProfile) normaliseDate(date string) string {
+	for _, format := range p.DateFormats {
+		if t, err := time.Parse(format, date); err == nil {
+			return t.Format(time.RFC3339)
+		}
+	}
+	return date
+}
+
+// mapCode translates a provider's code, leaving unmapped values as they are
+func mapCode(codes map[string]string, value string) string {
+	if mapped, ok := codes[value]; ok {
+		return mapped
+	}
+	return value
+}
+
+// contains reports whether a list holds a value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// loadCSVProfilesFromEnv loads the profiles named by CSV_PROFILES_PATH, if set
+func loadCSVProfilesFromEnv() error {
+	path := os.Getenv("CSV_PROFILES_PATH")
+	if path == "" {
+		return nil
+	}
+
+	profiles, err := loadCSVProfiles(path)
+	if err != nil {
+		return err
+	}
+	csvProfiles = profiles
+	log.Printf("Loaded %d CSV mapping profiles from %s", len(profiles), path)
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCSVProfileSeparators(t *testing.T) {
+	base := csvProfile{
+		Name:          "partner",
+		Columns:       map[string]string{columnID: "Ref", columnTransaction: "Type", columnAmount: "Value", columnDate: "Booked"},
+		DefaultStatus: "Completed",
+	}
+
+	tests := []struct {
+		name      string
+		decimal   string
+		thousands string
+		wantErr   string
+	}{
+		{name: "defaults"},
+		{name: "thousands comma with default decimal", thousands: ","},
+		{name: "european", decimal: ",", thousands: "."},
+		{name: "thousands dot with default decimal", thousands: ".", wantErr: "thousands_separator must differ"},
+		{name: "same separators", decimal: ",", thousands: ",", wantErr: "thousands_separator must differ"},
+		{name: "unknown decimal", decimal: ";", wantErr: "decimal_separator must be"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := base
+			profile.DecimalSeparator, profile.ThousandsSeparator = tt.decimal, tt.thousands
+
+			err := profile.validate()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("validate: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("validate error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCSVProfileNormaliseAmount(t *testing.T) {
+	tests := []struct {
+		decimal, thousands, amount, want string
+	}{
+		{"", "", "12.50", "12.50"},
+		{"", ",", "1,234.50", "1234.50"},
+		{",", ".", "1.234,50", "1234.50"},
+		{",", "", "12,50", "12.50"},
+	}
+	for _, tt := range tests {
+		profile := csvProfile{DecimalSeparator: tt.decimal, ThousandsSeparator: tt.thousands}
+		if got := profile.normaliseAmount(tt.amount); got != tt.want {
+			t.Errorf("normaliseAmount(%q) with %q/%q = %q, want %q", tt.amount, tt.decimal, tt.thousands, got, tt.want)
+		}
+	}
+}
+
+func TestCSVProfileParse(t *testing.T) {
+	profile := csvProfile{
+		Name:               "partner",
+		Delimiter:          ";",
+		DecimalSeparator:   ",",
+		ThousandsSeparator: ".",
+		DateFormats:        []string{"02/01/2006"},
+		Columns:            map[string]string{columnID: "Ref", columnTransaction: "Type", columnAmount: "Value", columnDate: "Booked"},
+		DefaultStatus:      "Completed",
+		TransactionCodes:   map[string]string{"CR": "D", "DR": "W"},
+	}
+	if err := profile.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	input := "Booked;Value;Type;Ref\n01/03/2025;1.234,50;CR;P-1\n"
+	parsed, err := profile.parse(strings.NewReader(input), SourceFile{ClientID: "client3", Name: "PA_1.csv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRows(t, parsed.Rows, []wantRow{
+		{row: 1, source: "csv:partner", ref: "P-1", clientID: "client3", transaction: "D", amountMinor: 123450,
+			date: day(2025, 3, 1), status: "Completed"},
+	})
+
+	if _, err := profile.parse(strings.NewReader("Booked;Value\n"), SourceFile{}); err == nil {
+		t.Error("parse accepted a header without the mapped columns")
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// storedTransaction is a transaction already in transaction_logs, in the fields a statement sets
+type storedTransaction struct {
+	ClientID    string
+	Transaction string
+	AmountMinor int64
+	Date        time.Time
+	Status      string
+}
+
+// findTransaction returns the stored transaction with the given source and reference, or nil if there is none
+func findTransaction(tx *sql.Tx, source, reference string) (*storedTransaction, error) {
+	var stored storedTransaction
+	err := tx.QueryRow(`
+		SELECT clientid, transaction_type, ROUND(amount * 100), transaction_date, status
+		FROM transaction_logs WHERE source = ? AND source_ref = ?`, source, reference).Scan(
+		&stored.ClientID, &stored.Transaction, &stored.AmountMinor, &stored.Date, &stored.Status)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up transaction %s from %s: %v", reference, source, err)
+	}
+	return &stored, nil
+}
+
+// matches reports whether a statement row describes the stored transaction, i.e. the row is
+// the same transaction delivered again
+func (s storedTransaction) matches(t TransactionLog, amountMinor int64) bool {
+	return s.ClientID == t.ClientID &&
+		strings.EqualFold(s.Transaction, t.Transaction) &&
+		s.AmountMinor == amountMinor &&
+		s.Date.Equal(t.Date) &&
+		strings.EqualFold(s.Status, t.Status)
+}
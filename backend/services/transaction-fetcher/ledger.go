@@ -0,0 +1,181 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The ledger tables (journal_entries, ledger_postings, ledger_balances) are created by the
+// backend's migrations. The fetcher posts to them directly so a file's transactions and
+// their ledger entries commit in the same database transaction.
+const (
+	ledgerSource       = "transaction_fetcher"
+	externalCashLedger = "external:cash"
+)
+
+type ledgerPosting struct {
+	entryID       int64
+	ledgerAccount string
+	accountID     *int
+	currency      string
+	amount        int64
+}
+
+// ledgerBatch holds a file's postings until the file is about to commit. Every file posts to
+// external:cash, so locking balances row by row would hold that lock for the whole file and
+// serialize the workers; applying the batch last holds balance locks only briefly.
+type ledgerBatch struct {
+	postings []ledgerPosting
+}
+
+// postToLedger records a completed deposit or withdrawal to the account the transaction is
+// linked to: the journal entry is written now and its postings are added to the batch.
+// It reports whether an entry was written: rows that are not completed, have an unknown
+// transaction type, have no linked account, or were already posted are skipped.
+func postToLedger(tx *sql.Tx, batch *ledgerBatch, id int, account *linkedAccount, clientID, transactionType string, amount int64, status string) (bool, error) {
+	if !strings.EqualFold(status, "Completed") {
+		return false, nil
+	}
+
+	var sign int64
+	switch strings.ToUpper(transactionType) {
+	case "D", "DEPOSIT":
+		sign = 1
+	case "W", "WITHDRAWAL":
+		sign = -1
+	default:
+		log.Printf("Not posting transaction %d: unknown transaction type %q", id, transactionType)
+		return false, nil
+	}
+
+	if amount == 0 {
+		return false, nil
+	}
+
+	if account == nil {
+		log.Printf("Not posting transaction %d: client %s has no active account", id, clientID)
+		return false, nil
+	}
+	accountID, currency := account.ID, account.Currency
+
+	result, err := tx.Exec(
+		`INSERT IGNORE INTO journal_entries (source, source_ref, description) VALUES (?, ?, ?)`,
+		ledgerSource, strconv.Itoa(id), fmt.Sprintf("%s %s", transactionType, clientID),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert journal entry: %v", err)
+	}
+	if inserted, _ := result.RowsAffected(); inserted == 0 {
+		// Already posted by an earlier ingest of the same transaction
+		return false, nil
+	}
+	entryID, err := result.LastInsertId()
+	if err != nil {
+		return false, fmt.Errorf("failed to get journal entry ID: %v", err)
+	}
+
+	batch.postings = append(batch.postings,
+		ledgerPosting{entryID: entryID, ledgerAccount: fmt.Sprintf("account:%d", accountID), accountID: &accountID, currency: currency, amount: sign * amount},
+		ledgerPosting{entryID: entryID, ledgerAccount: externalCashLedger, currency: currency, amount: -sign * amount},
+	)
+	return true, nil
+}
+
+// apply locks each balance the batch touches, in the same order as the backend locks them,
+// and records the postings against it with their running balances
+func (b *ledgerBatch) apply(tx *sql.Tx) error {
+	postings := append([]ledgerPosting{}, b.postings...)
+	sort.SliceStable(postings, func(i, j int) bool {
+		if postings[i].ledgerAccount != postings[j].ledgerAccount {
+			return postings[i].ledgerAccount < postings[j].ledgerAccount
+		}
+		return postings[i].currency < postings[j].currency
+	})
+
+	for start := 0; start < len(postings); {
+		end := start + 1
+		for end < len(postings) && postings[end].ledgerAccount == postings[start].ledgerAccount && postings[end].currency == postings[start].currency {
+			end++
+		}
+		if err := applyPostings(tx, postings[start:end]); err != nil {
+			return err
+		}
+		start = end
+	}
+	return nil
+}
+
+// applyPostings locks one ledger account's balance in one currency, records the postings
+// in order with the running balance after each and stores the final balance
+func applyPostings(tx *sql.Tx, postings []ledgerPosting) error {
+	first := postings[0]
+	_, err := tx.Exec(`
+		INSERT INTO ledger_balances (ledger_account, currency, account_id, balance)
+		VALUES (?, ?, ?, 0)
+		ON DUPLICATE KEY UPDATE ledger_account = ledger_account`,
+		first.ledgerAccount, first.currency, first.accountID)
+	if err != nil {
+		return fmt.Errorf("failed to create balance for %s: %v", first.ledgerAccount, err)
+	}
+
+	var balance int64
+	err = tx.QueryRow(
+		`SELECT balance FROM ledger_balances WHERE ledger_account = ? AND currency = ? FOR UPDATE`,
+		first.ledgerAccount, first.currency).Scan(&balance)
+	if err != nil {
+		return fmt.Errorf("failed to lock balance for %s: %v", first.ledgerAccount, err)
+	}
+
+	for _, posting := range postings {
+		balance += posting.amount
+		_, err = tx.Exec(`
+			INSERT INTO ledger_postings (entry_id, ledger_account, account_id, currency, amount, balance_after)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			posting.entryID, posting.ledgerAccount, posting.accountID, posting.currency, posting.amount, balance)
+		if err != nil {
+			return fmt.Errorf("failed to insert posting for %s: %v", posting.ledgerAccount, err)
+		}
+	}
+
+	_, err = tx.Exec(
+		`UPDATE ledger_balances SET balance = ? WHERE ledger_account = ? AND currency = ?`,
+		balance, first.ledgerAccount, first.currency)
+	if err != nil {
+		return fmt.Errorf("failed to update balance for %s: %v", first.ledgerAccount, err)
+	}
+	return nil
+}
+
+// formatMinorUnits renders hundredths as a decimal amount such as "1234.50"
+func formatMinorUnits(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
+
+// parseMinorUnits parses a decimal amount such as "1234.50" into hundredths without going through a float
+func parseMinorUnits(raw string) (int64, error) {
+	raw = strings.TrimSpace(raw)
+	whole, fraction, _ := strings.Cut(raw, ".")
+	if whole == "" || len(fraction) > 2 || strings.HasPrefix(whole, "-") {
+		return 0, fmt.Errorf("invalid amount %q", raw)
+	}
+	fraction += strings.Repeat("0", 2-len(fraction))
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", raw)
+	}
+	cents, err := strconv.ParseInt(fraction, 10, 64)
+	if err != nil || cents < 0 {
+		return 0, fmt.Errorf("invalid amount %q", raw)
+	}
+	return units*100 + cents, nil
+}
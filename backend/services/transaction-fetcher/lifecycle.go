@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// waitForShutdown blocks until SIGINT, SIGTERM or a request to /shutdown. Signals are only
+// caught while waiting, so a second one stops the process outright.
+func waitForShutdown() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case sig := <-signals:
+		log.Printf("Received %v", sig)
+	case <-shutdownTrigger:
+		log.Println("Shutdown requested via API")
+	}
+}
+
+// stopAPIServer stops accepting connections and gives open requests a few seconds to finish
+func stopAPIServer(server *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("API server did not shut down cleanly: %v", err)
+	}
+}
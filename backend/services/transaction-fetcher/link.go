@@ -0,0 +1,153 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// linkedAccount is the account a transaction is booked to
+type linkedAccount struct {
+	ID       int
+	Currency string
+}
+
+// clientExists applies the same rule as the backend's ClientService.GetClient:
+// the ID is not empty and is present in the client table
+func clientExists(tx *sql.Tx, clientID string) (bool, error) {
+	if clientID == "" {
+		return false, nil
+	}
+
+	var found int
+	err := tx.QueryRow(`SELECT 1 FROM client WHERE client_id = ?`, clientID).Scan(&found)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check client %s: %v", clientID, err)
+	}
+	return true, nil
+}
+
+// resolveAccount finds the account a client's transaction belongs to. An explicit account
+// must belong to the client and be active; otherwise the client's oldest active account, its
+// primary account, is used. It returns a reject reason when an explicit account is unusable,
+// and a nil account when the client has no active account at all.
+func resolveAccount(tx *sql.Tx, clientID, requested string) (*linkedAccount, string, error) {
+	requested = strings.TrimSpace(requested)
+	if requested == "" {
+		var account linkedAccount
+		err := tx.QueryRow(`
+			SELECT account_id, currency FROM account
+			WHERE client_id = ? AND is_active = TRUE AND account_status = 'Active'
+			ORDER BY account_id LIMIT 1`, clientID).Scan(&account.ID, &account.Currency)
+		if err == sql.ErrNoRows {
+			return nil, "", nil
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to find account for client %s: %v", clientID, err)
+		}
+		return &account, "", nil
+	}
+
+	accountID, err := strconv.Atoi(requested)
+	if err != nil {
+		return nil, fmt.Sprintf("invalid AccountID %q", requested), nil
+	}
+
+	var account linkedAccount
+	var owner, accountStatus string
+	var isActive bool
+	err = tx.QueryRow(
+		`SELECT account_id, currency, client_id, account_status, is_active FROM account WHERE account_id = ?`,
+		accountID).Scan(&account.ID, &account.Currency, &owner, &accountStatus, &isActive)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Sprintf("account %d does not exist", accountID), nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to find account %d: %v", accountID, err)
+	}
+
+	switch {
+	case owner != clientID:
+		return nil, fmt.Sprintf("account %d does not belong to client %s", accountID, clientID), nil
+	case !isActive || accountStatus != "Active":
+		return nil, fmt.Sprintf("account %d is not active", accountID), nil
+	}
+	return &account, "", nil
+}
+
+// ensureColumnExists adds a column to a table created by an earlier version of the fetcher
+func ensureColumnExists(db *sql.DB, table, column, definition string) error {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?`,
+		table, column).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s.%s: %v", table, column, err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		return fmt.Errorf("failed to add %s.%s: %v", table, column, err)
+	}
+	return nil
+}
+
+// ensureAutoIncrement makes an existing key column assign its own values
+func ensureAutoIncrement(db *sql.DB, table, column, definition string) error {
+	var extra string
+	err := db.QueryRow(`
+		SELECT extra FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?`,
+		table, column).Scan(&extra)
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s.%s: %v", table, column, err)
+	}
+	if strings.Contains(strings.ToLower(extra), "auto_increment") {
+		return nil
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s MODIFY %s %s AUTO_INCREMENT", table, column, definition))
+	if err != nil {
+		return fmt.Errorf("failed to make %s.%s auto-increment: %v", table, column, err)
+	}
+	return nil
+}
+
+// ensureIndexExists adds an index to a table created by an earlier version of the fetcher
+func ensureIndexExists(db *sql.DB, table, index, definition string) error {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.statistics
+		WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?`,
+		table, index).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to inspect index %s on %s: %v", index, table, err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD %s", table, definition))
+	if err != nil {
+		return fmt.Errorf("failed to add index %s on %s: %v", index, table, err)
+	}
+	return nil
+}
+
+// columnIndex returns the position of an optional column in the header, or -1
+func columnIndex(header []string, name string) int {
+	for i, column := range header {
+		if strings.EqualFold(strings.TrimSpace(column), name) {
+			return i
+		}
+	}
+	return -1
+}
@@ -1,25 +1,22 @@
 package main
 
 import (
+	"context"
 	"database/sql"
-	"encoding/csv"
 	"encoding/json"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	_"github.com/go-sql-driver/mysql" // MySQL driver
 	"github.com/joho/godotenv"
-	"github.com/pkg/sftp"
-	"golang.org/x/crypto/ssh"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // TransactionLog represents your transaction log structure for CSV data
@@ -30,15 +27,34 @@ type TransactionLog struct {
 	Amount      float64   `json:"amount"`
 	Date        time.Time `json:"date"`
 	Status      string    `json:"status"`
+	AccountID   *int      `json:"account_id"`
+	// Source and SourceRef identify the transaction as its statement did: the bank account,
+	// provider or internal system, and the reference it gave the transaction there
+	Source    string `json:"source"`
+	SourceRef string `json:"source_ref"`
 }
 
 // ServiceStatus represents the current status of the log processor
 type ServiceStatus struct {
-	Running          bool      `json:"running"`
-	LastCheck        time.Time `json:"last_check"`
-	CycleCount       int       `json:"cycle_count"`
-	FilesProcessed   int       `json:"files_processed"`
-	ProcessingErrors int       `json:"processing_errors"`
+	Running          bool            `json:"running"`
+	LastCheck        time.Time       `json:"last_check"`
+	CycleCount       int             `json:"cycle_count"`
+	FilesProcessed   int             `json:"files_processed"`
+	ProcessingErrors int             `json:"processing_errors"`
+	ShuttingDown     bool            `json:"shutting_down"`
+	RowsIngested     int             `json:"rows_ingested"`
+	Workers          int             `json:"workers"`
+	InFlightFiles    []InFlightFile  `json:"in_flight_files"`
+	LastCycle        CycleThroughput `json:"last_cycle"`
+}
+
+// CycleThroughput is how much the last processing cycle ingested and how fast
+type CycleThroughput struct {
+	Files          int     `json:"files"`
+	Rows           int     `json:"rows"`
+	Seconds        float64 `json:"seconds"`
+	FilesPerMinute float64 `json:"files_per_minute"`
+	RowsPerSecond  float64 `json:"rows_per_second"`
 }
 
 var (
@@ -62,43 +78,73 @@ func main() {
 	}
 	defer db.Close()
 
-	// SFTP Config
-	sftpServer := os.Getenv("SFTP_SERVER")
-	username := os.Getenv("SFTP_USERNAME")
-	privateKeyPath := os.Getenv("SFTP_PRIVATE_KEY")
-	remotePath := os.Getenv("SFTP_REMOTE_LOG_PATH")
-	processedPath := os.Getenv("SFTP_PROCESSED_PATH")
+	// Source Config: SFTP by default, or a local directory
+	sourceConfig, err := loadSourceConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// Debug logging for paths
-	log.Printf("Remote path: %s", remotePath)
-	log.Printf("Processed path: %s", processedPath)
+	logSourceConfig(sourceConfig)
 
-	// Expand ~ to home directory in privateKeyPath if needed
-	if len(privateKeyPath) > 0 && privateKeyPath[0] == '~' {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			log.Fatal("Error getting home directory:", err)
-		}
-		privateKeyPath = filepath.Join(homeDir, privateKeyPath[1:])
+	// CSV mapping profiles for providers with their own layouts
+	if err := loadCSVProfilesFromEnv(); err != nil {
+		log.Fatal(err)
 	}
 
+	// Worker pool for processing files in parallel
+	pool, err := loadPoolConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	status.Workers = pool.Workers
+	workersGauge.Set(float64(pool.Workers))
+
 	// Start API server in a separate goroutine
-	go startAPIServer()
+	server := startAPIServer()
+
+	// Start the main processing loop. Cancelling ctx stops it from starting new cycles and
+	// files; cancelling workCtx rolls back the files still in flight
+	ctx, cancel := context.WithCancel(context.Background())
+	workCtx, abort := context.WithCancel(context.Background())
+	loopDone := make(chan struct{})
+	go func() {
+		defer close(loopDone)
+		processLoop(ctx, workCtx, sourceConfig, pool)
+	}()
+
+	// Block until SIGINT, SIGTERM or the shutdown endpoint
+	waitForShutdown()
+	cancel()
 
-	// Start the main processing loop
-	go processLoop(sftpServer, username, privateKeyPath, remotePath, processedPath)
+	statusMutex.Lock()
+	status.ShuttingDown = true
+	statusMutex.Unlock()
 
-	// Block indefinitely (until shutdown)
-	<-shutdownTrigger
-	log.Println("Service shutting down gracefully...")
+	// Let the files being ingested commit, or roll them back once the drain timeout passes
+	log.Printf("Service shutting down, waiting up to %s for in-flight files...", pool.ShutdownTimeout)
+	select {
+	case <-loopDone:
+	case <-time.After(pool.ShutdownTimeout):
+		log.Println("In-flight files did not finish in time, rolling them back")
+		abort()
+		<-loopDone
+	}
+	abort()
+
+	stopAPIServer(server)
+	log.Println("Service shut down gracefully")
 }
 
-func startAPIServer() {
+func startAPIServer() *http.Server {
 	// Define API endpoints
 	http.HandleFunc("/status", getStatusHandler)
 	http.HandleFunc("/trigger", triggerProcessingHandler)
 	http.HandleFunc("/shutdown", shutdownHandler)
 	http.HandleFunc("/transactions/", getTransactionsHandler)
+	http.HandleFunc("/files/", getFileErrorsHandler)
+	http.HandleFunc("/reconciliation", getReconciliationHandler)
+	http.Handle("/metrics", promhttp.Handler())
 
 	// Get port from env or use default
 	port := os.Getenv("API_PORT")
@@ -106,18 +152,43 @@ func startAPIServer() {
 		port = "8080"
 	}
 
-	log.Printf("Starting API server on port %s", port)
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
-		log.Fatalf("Failed to start API server: %v", err)
-	}
+	server := &http.Server{Addr: ":" + port}
+	go func() {
+		log.Printf("Starting API server on port %s", port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Failed to start API server: %v", err)
+		}
+	}()
+
+	return server
+}
+
+// statusResponse is the service status together with the ingestion manifest
+type statusResponse struct {
+	ServiceStatus
+	IngestedFiles *ManifestSummary `json:"ingested_files,omitempty"`
 }
 
 func getStatusHandler(w http.ResponseWriter, r *http.Request) {
 	statusMutex.Lock()
-	defer statusMutex.Unlock()
+	response := statusResponse{ServiceStatus: status}
+	response.InFlightFiles = append([]InFlightFile{}, status.InFlightFiles...)
+	statusMutex.Unlock()
+
+	// The manifest is best effort: the service status is still reported if the database is down
+	db, err := sql.Open("mysql", databaseDSN())
+	if err == nil {
+		defer db.Close()
+		summary, err := loadManifestSummary(db, 50)
+		if err == nil {
+			response.IngestedFiles = &summary
+		} else {
+			log.Println("Failed to load ingestion manifest:", err)
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(status)
+	json.NewEncoder(w).Encode(response)
 }
 
 func triggerProcessingHandler(w http.ResponseWriter, r *http.Request) {
@@ -158,10 +229,13 @@ func shutdownHandler(w http.ResponseWriter, r *http.Request) {
 		"message": "Service is shutting down",
 	})
 
-	// Trigger shutdown after sending response
+	// Trigger shutdown after sending response; a shutdown already underway is left to finish
 	go func() {
 		time.Sleep(100 * time.Millisecond)
-		shutdownTrigger <- true
+		select {
+		case shutdownTrigger <- true:
+		default:
+		}
 	}()
 }
 
@@ -198,7 +272,8 @@ func getTransactionsHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Query transactions for the given clientid
 	query := `
-		SELECT id, clientid, transaction_type, amount, transaction_date, status
+		SELECT id, clientid, transaction_type, amount, transaction_date, status, account_id,
+			COALESCE(source, ''), COALESCE(source_ref, '')
 		FROM transaction_logs
 		WHERE clientid = ?
 		ORDER BY transaction_date DESC
@@ -222,6 +297,9 @@ func getTransactionsHandler(w http.ResponseWriter, r *http.Request) {
 			&t.Amount,
 			&t.Date,
 			&t.Status,
+			&t.AccountID,
+			&t.Source,
+			&t.SourceRef,
 		)
 		if err != nil {
 			log.Println("Failed to scan transaction row:", err)
@@ -260,20 +338,45 @@ func ensureDatabaseExists(db *sql.DB, dbName string) error {
 func ensureTableExists(db *sql.DB) error {
 	query := `
 		CREATE TABLE IF NOT EXISTS transaction_logs (
-			id INT PRIMARY KEY,
+			id INT AUTO_INCREMENT PRIMARY KEY,
 			clientid VARCHAR(255) NOT NULL,
 			transaction_type VARCHAR(255) NOT NULL,
 			amount FLOAT(10, 2) NOT NULL,
 			transaction_date DATETIME NOT NULL,
 			status VARCHAR(255) NOT NULL,
-			UNIQUE KEY (id)
+			account_id INT NULL,
+			source VARCHAR(255) NULL,
+			source_ref VARCHAR(255) NULL,
+			UNIQUE KEY (id),
+			UNIQUE KEY uq_transaction_logs_source (source, source_ref)
 		)
 	`
 	_, err := db.Exec(query)
 	if err != nil {
 		return fmt.Errorf("failed to create table: %v", err)
 	}
-	return nil
+
+	// Tables created before transactions were linked to accounts
+	if err := ensureColumnExists(db, "transaction_logs", "account_id", "INT NULL"); err != nil {
+		return err
+	}
+
+	// Tables created when the statement's ID was the primary key: the IDs so far came from
+	// internal systems, and new rows get a surrogate ID
+	if err := ensureColumnExists(db, "transaction_logs", "source", "VARCHAR(255) NULL"); err != nil {
+		return err
+	}
+	if err := ensureColumnExists(db, "transaction_logs", "source_ref", "VARCHAR(255) NULL"); err != nil {
+		return err
+	}
+	_, err = db.Exec(`UPDATE transaction_logs SET source = ?, source_ref = CAST(id AS CHAR) WHERE source IS NULL`, sourceInternal)
+	if err != nil {
+		return fmt.Errorf("failed to backfill transaction sources: %v", err)
+	}
+	if err := ensureAutoIncrement(db, "transaction_logs", "id", "INT NOT NULL"); err != nil {
+		return err
+	}
+	return ensureIndexExists(db, "transaction_logs", "uq_transaction_logs_source", "UNIQUE KEY uq_transaction_logs_source (source, source_ref)")
 }
 
 func initializeDatabase() (*sql.DB, error) {
@@ -311,28 +414,49 @@ func initializeDatabase() (*sql.DB, error) {
 		return nil, fmt.Errorf("failed to ensure table exists: %v", err)
 	}
 
+	// Ensure the manifest table exists
+	err = ensureManifestTableExists(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ensure manifest table exists: %v", err)
+	}
+
+	// Ensure the quarantine table exists
+	err = ensureQuarantineTableExists(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ensure quarantine table exists: %v", err)
+	}
+
+	// Ensure the reconciliation table exists
+	err = ensureReconciliationTableExists(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ensure reconciliation table exists: %v", err)
+	}
+
 	return db, nil
 }
 
-func processLoop(sftpServer, username, privateKeyPath, remotePath, processedPath string) {
+func processLoop(ctx, workCtx context.Context, sourceConfig SourceConfig, pool poolConfig) {
 	tickerInterval := 5 * time.Minute
 	ticker := time.NewTicker(tickerInterval)
 	defer ticker.Stop()
 
 	// Process once immediately on startup
-	processOnce(sftpServer, username, privateKeyPath, remotePath, processedPath)
+	processOnce(ctx, workCtx, sourceConfig, pool)
 
 	for {
 		select {
+		case <-ctx.Done():
+			return
+
 		case <-ticker.C:
 			// Process on ticker schedule
 			log.Println("Scheduled processing triggered")
-			processOnce(sftpServer, username, privateKeyPath, remotePath, processedPath)
+			processOnce(ctx, workCtx, sourceConfig, pool)
 
 		case <-processTrigger:
 			// Process on manual trigger
 			log.Println("Manual processing triggered")
-			processOnce(sftpServer, username, privateKeyPath, remotePath, processedPath)
+			processOnce(ctx, workCtx, sourceConfig, pool)
 
 			// Reset the ticker to avoid processing twice in quick succession
 			ticker.Reset(tickerInterval)
@@ -340,55 +464,30 @@ func processLoop(sftpServer, username, privateKeyPath, remotePath, processedPath
 	}
 }
 
-func processOnce(sftpServer, username, privateKeyPath, remotePath, processedPath string) {
+func processOnce(ctx, workCtx context.Context, sourceConfig SourceConfig, pool poolConfig) {
+	// Don't start a cycle once shutdown has begun
+	if ctx.Err() != nil {
+		return
+	}
+
 	// Update status
 	statusMutex.Lock()
 	status.Running = true
 	status.LastCheck = time.Now()
 	status.CycleCount++
 	statusMutex.Unlock()
+	cyclesTotal.Inc()
 
 	log.Printf("Starting log processing cycle %d...", status.CycleCount)
 
-	// Load SSH private key
-	key, err := ioutil.ReadFile(privateKeyPath)
-	if err != nil {
-		log.Println("Unable to read private key:", err)
-		updateStatusError()
-		return
-	}
-
-	signer, err := ssh.ParsePrivateKey(key)
+	// Connect to the transaction source
+	source, err := openSource(sourceConfig)
 	if err != nil {
-		log.Println("Unable to parse private key:", err)
+		log.Println("Failed to open transaction source:", err)
 		updateStatusError()
 		return
 	}
-
-	// SSH Config
-	config := &ssh.ClientConfig{
-		User:            username,
-		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         30 * time.Second,
-	}
-
-	// Connect to SFTP Server
-	client, err := ssh.Dial("tcp", sftpServer+":2022", config)
-	if err != nil {
-		log.Println("Failed to connect:", err)
-		updateStatusError()
-		return
-	}
-	defer client.Close()
-
-	sftpClient, err := sftp.NewClient(client)
-	if err != nil {
-		log.Println("Failed to create SFTP client:", err)
-		updateStatusError()
-		return
-	}
-	defer sftpClient.Close()
+	defer source.Close()
 
 	// Connect to MySQL database
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
@@ -406,9 +505,10 @@ func processOnce(sftpServer, username, privateKeyPath, remotePath, processedPath
 	}
 	defer db.Close()
 
-	// Set connection pool parameters
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
+	// Set connection pool parameters: a transaction per worker plus a few for manifest
+	// updates, so the workers queue for connections rather than overwhelm the database
+	db.SetMaxOpenConns(pool.Workers + 2)
+	db.SetMaxIdleConns(pool.Workers + 2)
 	db.SetConnMaxLifetime(5 * time.Minute)
 
 	// Test database connection
@@ -428,105 +528,32 @@ func processOnce(sftpServer, username, privateKeyPath, remotePath, processedPath
 	}
 	defer os.RemoveAll(tempDir)
 
-	// Ensure processed directory exists
-	err = ensureRemoteDir(sftpClient, processedPath)
-	if err != nil {
-		log.Println("Failed to ensure processed directory exists:", err)
-		updateStatusError()
-		return
-	}
-
-	// List client directories
-	clientDirs, err := sftpClient.ReadDir(remotePath)
-	if err != nil {
-		log.Println("Failed to list directory:", err)
-		updateStatusError()
-		return
-	}
-
-	filesProcessed := 0
 	errors := 0
 
-	// Process each client directory
-	for _, clientDir := range clientDirs {
-		// Skip files and hidden directories
-		if !clientDir.IsDir() || strings.HasPrefix(clientDir.Name(), ".") {
-			continue
-		}
-
-		clientID := clientDir.Name()
-		// Skip the "processed" directory itself
-		if clientID == "processed" {
-			continue
-		}
-		clientPath := filepath.Join(remotePath, clientID)
-		log.Printf("Processing client directory: %s", clientID)
-		log.Printf("Client path: %s", clientPath)
-
-		// Ensure processed directory exists for this client
-		clientProcessedPath := filepath.Join(processedPath, clientID)
-		log.Printf("Client processed path: %s", clientProcessedPath)
-
-		err = ensureRemoteDir(sftpClient, clientProcessedPath)
-		if err != nil {
-			log.Printf("Warning: Could not create processed directory for client %s: %v", clientID, err)
-			errors++
-			continue
-		}
-
-		// List files in the client directory
-		files, err := sftpClient.ReadDir(clientPath)
-		if err != nil {
-			log.Printf("Failed to list files for client %s: %v", clientID, err)
-			errors++
-			continue
+	// List the files waiting in each client directory
+	files, err := source.List()
+	if err != nil {
+		log.Println("Failed to list transaction files:", err)
+		errors++
+		if len(files) == 0 {
+			updateStatusError()
+			return
 		}
+	}
 
-		// Process each log file
-		for _, file := range files {
-			// Skip directories and hidden files
-			if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
-				continue
-			}
-
-			// Only process CSV files
-			if !strings.HasSuffix(strings.ToLower(file.Name()), ".csv") {
-				continue
-			}
-
-			log.Printf("Processing file: %s/%s", clientID, file.Name())
-
-			// Full path to remote file
-			remoteFilePath := filepath.Join(clientPath, file.Name())
-			localFilePath := filepath.Join(tempDir, clientID+"_"+file.Name())
-
-			// Download the file
-			err = downloadFile(sftpClient, remoteFilePath, localFilePath)
-			if err != nil {
-				log.Printf("Error downloading file %s/%s: %v", clientID, file.Name(), err)
-				errors++
-				continue
-			}
-
-			// Process the downloaded file
-			err = processLogFile(localFilePath, db)
-			if err != nil {
-				log.Printf("Error processing file %s/%s: %v", clientID, file.Name(), err)
-				errors++
-				continue
-			}
-
-			// Move to processed directory
-			processedFilePath := filepath.Join(clientProcessedPath, file.Name())
-			err = moveRemoteFile(sftpClient, remoteFilePath, processedFilePath)
-			if err != nil {
-				log.Printf("Warning: Could not move processed file %s/%s: %v", clientID, file.Name(), err)
-				errors++
-			}
+	// Process the files on a bounded pool of workers
+	started := time.Now()
+	totals := runPool(ctx, workCtx, pool, files, func(ctx context.Context, file SourceFile) fileOutcome {
+		return processFile(ctx, source, db, tempDir, file)
+	})
+	filesProcessed := totals.Files
+	errors += totals.Errors
+	elapsed := time.Since(started)
 
-			log.Printf("Successfully processed %s/%s", clientID, file.Name())
-			filesProcessed++
-		}
+	// Reconcile the previous day once it is over
+	if err := ensureDailyReconciliation(db); err != nil {
+		log.Println("Failed to produce daily reconciliation:", err)
+		errors++
 	}
 
 	// Update status
@@ -534,177 +561,215 @@ func processOnce(sftpServer, username, privateKeyPath, remotePath, processedPath
 	status.Running = false
 	status.FilesProcessed += filesProcessed
 	status.ProcessingErrors += errors
+	status.RowsIngested += totals.Rows
+	status.LastCycle = CycleThroughput{
+		Files:          filesProcessed,
+		Rows:           totals.Rows,
+		Seconds:        elapsed.Seconds(),
+		FilesPerMinute: perUnit(filesProcessed, elapsed, time.Minute),
+		RowsPerSecond:  perUnit(totals.Rows, elapsed, time.Second),
+	}
 	statusMutex.Unlock()
+	observeCycle(filesProcessed, errors, totals.Rows, elapsed)
 
 	log.Printf("Processing cycle %d completed. Files processed: %d, Errors: %d", status.CycleCount, filesProcessed, errors)
 }
 
+// perUnit is a count per unit of time over an elapsed duration
+func perUnit(count int, elapsed, unit time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(count) * float64(unit) / float64(elapsed)
+}
+
 func updateStatusError() {
 	statusMutex.Lock()
 	status.Running = false
 	status.ProcessingErrors++
 	statusMutex.Unlock()
+	processingErrorsTotal.Inc()
 }
 
-// downloadFile downloads a file from SFTP server to local path
-func downloadFile(client *sftp.Client, remotePath, localPath string) error {
-	srcFile, err := client.Open(remotePath)
-	if err != nil {
-		return fmt.Errorf("failed to open remote file: %v", err)
-	}
-	defer srcFile.Close()
-
-	dstFile, err := os.Create(localPath)
-	if err != nil {
-		return fmt.Errorf("failed to create local file: %v", err)
-	}
-	defer dstFile.Close()
-
-	_, err = io.Copy(dstFile, srcFile)
-	if err != nil {
-		return fmt.Errorf("failed to copy file contents: %v", err)
-	}
-
-	return nil
-}
-
-// moveRemoteFile moves a file on the remote SFTP server
-func moveRemoteFile(client *sftp.Client, oldPath, newPath string) error {
-	return client.Rename(oldPath, newPath)
+// ingestResult summarises a processed log file
+type ingestResult struct {
+	Header   []string
+	Inserted int
+	// Duplicates are rows already stored with the same content, which are skipped
+	Duplicates int
+	Rejects    []rowReject
 }
 
-// ensureRemoteDir ensures a directory exists on the remote server
-func ensureRemoteDir(client *sftp.Client, path string) error {
-	_, err := client.Stat(path)
-	if err == nil {
-		// Directory exists
-		return nil
-	}
-
-	// Create directory if it doesn't exist
-	return client.MkdirAll(path)
-}
+// processLogFile parses a statement file in any registered format and stores entries in the database.
+// Rows that cannot be ingested are quarantined, and the file's manifest entry is marked committed,
+// in the same transaction; the quarantined rows are returned in the result.
+func processLogFile(ctx context.Context, filePath string, source SourceFile, manifestID int64, db *sql.DB) (ingestResult, error) {
+	var result ingestResult
 
-// processLogFile parses the CSV log file and stores entries in the database
-func processLogFile(filePath string, db *sql.DB) error {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to open file: %v", err)
+		return result, fmt.Errorf("failed to open file: %v", err)
 	}
 	defer file.Close()
 
-	// Create CSV reader
-	reader := csv.NewReader(file)
-
-	// Read header row
-	header, err := reader.Read()
+	// Pick the parser for the file's format and normalise its rows
+	parser, err := detectParser(source.Name, filePath)
 	if err != nil {
-		return fmt.Errorf("failed to read CSV header: %v", err)
+		return result, err
 	}
 
-	// Verify header format
-	expectedHeaders := []string{"ID", "ClientID", "Transaction", "Amount", "Date", "Status"}
-	if !validateHeaders(header, expectedHeaders) {
-		return fmt.Errorf("invalid CSV header format: %v", header)
+	parsed, err := parser.Parse(file, source)
+	if err != nil {
+		return result, err
 	}
+	result.Header = parsed.Header
+	log.Printf("Parsed %s as %s: %d rows", source.Name, parser.Name(), len(parsed.Rows))
 
-	// Begin transaction for batch inserts
-	tx, err := db.Begin()
+	// Begin transaction for batch inserts; it is rolled back if ctx times out or is cancelled
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %v", err)
+		return result, fmt.Errorf("failed to begin transaction: %v", err)
 	}
 
 	// Prepare statement for inserting logs - MySQL syntax
 	stmt, err := tx.Prepare(`
-		INSERT INTO transaction_logs (id, clientid, transaction_type, amount, transaction_date, status) 
-		VALUES (?, ?, ?, ?, ?, ?)
-		ON DUPLICATE KEY UPDATE 
-		    clientid = VALUES(clientid),
-		    transaction_type = VALUES(transaction_type),
-		    amount = VALUES(amount),
-		    transaction_date = VALUES(transaction_date),
-		    status = VALUES(status)
+		INSERT INTO transaction_logs (clientid, transaction_type, amount, transaction_date, status, account_id, source, source_ref) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`)
 	if err != nil {
 		tx.Rollback()
-		return fmt.Errorf("failed to prepare statement: %v", err)
+		return result, fmt.Errorf("failed to prepare statement: %v", err)
 	}
 	defer stmt.Close()
 
-	// Process each row
-	count := 0
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
+	// Ingest each row, quarantining the ones that cannot be ingested
+	reject := func(row statementRow, reason string) {
+		log.Printf("Rejected row %d of %s: %s", row.Row, source.Name, reason)
+		result.Rejects = append(result.Rejects, rowReject{Row: row.Row, Record: row.Record, Reason: reason})
+	}
+
+	// Postings are collected per file and applied before commit
+	var ledger ledgerBatch
+
+	for _, row := range parsed.Rows {
+		// Stop early when the file timed out or the service is shutting down
+		if err := ctx.Err(); err != nil {
+			tx.Rollback()
+			return result, fmt.Errorf("stopped after %d rows: %v", result.Inserted, err)
 		}
-		if err != nil {
-			log.Printf("Error reading CSV row: %v", err)
+
+		if row.Reject != "" {
+			reject(row, row.Reject)
 			continue
 		}
+		t := row.Log
 
-		// Parse the data
-		id, err := strconv.Atoi(record[0])
+		// An ID is ingested once per source: the same transaction delivered again is skipped,
+		// and a different one under a taken ID is rejected instead of overwriting the stored row
+		stored, err := findTransaction(tx, t.Source, t.SourceRef)
 		if err != nil {
-			log.Printf("Invalid ID in row %d: %v", count+1, err)
+			tx.Rollback()
+			return result, err
+		}
+		if stored != nil {
+			if stored.matches(t, row.AmountMinor) {
+				result.Duplicates++
+				continue
+			}
+			reject(row, fmt.Sprintf("transaction %s from %s already exists with different content", t.SourceRef, t.Source))
 			continue
 		}
 
-		amount, err := strconv.ParseFloat(record[3], 64)
+		// Only accept transactions for clients the backend knows about
+		exists, err := clientExists(tx, t.ClientID)
 		if err != nil {
-			log.Printf("Invalid Amount in row %d: %v", count+1, err)
+			tx.Rollback()
+			return result, err
+		}
+		if !exists {
+			reject(row, fmt.Sprintf("unknown client %q", t.ClientID))
 			continue
 		}
 
-		date, err := time.Parse(time.RFC3339, record[4])
+		account, reason, err := resolveAccount(tx, t.ClientID, row.AccountID)
 		if err != nil {
-			// Try alternative date formats if RFC3339 fails
-			date, err = tryParseDate(record[4])
-			if err != nil {
-				log.Printf("Invalid Date in row %d: %v", count+1, err)
-				continue
-			}
+			tx.Rollback()
+			return result, err
+		}
+		if reason != "" {
+			reject(row, reason)
+			continue
+		}
+
+		// A row in another currency cannot be booked to its account
+		if account != nil && row.Currency != "" && row.Currency != strings.ToUpper(account.Currency) {
+			reject(row, fmt.Sprintf("currency %s does not match account %d in %s", row.Currency, account.ID, account.Currency))
+			continue
+		}
+
+		var accountID interface{}
+		if account != nil {
+			accountID = account.ID
 		}
 
 		// Insert into database
-		_, err = stmt.Exec(
-			id,
-			record[1], // ClientID
-			record[2], // Transaction
-			amount,
-			date,
-			record[5], // Status
+		inserted, err := stmt.ExecContext(ctx,
+			t.ClientID,
+			t.Transaction,
+			t.Amount,
+			t.Date,
+			t.Status,
+			accountID,
+			t.Source,
+			t.SourceRef,
 		)
 		if err != nil {
-			log.Printf("Error inserting row %d: %v", count+1, err)
+			if !isRowError(err) {
+				tx.Rollback()
+				return result, fmt.Errorf("failed to insert row %d: %v", row.Row, err)
+			}
+			reject(row, fmt.Sprintf("insert failed: %v", err))
 			continue
 		}
+		id, err := inserted.LastInsertId()
+		if err != nil {
+			tx.Rollback()
+			return result, fmt.Errorf("failed to get transaction ID: %v", err)
+		}
+		t.ID = int(id)
 
-		count++
+		// Post completed deposits and withdrawals to the linked account in the ledger
+		if _, err := postToLedger(tx, &ledger, t.ID, account, t.ClientID, t.Transaction, row.AmountMinor, t.Status); err != nil {
+			tx.Rollback()
+			return result, fmt.Errorf("failed to post transaction %d to ledger: %v", t.ID, err)
+		}
+
+		result.Inserted++
 	}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %v", err)
+	// Balances are locked only now, just before the file commits
+	if err := ledger.apply(tx); err != nil {
+		tx.Rollback()
+		return result, fmt.Errorf("failed to post to ledger: %v", err)
 	}
 
-	log.Printf("Successfully processed %d transaction records from %s", count, filepath.Base(filePath))
-	return nil
-}
+	if err := quarantineRows(tx, manifestID, source.Name, source.ClientID, result.Rejects); err != nil {
+		tx.Rollback()
+		return result, err
+	}
 
-// validateHeaders checks if the CSV header matches the expected format
-func validateHeaders(headers, expectedHeaders []string) bool {
-	if len(headers) < len(expectedHeaders) {
-		return false
+	if err := commitIngest(tx, manifestID, result); err != nil {
+		tx.Rollback()
+		return result, err
 	}
 
-	for i, expected := range expectedHeaders {
-		if i >= len(headers) || headers[i] != expected {
-			return false
-		}
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("failed to commit transaction: %v", err)
 	}
 
-	return true
+	log.Printf("Successfully processed %d transaction records from %s (%d already ingested, %d rejected)", result.Inserted, filepath.Base(filePath), result.Duplicates, len(result.Rejects))
+	return result, nil
 }
 
 // tryParseDate attempts to parse a date string using multiple formats
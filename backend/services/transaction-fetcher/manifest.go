@@ -0,0 +1,191 @@
+package main
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Manifest statuses. A file's rows and its move to committed happen in one database
+// transaction, so a file that is committed has been applied exactly once, and a file left
+// processing (e.g. after a crash) or failed has applied nothing and is safe to run again.
+const (
+	manifestProcessing = "processing"
+	manifestCommitted  = "committed"
+	manifestFailed     = "failed"
+)
+
+// IngestedFile is a file's entry in the ingestion manifest
+type IngestedFile struct {
+	ID           int64      `json:"id"`
+	FileName     string     `json:"file_name"`
+	ClientID     string     `json:"client_id"`
+	ContentHash  string     `json:"content_hash"`
+	Status       string     `json:"status"`
+	Attempts     int        `json:"attempts"`
+	RowsTotal    int        `json:"rows_total"`
+	RowsInserted int        `json:"rows_inserted"`
+	RowsRejected int        `json:"rows_rejected"`
+	Error        string     `json:"error,omitempty"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+}
+
+func ensureManifestTableExists(db *sql.DB) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS ingested_files (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			file_name VARCHAR(255) NOT NULL,
+			clientid VARCHAR(255) NOT NULL,
+			content_hash CHAR(64) NOT NULL,
+			status ENUM('processing', 'committed', 'failed') NOT NULL DEFAULT 'processing',
+			attempts INT NOT NULL DEFAULT 1,
+			rows_total INT NOT NULL DEFAULT 0,
+			rows_inserted INT NOT NULL DEFAULT 0,
+			rows_rejected INT NOT NULL DEFAULT 0,
+			error TEXT NULL,
+			started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			finished_at DATETIME NULL,
+			UNIQUE KEY uq_ingested_files (clientid, file_name, content_hash)
+		)
+	`
+	_, err := db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create manifest table: %v", err)
+	}
+	return nil
+}
+
+// hashFile returns the hex SHA-256 of a file's contents
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to hash file: %v", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// beginIngest records that a file is being processed. It returns the manifest entry and
+// whether the same content was already committed, in which case it must not be applied again.
+func beginIngest(db *sql.DB, file SourceFile, contentHash string) (int64, bool, error) {
+	_, err := db.Exec(`
+		INSERT INTO ingested_files (file_name, clientid, content_hash, status)
+		VALUES (?, ?, ?, 'processing')
+		ON DUPLICATE KEY UPDATE
+			attempts = IF(status = 'committed', attempts, attempts + 1),
+			status = IF(status = 'committed', status, 'processing'),
+			error = IF(status = 'committed', error, NULL),
+			started_at = IF(status = 'committed', started_at, CURRENT_TIMESTAMP),
+			finished_at = IF(status = 'committed', finished_at, NULL)`,
+		file.Name, file.ClientID, contentHash,
+	)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to record file in manifest: %v", err)
+	}
+
+	var id int64
+	var status string
+	err = db.QueryRow(
+		`SELECT id, status FROM ingested_files WHERE clientid = ? AND file_name = ? AND content_hash = ?`,
+		file.ClientID, file.Name, contentHash,
+	).Scan(&id, &status)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read manifest entry: %v", err)
+	}
+
+	return id, status == manifestCommitted, nil
+}
+
+// commitIngest marks a file committed inside the transaction that applied its rows
+func commitIngest(tx *sql.Tx, manifestID int64, result ingestResult) error {
+	_, err := tx.Exec(`
+		UPDATE ingested_files
+		SET status = 'committed', rows_total = ?, rows_inserted = ?, rows_rejected = ?, finished_at = CURRENT_TIMESTAMP
+		WHERE id = ?`,
+		result.Inserted+result.Duplicates+len(result.Rejects), result.Inserted, len(result.Rejects), manifestID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark file committed: %v", err)
+	}
+	return nil
+}
+
+// failIngest records why a file could not be applied; it will be retried on the next cycle
+func failIngest(db *sql.DB, manifestID int64, cause error) error {
+	_, err := db.Exec(
+		`UPDATE ingested_files SET status = 'failed', error = ?, finished_at = CURRENT_TIMESTAMP WHERE id = ? AND status <> 'committed'`,
+		cause.Error(), manifestID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark file failed: %v", err)
+	}
+	return nil
+}
+
+// ManifestSummary is the manifest as reported on /status
+type ManifestSummary struct {
+	Counts map[string]int `json:"counts"`
+	Recent []IngestedFile `json:"recent"`
+}
+
+// loadManifestSummary counts manifest entries by status and returns the most recent ones
+func loadManifestSummary(db *sql.DB, limit int) (ManifestSummary, error) {
+	summary := ManifestSummary{
+		Counts: map[string]int{manifestProcessing: 0, manifestCommitted: 0, manifestFailed: 0},
+		Recent: []IngestedFile{},
+	}
+
+	rows, err := db.Query(`SELECT status, COUNT(*) FROM ingested_files GROUP BY status`)
+	if err != nil {
+		return summary, fmt.Errorf("failed to count manifest entries: %v", err)
+	}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			rows.Close()
+			return summary, fmt.Errorf("error scanning manifest count: %v", err)
+		}
+		summary.Counts[status] = count
+	}
+	rows.Close()
+
+	rows, err = db.Query(`
+		SELECT id, file_name, clientid, content_hash, status, attempts, rows_total, rows_inserted,
+			rows_rejected, COALESCE(error, ''), started_at, finished_at
+		FROM ingested_files
+		ORDER BY started_at DESC, id DESC
+		LIMIT ?`, limit)
+	if err != nil {
+		return summary, fmt.Errorf("failed to retrieve manifest entries: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry IngestedFile
+		var finishedAt sql.NullTime
+		err := rows.Scan(
+			&entry.ID, &entry.FileName, &entry.ClientID, &entry.ContentHash, &entry.Status, &entry.Attempts,
+			&entry.RowsTotal, &entry.RowsInserted, &entry.RowsRejected, &entry.Error, &entry.StartedAt, &finishedAt,
+		)
+		if err != nil {
+			return summary, fmt.Errorf("error scanning manifest entry: %v", err)
+		}
+		if finishedAt.Valid {
+			entry.FinishedAt = &finishedAt.Time
+		}
+		summary.Recent = append(summary.Recent, entry)
+	}
+
+	return summary, rows.Err()
+}
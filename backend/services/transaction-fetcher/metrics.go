@@ -0,0 +1,66 @@
+package main
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus counterparts of ServiceStatus, served on /metrics
+var (
+	cyclesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "fetcher_cycles_total",
+		Help: "Processing cycles started.",
+	})
+
+	filesProcessedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "fetcher_files_processed_total",
+		Help: "Files ingested and archived.",
+	})
+
+	processingErrorsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "fetcher_processing_errors_total",
+		Help: "Errors while listing, fetching, ingesting or archiving files.",
+	})
+
+	rowsIngestedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "fetcher_rows_ingested_total",
+		Help: "Transaction rows inserted.",
+	})
+
+	rowsRejectedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "fetcher_rows_rejected_total",
+		Help: "Transaction rows quarantined as rejects.",
+	})
+
+	inFlightFilesGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "fetcher_in_flight_files",
+		Help: "Files the workers are processing right now.",
+	})
+
+	workersGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "fetcher_workers",
+		Help: "Size of the worker pool.",
+	})
+
+	cycleDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "fetcher_cycle_duration_seconds",
+		Help:    "How long processing cycles take.",
+		Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600},
+	})
+
+	lastCycleTimestamp = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "fetcher_last_cycle_completed_timestamp_seconds",
+		Help: "Unix time the last processing cycle completed.",
+	})
+)
+
+// observeCycle records a completed cycle
+func observeCycle(files, errors, rows int, elapsed time.Duration) {
+	filesProcessedTotal.Add(float64(files))
+	processingErrorsTotal.Add(float64(errors))
+	rowsIngestedTotal.Add(float64(rows))
+	cycleDuration.Observe(elapsed.Seconds())
+	lastCycleTimestamp.SetToCurrentTime()
+}
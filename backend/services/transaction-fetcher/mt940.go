@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// mt940Parser reads SWIFT MT940 customer statements. Each :61: statement line is a booked
+// transaction: C is a deposit, D a withdrawal, and RC/RD reverse them. The bank's reference
+// after // identifies a transaction within the :25: account, falling back to the reference for
+// the account owner.
+type mt940Parser struct{}
+
+func (mt940Parser) Name() string         { return "mt940" }
+func (mt940Parser) Extensions() []string { return []string{".sta", ".mt940", ".940"} }
+
+func (mt940Parser) Sniff(head []byte) bool {
+	return (bytes.HasPrefix(head, []byte("{1:")) || bytes.HasPrefix(head, []byte(":20:"))) &&
+		bytes.Contains(head, []byte(":61:"))
+}
+
+// mt940StatementLine matches the :61: field: value date YYMMDD, optional entry date MMDD,
+// debit/credit mark, optional funds code, amount with a decimal comma, transaction type,
+// reference for the account owner and optional // bank reference
+var mt940StatementLine = regexp.MustCompile(`^(\d{2})(\d{2})(\d{2})(\d{4})?(RC|RD|C|D)([A-Z])?(\d+,\d*)([NFS][A-Z0-9]{3})([^/]*)(?://(.*))?$`)
+
+// mt940Balance matches the opening balance fields :60F: and :60M:: debit/credit mark, date
+// YYMMDD and the statement's currency
+var mt940Balance = regexp.MustCompile(`^[CD]\d{6}([A-Z]{3})`)
+
+// mt940Tag matches the start of a field, e.g. ":61:" or ":60F:"
+var mt940Tag = regexp.MustCompile(`^:(\d{2}[A-Z]?):`)
+
+func (mt940Parser) Parse(r io.Reader, file SourceFile) (statement, error) {
+	parsed := statement{Header: normalisedHeader}
+
+	scanner := bufio.NewScanner(r)
+	row := 0
+	sawStatement := false
+	currency := "" // from the current statement's opening balance
+	account := ""  // the current statement's :25: account identification
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		tag := mt940Tag.FindStringSubmatch(line)
+		if tag == nil {
+			// Continuation lines (:61: supplementary details, :86: narrative) and block markers
+			continue
+		}
+		if tag[1] == "20" {
+			sawStatement = true
+			currency, account = "", ""
+		}
+		if tag[1] == "25" {
+			account = strings.TrimSpace(line[len(tag[0]):])
+		}
+		if tag[1] == "60F" || tag[1] == "60M" {
+			if match := mt940Balance.FindStringSubmatch(strings.TrimSpace(line[len(tag[0]):])); match != nil {
+				currency = match[1]
+			}
+		}
+		if tag[1] != "61" {
+			continue
+		}
+
+		row++
+		value := strings.TrimSpace(line[len(tag[0]):])
+		match := mt940StatementLine.FindStringSubmatch(value)
+		if match == nil {
+			parsed.Rows = append(parsed.Rows, statementRow{
+				Row:    row,
+				Record: statementFields{ClientID: file.ClientID}.record(line),
+				Reject: "unreadable :61: statement line",
+			})
+			continue
+		}
+
+		mark := match[5]
+		fields := statementFields{
+			ID:          firstReference([]string{match[10], match[9]}),
+			ClientID:    file.ClientID,
+			Transaction: creditDebitType(strings.HasSuffix(mark, "C"), strings.HasPrefix(mark, "R")),
+			Amount:      strings.Replace(match[7], ",", ".", 1),
+			// Years are two digits; statements are taken to be from this century
+			Date:     fmt.Sprintf("20%s-%s-%s", match[1], match[2], match[3]),
+			Status:   "Completed",
+			Currency: currency,
+		}
+		parsed.Rows = append(parsed.Rows, normaliseRow(row, statementSource("mt940", account, file), fields, fields.record(line)))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return statement{}, fmt.Errorf("failed to read MT940: %v", err)
+	}
+	if !sawStatement {
+		return statement{}, fmt.Errorf("no :20: statement found in MT940 file")
+	}
+	return parsed, nil
+}
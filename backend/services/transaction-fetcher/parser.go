@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// StatementParser reads one bank statement format into transaction log records
+type StatementParser interface {
+	// Name identifies the format in logs
+	Name() string
+	// Extensions lists the file extensions the format is recognised by, lower case with the dot
+	Extensions() []string
+	// Sniff reports whether the start of a file looks like this format
+	Sniff(head []byte) bool
+	// Parse reads every transaction in the statement. The file's client directory is used
+	// for formats that do not name the client on each row.
+	Parse(r io.Reader, file SourceFile) (statement, error)
+}
+
+// parsers is the registry of supported statement formats, in sniffing order
+var parsers = []StatementParser{
+	csvParser{},
+	camt053Parser{},
+	mt940Parser{},
+	jsonLinesParser{},
+}
+
+// statement is a parsed file: the columns of its raw records and its rows
+type statement struct {
+	Header []string
+	Rows   []statementRow
+}
+
+// statementRow is one transaction read from a statement, normalised into a TransactionLog.
+// Reject is set when the row cannot be ingested; Record keeps the row as read, for quarantine.
+type statementRow struct {
+	Row         int
+	Record      []string
+	Log         TransactionLog
+	AmountMinor int64
+	AccountID   string
+	// Currency is the statement's currency code, upper case, or "" when the format has none
+	Currency string
+	Reject   string
+}
+
+// statementFields are a transaction's fields as text, the form every format is normalised to.
+// ID is the statement's own reference for the transaction, unique within its source.
+type statementFields struct {
+	ID          string
+	ClientID    string
+	Transaction string
+	Amount      string
+	Date        string
+	Status      string
+	AccountID   string
+	Currency    string
+}
+
+// normalisedHeader names the columns of records built from statementFields
+var normalisedHeader = []string{"ID", "ClientID", "Transaction", "Amount", "Date", "Status", "AccountID", "Currency", "Source"}
+
+// record lays the fields out under normalisedHeader, followed by the raw text they came from
+func (f statementFields) record(raw string) []string {
+	return []string{f.ID, f.ClientID, f.Transaction, f.Amount, f.Date, f.Status, f.AccountID, f.Currency, raw}
+}
+
+// Sources of statement rows. A row is identified by its source and its reference within it,
+// so references from different banks, accounts or providers cannot collide.
+const (
+	// sourceInternal is the fetcher's own CSV layout and JSON Lines, whose IDs come from internal systems
+	sourceInternal = "internal"
+)
+
+// statementSource scopes a format's references to the account a statement is for, falling
+// back to the file's client directory when the statement does not identify the account
+func statementSource(format, account string, file SourceFile) string {
+	if account = strings.TrimSpace(account); account == "" {
+		account = "client:" + file.ClientID
+	}
+	return format + ":" + account
+}
+
+// normaliseRow parses statement fields into a transaction log from the given source, or records why it cannot be
+func normaliseRow(row int, source string, fields statementFields, record []string) statementRow {
+	parsed := statementRow{
+		Row:       row,
+		Record:    record,
+		AccountID: fields.AccountID,
+		Currency:  strings.ToUpper(strings.TrimSpace(fields.Currency)),
+	}
+
+	reference := strings.TrimSpace(fields.ID)
+	if reference == "" {
+		parsed.Reject = "missing ID"
+		return parsed
+	}
+
+	// Amounts are kept in hundredths so the ledger never sees float rounding
+	amountMinor, err := parseMinorUnits(fields.Amount)
+	if err != nil {
+		parsed.Reject = err.Error()
+		return parsed
+	}
+
+	date, err := time.Parse(time.RFC3339, fields.Date)
+	if err != nil {
+		// Try alternative date formats if RFC3339 fails
+		date, err = tryParseDate(fields.Date)
+		if err != nil {
+			parsed.Reject = err.Error()
+			return parsed
+		}
+	}
+
+	parsed.AmountMinor = amountMinor
+	parsed.Log = TransactionLog{
+		Source:      source,
+		SourceRef:   reference,
+		ClientID:    fields.ClientID,
+		Transaction: fields.Transaction,
+		Amount:      float64(amountMinor) / 100,
+		Date:        date,
+		Status:      fields.Status,
+	}
+	return parsed
+}
+
+// detectParser picks the parser for a file by its extension, falling back to sniffing its contents
+func detectParser(name, path string) (StatementParser, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, parser := range parsers {
+		for _, known := range parser.Extensions() {
+			if ext == known {
+				return parser, nil
+			}
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	head := make([]byte, 4096)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+	head = bytes.TrimSpace(bytes.TrimPrefix(head[:n], []byte("\xef\xbb\xbf")))
+
+	for _, parser := range parsers {
+		if parser.Sniff(head) {
+			return parser, nil
+		}
+	}
+	return nil, fmt.Errorf("unrecognised statement format")
+}
+
+// isStatementFile reports whether a file name may hold a statement: a registered
+// extension, or .txt, which is sniffed
+func isStatementFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == ".txt" {
+		return true
+	}
+	for _, parser := range parsers {
+		for _, known := range parser.Extensions() {
+			if ext == known {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// csvParser reads CSV files through the mapping profile matching the file, by default the
+// fetcher's own layout: ID,ClientID,Transaction,Amount,Date,Status[,AccountID]
+type csvParser struct{}
+
+func (csvParser) Name() string         { return "csv" }
+func (csvParser) Extensions() []string { return []string{".csv"} }
+
+func (csvParser) Sniff(head []byte) bool {
+	return bytes.HasPrefix(head, []byte("ID,ClientID,"))
+}
+
+func (csvParser) Parse(r io.Reader, file SourceFile) (statement, error) {
+	return profileFor(file).parse(r, file)
+}
+
+// jsonLinesParser reads one JSON object per line, as emitted by internal systems:
+// {"id": 1001, "clientid": "client1", "transaction": "D", "amount": "12.50", "date": "...", "status": "Completed"}
+// client_id, transaction_type and transaction_date are accepted as alternative keys, and
+// account_id books the row to a specific account and currency names the amount's currency. Rows
+// without a client use the file's client directory.
+type jsonLinesParser struct{}
+
+func (jsonLinesParser) Name() string         { return "jsonl" }
+func (jsonLinesParser) Extensions() []string { return []string{".jsonl", ".ndjson"} }
+
+func (jsonLinesParser) Sniff(head []byte) bool {
+	return bytes.HasPrefix(head, []byte("{"))
+}
+
+func (jsonLinesParser) Parse(r io.Reader, file SourceFile) (statement, error) {
+	parsed := statement{Header: normalisedHeader}
+
+	// Rows are numbered by line, so blank lines still count
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for row := 1; scanner.Scan(); row++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var object map[string]interface{}
+		decoder := json.NewDecoder(strings.NewReader(line))
+		decoder.UseNumber()
+		if err := decoder.Decode(&object); err != nil {
+			parsed.Rows = append(parsed.Rows, statementRow{
+				Row:    row,
+				Record: statementFields{}.record(line),
+				Reject: fmt.Sprintf("invalid JSON: %v", err),
+			})
+			continue
+		}
+
+		fields := statementFields{
+			ID:          jsonField(object, "id"),
+			ClientID:    jsonField(object, "clientid", "client_id"),
+			Transaction: jsonField(object, "transaction", "transaction_type"),
+			Amount:      jsonField(object, "amount"),
+			Date:        jsonField(object, "date", "transaction_date"),
+			Status:      jsonField(object, "status"),
+			AccountID:   jsonField(object, "account_id"),
+			Currency:    jsonField(object, "currency"),
+		}
+		if fields.ClientID == "" {
+			fields.ClientID = file.ClientID
+		}
+		parsed.Rows = append(parsed.Rows, normaliseRow(row, sourceInternal, fields, fields.record(line)))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return statement{}, fmt.Errorf("failed to read JSON Lines: %v", err)
+	}
+	return parsed, nil
+}
+
+// jsonField returns the first of the keys present in the object, as text
+func jsonField(object map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := object[key]; ok && value != nil {
+			return fmt.Sprint(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wantRow is what a fixture row should parse into; reject, when set, is the start of the
+// expected reject reason and the other fields are not checked
+type wantRow struct {
+	row         int
+	reject      string
+	source      string
+	ref         string
+	clientID    string
+	transaction string
+	amountMinor int64
+	date        time.Time
+	status      string
+	accountID   string
+	currency    string
+}
+
+func parseFixture(t *testing.T, name, clientID string) (StatementParser, statement) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	parser, err := detectParser(name, path)
+	if err != nil {
+		t.Fatalf("detectParser(%s): %v", name, err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	parsed, err := parser.Parse(file, SourceFile{ClientID: clientID, Name: name, Path: path})
+	if err != nil {
+		t.Fatalf("%s.Parse(%s): %v", parser.Name(), name, err)
+	}
+	return parser, parsed
+}
+
+func checkRows(t *testing.T, rows []statementRow, want []wantRow) {
+	t.Helper()
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d: %+v", len(rows), len(want), rows)
+	}
+
+	for i, w := range want {
+		got := rows[i]
+		if got.Row != w.row {
+			t.Errorf("row %d: numbered %d", w.row, got.Row)
+		}
+		if w.reject != "" {
+			if !strings.HasPrefix(got.Reject, w.reject) {
+				t.Errorf("row %d: reject = %q, want %q", w.row, got.Reject, w.reject)
+			}
+			if len(got.Record) == 0 {
+				t.Errorf("row %d: rejected without its record", w.row)
+			}
+			continue
+		}
+		if got.Reject != "" {
+			t.Errorf("row %d: rejected: %s", w.row, got.Reject)
+			continue
+		}
+
+		l := got.Log
+		if l.Source != w.source || l.SourceRef != w.ref {
+			t.Errorf("row %d: identified as %s/%s, want %s/%s", w.row, l.Source, l.SourceRef, w.source, w.ref)
+		}
+		if l.ClientID != w.clientID || l.Transaction != w.transaction || l.Status != w.status {
+			t.Errorf("row %d: client %s, type %s, status %s; want %s, %s, %s",
+				w.row, l.ClientID, l.Transaction, l.Status, w.clientID, w.transaction, w.status)
+		}
+		if got.AmountMinor != w.amountMinor {
+			t.Errorf("row %d: amount %d, want %d", w.row, got.AmountMinor, w.amountMinor)
+		}
+		if !l.Date.Equal(w.date) {
+			t.Errorf("row %d: date %s, want %s", w.row, l.Date, w.date)
+		}
+		if got.AccountID != w.accountID || got.Currency != w.currency {
+			t.Errorf("row %d: account %q in %q, want %q in %q", w.row, got.AccountID, got.Currency, w.accountID, w.currency)
+		}
+	}
+}
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseCSV(t *testing.T) {
+	parser, parsed := parseFixture(t, "statement.csv", "client1")
+	if parser.Name() != "csv" {
+		t.Fatalf("parsed as %s", parser.Name())
+	}
+	if parsed.Header[0] != "ID" || parsed.Header[len(parsed.Header)-1] != "Currency" {
+		t.Errorf("header = %v", parsed.Header)
+	}
+
+	checkRows(t, parsed.Rows, []wantRow{
+		{row: 1, source: sourceInternal, ref: "1001", clientID: "client1", transaction: "D", amountMinor: 1250,
+			date: time.Date(2025, 3, 1, 10, 15, 0, 0, time.UTC), status: "Completed", currency: "SGD"},
+		{row: 2, source: sourceInternal, ref: "1002", clientID: "client1", transaction: "W", amountMinor: 700,
+			date: time.Date(2025, 3, 1, 11, 0, 0, 0, time.UTC), status: "Completed", accountID: "3"},
+		{row: 3, reject: `invalid amount "abc"`},
+		{row: 4, reject: "missing ID"},
+		{row: 5, reject: "unable to parse date: yesterday"},
+	})
+}
+
+func TestParseCamt053(t *testing.T) {
+	parser, parsed := parseFixture(t, "statement.camt.xml", "client7")
+	if parser.Name() != "camt.053" {
+		t.Fatalf("parsed as %s", parser.Name())
+	}
+
+	source := "camt.053:SG12BANK0000123456"
+	checkRows(t, parsed.Rows, []wantRow{
+		// The account servicer's reference wins over NtryRef
+		{row: 1, source: source, ref: "BANKREF-0001", clientID: "client7", transaction: "D", amountMinor: 25000,
+			date: day(2025, 3, 1), status: "Completed", currency: "SGD"},
+		// Without one, the transaction reference; the value date stands in for a booking date
+		{row: 2, source: source, ref: "TX-778", clientID: "client7", transaction: "W", amountMinor: 4010,
+			date: time.Date(2025, 3, 1, 12, 30, 0, 0, time.UTC), status: "Pending", currency: "USD"},
+		{row: 3, reject: "missing ID"},
+	})
+}
+
+func TestParseMT940(t *testing.T) {
+	parser, parsed := parseFixture(t, "statement.sta", "client7")
+	if parser.Name() != "mt940" {
+		t.Fatalf("parsed as %s", parser.Name())
+	}
+
+	source := "mt940:SG12BANK0000123456"
+	checkRows(t, parsed.Rows, []wantRow{
+		{row: 1, source: source, ref: "BANKREF-0001", clientID: "client7", transaction: "D", amountMinor: 25000,
+			date: day(2025, 3, 1), status: "Completed", currency: "SGD"},
+		// A reversed debit is a deposit
+		{row: 2, source: source, ref: "BANKREF-0002", clientID: "client7", transaction: "D", amountMinor: 4010,
+			date: day(2025, 3, 1), status: "Completed", currency: "SGD"},
+		{row: 3, reject: "unreadable :61: statement line"},
+	})
+}
+
+func TestParseJSONLines(t *testing.T) {
+	parser, parsed := parseFixture(t, "statement.jsonl", "client9")
+	if parser.Name() != "jsonl" {
+		t.Fatalf("parsed as %s", parser.Name())
+	}
+
+	checkRows(t, parsed.Rows, []wantRow{
+		{row: 1, source: sourceInternal, ref: "2001", clientID: "client1", transaction: "D", amountMinor: 1250,
+			date: time.Date(2025, 3, 1, 10, 15, 0, 0, time.UTC), status: "Completed", currency: "SGD"},
+		// Line 2 is blank; alternative keys, and the file's client when the row names none
+		{row: 3, source: sourceInternal, ref: "2002", clientID: "client9", transaction: "W", amountMinor: 320,
+			date: day(2025, 3, 1), status: "Pending", accountID: "4"},
+		{row: 4, reject: "invalid JSON"},
+		{row: 5, reject: `invalid amount "-4.00"`},
+	})
+}
+
+func TestStatementSourceFallsBackToClient(t *testing.T) {
+	file := SourceFile{ClientID: "client7"}
+	if got := statementSource("camt.053", " ", file); got != "camt.053:client:client7" {
+		t.Errorf("source without an account = %q", got)
+	}
+	if got := statementSource("mt940", "SG12BANK0000123456", file); got != "mt940:SG12BANK0000123456" {
+		t.Errorf("source with an account = %q", got)
+	}
+}
+
+func TestDetectParserByExtension(t *testing.T) {
+	// The extension decides, whatever the content
+	empty := filepath.Join(t.TempDir(), "empty")
+	if err := os.WriteFile(empty, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"statement.csv":    "csv",
+		"STATEMENT.CSV":    "csv",
+		"statement.xml":    "camt.053",
+		"statement.053":    "camt.053",
+		"statement.sta":    "mt940",
+		"statement.940":    "mt940",
+		"statement.jsonl":  "jsonl",
+		"statement.ndjson": "jsonl",
+	}
+	for name, want := range tests {
+		parser, err := detectParser(name, empty)
+		if err != nil {
+			t.Errorf("detectParser(%s): %v", name, err)
+			continue
+		}
+		if parser.Name() != want {
+			t.Errorf("detectParser(%s) = %s, want %s", name, parser.Name(), want)
+		}
+	}
+}
+
+func TestDetectParserByContent(t *testing.T) {
+	tests := map[string]string{
+		"statement.csv":      "csv",
+		"statement.camt.xml": "camt.053",
+		"statement.sta":      "mt940",
+		"statement.jsonl":    "jsonl",
+	}
+	for fixture, want := range tests {
+		parser, err := detectParser("statement.txt", filepath.Join("testdata", fixture))
+		if err != nil {
+			t.Errorf("sniffing %s: %v", fixture, err)
+			continue
+		}
+		if parser.Name() != want {
+			t.Errorf("sniffed %s as %s, want %s", fixture, parser.Name(), want)
+		}
+	}
+
+	// A byte order mark does not hide the format
+	withBOM := filepath.Join(t.TempDir(), "bom.txt")
+	if err := os.WriteFile(withBOM, []byte("\xef\xbb\xbfID,ClientID,Transaction\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if parser, err := detectParser("bom.txt", withBOM); err != nil || parser.Name() != "csv" {
+		t.Errorf("sniffing a CSV with a byte order mark = %v, %v", parser, err)
+	}
+
+	unknown := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(unknown, []byte("nothing to see here"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := detectParser("notes.txt", unknown); err == nil {
+		t.Error("detectParser accepted an unrecognised file")
+	}
+}
+
+func TestIsStatementFile(t *testing.T) {
+	for name, want := range map[string]bool{
+		"a.csv": true, "a.XML": true, "a.sta": true, "a.ndjson": true, "a.txt": true,
+		"a.pdf": false, "a": false, "a.csv.part": false,
+	} {
+		if got := isStatementFile(name); got != want {
+			t.Errorf("isStatementFile(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
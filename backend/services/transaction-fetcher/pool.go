@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// poolConfig bounds how files are processed in parallel
+type poolConfig struct {
+	Workers         int
+	FileTimeout     time.Duration
+	ShutdownTimeout time.Duration
+}
+
+// InFlightFile is a file a worker is processing
+type InFlightFile struct {
+	ClientID  string    `json:"client_id"`
+	Name      string    `json:"name"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// fileOutcome is what processing one file contributed to a cycle
+type fileOutcome struct {
+	Processed bool
+	Errors    int
+	Rows      int
+}
+
+// loadPoolConfig reads FETCHER_WORKERS (default 4), FETCHER_FILE_TIMEOUT (default 2m) and
+// FETCHER_SHUTDOWN_TIMEOUT (default 30s), how long in-flight files may finish at shutdown
+func loadPoolConfig() (poolConfig, error) {
+	cfg := poolConfig{Workers: 4, FileTimeout: 2 * time.Minute, ShutdownTimeout: 30 * time.Second}
+
+	if raw := os.Getenv("FETCHER_WORKERS"); raw != "" {
+		workers, err := strconv.Atoi(raw)
+		if err != nil || workers < 1 {
+			return poolConfig{}, fmt.Errorf("FETCHER_WORKERS must be a positive integer, got %q", raw)
+		}
+		cfg.Workers = workers
+	}
+
+	if raw := os.Getenv("FETCHER_FILE_TIMEOUT"); raw != "" {
+		timeout, err := time.ParseDuration(raw)
+		if err != nil || timeout <= 0 {
+			return poolConfig{}, fmt.Errorf("FETCHER_FILE_TIMEOUT must be a positive duration such as 90s, got %q", raw)
+		}
+		cfg.FileTimeout = timeout
+	}
+
+	if raw := os.Getenv("FETCHER_SHUTDOWN_TIMEOUT"); raw != "" {
+		timeout, err := time.ParseDuration(raw)
+		if err != nil || timeout <= 0 {
+			return poolConfig{}, fmt.Errorf("FETCHER_SHUTDOWN_TIMEOUT must be a positive duration such as 30s, got %q", raw)
+		}
+		cfg.ShutdownTimeout = timeout
+	}
+
+	return cfg, nil
+}
+
+// cycleTotals adds up the outcomes of a cycle's files
+type cycleTotals struct {
+	Files  int
+	Errors int
+	Rows   int
+}
+
+// runPool hands files to a fixed number of workers, each file under its own timeout. Files are
+// handed over one at a time, so listing never runs ahead of the workers, and no new file is
+// started once ctx is cancelled. Files already started run under workCtx, so they can finish
+// while the service drains and are only rolled back once workCtx is cancelled too.
+func runPool(ctx, workCtx context.Context, cfg poolConfig, files []SourceFile, work func(ctx context.Context, file SourceFile) fileOutcome) cycleTotals {
+	var totals cycleTotals
+	var totalsMutex sync.Mutex
+	var wg sync.WaitGroup
+
+	jobs := make(chan SourceFile)
+	for i := 0; i < cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range jobs {
+				fileCtx, cancel := context.WithTimeout(workCtx, cfg.FileTimeout)
+				startInFlight(file)
+				outcome := work(fileCtx, file)
+				finishInFlight(file)
+				cancel()
+
+				totalsMutex.Lock()
+				if outcome.Processed {
+					totals.Files++
+					totals.Rows += outcome.Rows
+				}
+				totals.Errors += outcome.Errors
+				totalsMutex.Unlock()
+			}
+		}()
+	}
+
+dispatch:
+	for _, file := range files {
+		select {
+		case jobs <- file:
+		case <-ctx.Done():
+			log.Printf("Shutdown requested, leaving %s/%s for the next run", file.ClientID, file.Name)
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return totals
+}
+
+// processFile downloads, ingests and archives one file
+func processFile(ctx context.Context, source TransactionSource, db *sql.DB, tempDir string, file SourceFile) fileOutcome {
+	var outcome fileOutcome
+	log.Printf("Processing file: %s/%s", file.ClientID, file.Name)
+
+	localFilePath := filepath.Join(tempDir, file.ClientID+"_"+file.Name)
+
+	// Download the file
+	err := source.Fetch(file, localFilePath)
+	if err != nil {
+		log.Printf("Error downloading file %s/%s: %v", file.ClientID, file.Name, err)
+		outcome.Errors++
+		return outcome
+	}
+	defer os.Remove(localFilePath)
+
+	// Record the file in the manifest, keyed by name and content
+	contentHash, err := hashFile(localFilePath)
+	if err != nil {
+		log.Printf("Error hashing file %s/%s: %v", file.ClientID, file.Name, err)
+		outcome.Errors++
+		return outcome
+	}
+
+	manifestID, committed, err := beginIngest(db, file, contentHash)
+	if err != nil {
+		log.Printf("Error recording file %s/%s: %v", file.ClientID, file.Name, err)
+		outcome.Errors++
+		return outcome
+	}
+
+	// The same content was committed before but not moved; only finish the move
+	if committed {
+		log.Printf("File %s/%s was already ingested, archiving without reapplying", file.ClientID, file.Name)
+		if err := source.Archive(file); err != nil {
+			log.Printf("Warning: Could not move processed file %s/%s: %v", file.ClientID, file.Name, err)
+			outcome.Errors++
+		}
+		return outcome
+	}
+
+	// Process the downloaded file
+	result, err := processLogFile(ctx, localFilePath, file, manifestID, db)
+	if err != nil {
+		log.Printf("Error processing file %s/%s: %v", file.ClientID, file.Name, err)
+		if err := failIngest(db, manifestID, err); err != nil {
+			log.Println(err)
+		}
+		outcome.Errors++
+		return outcome
+	}
+
+	// Keep the rejected rows next to the processed file
+	rowsRejectedTotal.Add(float64(len(result.Rejects)))
+	if len(result.Rejects) > 0 {
+		rejectsPath := filepath.Join(tempDir, file.ClientID+"_"+rejectsFileName(file.Name))
+		err = writeRejectsFile(rejectsPath, result.Header, result.Rejects)
+		if err == nil {
+			err = source.StoreRejects(file, rejectsPath)
+		}
+		if err != nil {
+			log.Printf("Warning: Could not store rejects for %s/%s: %v", file.ClientID, file.Name, err)
+			outcome.Errors++
+		}
+	}
+
+	// Move to processed directory
+	err = source.Archive(file)
+	if err != nil {
+		log.Printf("Warning: Could not move processed file %s/%s: %v", file.ClientID, file.Name, err)
+		outcome.Errors++
+	}
+
+	log.Printf("Successfully processed %s/%s", file.ClientID, file.Name)
+	outcome.Processed = true
+	outcome.Rows = result.Inserted
+	return outcome
+}
+
+func startInFlight(file SourceFile) {
+	statusMutex.Lock()
+	defer statusMutex.Unlock()
+	status.InFlightFiles = append(status.InFlightFiles, InFlightFile{ClientID: file.ClientID, Name: file.Name, StartedAt: time.Now()})
+	inFlightFilesGauge.Inc()
+}
+
+func finishInFlight(file SourceFile) {
+	statusMutex.Lock()
+	defer statusMutex.Unlock()
+	for i, inFlight := range status.InFlightFiles {
+		if inFlight.ClientID == file.ClientID && inFlight.Name == file.Name {
+			status.InFlightFiles = append(status.InFlightFiles[:i], status.InFlightFiles[i+1:]...)
+			inFlightFilesGauge.Dec()
+			return
+		}
+	}
+}
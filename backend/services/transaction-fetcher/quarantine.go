@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// rowReject is a CSV row that could not be ingested
+type rowReject struct {
+	Row    int
+	Record []string
+	Reason string
+}
+
+// RejectedRow is a quarantined row as returned by the API
+type RejectedRow struct {
+	ID        int       `json:"id"`
+	Row       int       `json:"row"`
+	RawRecord string    `json:"raw_record"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FileErrors is the quarantined rows of one manifest entry. Files that reuse a name are
+// different entries, each with its own rows.
+type FileErrors struct {
+	ManifestID  int64         `json:"manifest_id"`
+	FileName    string        `json:"file_name"`
+	ClientID    string        `json:"client_id"`
+	ContentHash string        `json:"content_hash"`
+	Status      string        `json:"status"`
+	Rows        []RejectedRow `json:"rows"`
+}
+
+// maxReasonLength is the size of transaction_rejects.reason, in characters
+const maxReasonLength = 512
+
+func ensureQuarantineTableExists(db *sql.DB) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS transaction_rejects (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			manifest_id BIGINT NULL,
+			file_name VARCHAR(255) NOT NULL,
+			clientid VARCHAR(255) NOT NULL,
+			row_num INT NOT NULL,
+			raw_record TEXT NOT NULL,
+			reason VARCHAR(512) NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			KEY idx_transaction_rejects_file (file_name, clientid),
+			KEY idx_transaction_rejects_manifest (manifest_id)
+		)
+	`
+	_, err := db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create quarantine table: %v", err)
+	}
+
+	// Tables from before rejects were keyed by manifest entry: rows of a file are credited to the
+	// latest committed entry under its name, the only one whose rows were kept
+	if err := ensureColumnExists(db, "transaction_rejects", "manifest_id", "BIGINT NULL AFTER id"); err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		UPDATE transaction_rejects r
+		JOIN (
+			SELECT clientid, file_name, MAX(id) AS id FROM ingested_files
+			WHERE status = 'committed' GROUP BY clientid, file_name
+		) m ON m.clientid = r.clientid AND m.file_name = r.file_name
+		SET r.manifest_id = m.id
+		WHERE r.manifest_id IS NULL`)
+	if err != nil {
+		return fmt.Errorf("failed to link quarantined rows to the manifest: %v", err)
+	}
+	return ensureIndexExists(db, "transaction_rejects", "idx_transaction_rejects_manifest",
+		"KEY idx_transaction_rejects_manifest (manifest_id)")
+}
+
+// quarantineRows records the rows of a manifest entry that could not be ingested. Rows left by
+// an earlier attempt at the same entry are replaced; other files under the same name keep theirs.
+func quarantineRows(tx *sql.Tx, manifestID int64, fileName, clientID string, rejects []rowReject) error {
+	_, err := tx.Exec(`DELETE FROM transaction_rejects WHERE manifest_id = ?`, manifestID)
+	if err != nil {
+		return fmt.Errorf("failed to clear quarantined rows: %v", err)
+	}
+
+	for _, reject := range rejects {
+		_, err := tx.Exec(
+			`INSERT INTO transaction_rejects (manifest_id, file_name, clientid, row_num, raw_record, reason) VALUES (?, ?, ?, ?, ?, ?)`,
+			manifestID, fileName, clientID, reject.Row, rawRecord(reject.Record), truncateReason(reject.Reason),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to quarantine row %d: %v", reject.Row, err)
+		}
+	}
+	return nil
+}
+
+// rowErrors are the MySQL errors caused by the values of the row being inserted. The statement
+// fails but the transaction goes on, so the row can be quarantined and the file carried on with.
+var rowErrors = map[uint16]bool{
+	1048: true, // column cannot be null
+	1062: true, // duplicate entry
+	1264: true, // out of range value
+	1265: true, // data truncated
+	1292: true, // incorrect date or datetime value
+	1366: true, // incorrect value for column
+	1406: true, // data too long for column
+	1452: true, // foreign key constraint fails
+	3819: true, // check constraint violated
+}
+
+// isRowError reports whether an insert failed because of the row itself. Anything else, such as
+// a deadlock or a lost connection, may have rolled back the transaction and fails the whole file.
+func isRowError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && rowErrors[mysqlErr.Number]
+}
+
+// truncateReason shortens a reason to fit transaction_rejects.reason
+func truncateReason(reason string) string {
+	runes := []rune(reason)
+	if len(runes) <= maxReasonLength {
+		return reason
+	}
+	return string(runes[:maxReasonLength-3]) + "..."
+}
+
+// writeRejectsFile writes the rejected rows as CSV: the row number and reason, then the original columns
+func writeRejectsFile(path string, header []string, rejects []rowReject) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create rejects file: %v", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Write(append([]string{"Row", "Reason"}, header...))
+	for _, reject := range rejects {
+		writer.Write(append([]string{strconv.Itoa(reject.Row), reject.Reason}, reject.Record...))
+	}
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write rejects file: %v", err)
+	}
+	return nil
+}
+
+// rejectsFileName names the rejects file stored next to a processed file
+func rejectsFileName(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ".rejects.csv"
+}
+
+// rawRecord re-encodes a record as the CSV line it was read from
+func rawRecord(record []string) string {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write(record)
+	writer.Flush()
+	return strings.TrimRight(buf.String(), "\r\n")
+}
+
+// getFileErrorsHandler serves GET /files/{name}/errors, optionally narrowed with ?client_id=.
+// Rows are grouped by manifest entry, oldest first, so files that reuse a name stay apart.
+func getFileErrorsHandler(w http.ResponseWriter, r *http.Request) {
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Expect /files/{name}/errors
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 3 || pathParts[2] != "errors" || pathParts[1] == "" {
+		http.Error(w, "Invalid URL path", http.StatusNotFound)
+		return
+	}
+	fileName := pathParts[1]
+
+	db, err := sql.Open("mysql", databaseDSN())
+	if err != nil {
+		log.Println("Failed to connect to database:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	query := `
+		SELECT m.id, m.file_name, m.clientid, m.content_hash, m.status,
+			r.id, r.row_num, r.raw_record, r.reason, r.created_at
+		FROM transaction_rejects r
+		JOIN ingested_files m ON m.id = r.manifest_id
+		WHERE m.file_name = ?`
+	args := []interface{}{fileName}
+	if clientID := r.URL.Query().Get("client_id"); clientID != "" {
+		query += ` AND m.clientid = ?`
+		args = append(args, clientID)
+	}
+	query += ` ORDER BY m.id, r.row_num`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		log.Println("Failed to query quarantined rows:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	files := []FileErrors{}
+	for rows.Next() {
+		var entry FileErrors
+		var row RejectedRow
+		err := rows.Scan(&entry.ManifestID, &entry.FileName, &entry.ClientID, &entry.ContentHash, &entry.Status,
+			&row.ID, &row.Row, &row.RawRecord, &row.Reason, &row.CreatedAt)
+		if err != nil {
+			log.Println("Failed to scan quarantined row:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if len(files) == 0 || files[len(files)-1].ManifestID != entry.ManifestID {
+			files = append(files, entry)
+		}
+		last := &files[len(files)-1]
+		last.Rows = append(last.Rows, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Println("Error iterating over quarantined rows:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(files)
+}
+
+// databaseDSN builds the DSN of the transactions database
+func databaseDSN() string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
+		os.Getenv("DB_USER"),
+		os.Getenv("DB_PASSWORD"),
+		os.Getenv("DB_HOST"),
+		os.Getenv("DB_PORT"),
+		os.Getenv("DB_NAME"))
+}
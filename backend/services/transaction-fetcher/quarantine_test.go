@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestIsRowError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "duplicate entry", err: &mysql.MySQLError{Number: 1062}, want: true},
+		{name: "data too long", err: fmt.Errorf("exec: %w", &mysql.MySQLError{Number: 1406}), want: true},
+		{name: "deadlock", err: &mysql.MySQLError{Number: 1213}},
+		{name: "lock wait timeout", err: &mysql.MySQLError{Number: 1205}},
+		{name: "bad connection", err: mysql.ErrInvalidConn},
+		{name: "not a MySQL error", err: errors.New("context canceled")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRowError(tt.err); got != tt.want {
+				t.Errorf("isRowError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateReason(t *testing.T) {
+	if got := truncateReason("bad amount"); got != "bad amount" {
+		t.Errorf("truncateReason changed a short reason to %q", got)
+	}
+
+	long := "insert failed: " + strings.Repeat("é", 1000)
+	got := truncateReason(long)
+	if n := utf8.RuneCountInString(got); n != maxReasonLength {
+		t.Errorf("truncated reason has %d characters, want %d", n, maxReasonLength)
+	}
+	if !utf8.ValidString(got) || !strings.HasSuffix(got, "...") {
+		t.Errorf("truncated reason %q is not valid UTF-8 ending in ...", got[len(got)-10:])
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ReconciliationReport compares one day of ingested transactions with the clients,
+// accounts and ledger they should be booked to. Days are UTC calendar days.
+type ReconciliationReport struct {
+	Date               string              `json:"date"`
+	GeneratedAt        time.Time           `json:"generated_at"`
+	TransactionCount   int                 `json:"transaction_count"`
+	UnknownClients     []UnknownClient     `json:"unknown_clients"`
+	OrphanTransactions []OrphanTransaction `json:"orphan_transactions"`
+	Clients            []ClientTotals      `json:"clients"`
+}
+
+// UnknownClient is a client ID with transactions but no row in the client table,
+// e.g. a client deleted after its transactions were ingested
+type UnknownClient struct {
+	ClientID     string `json:"client_id"`
+	Transactions int    `json:"transactions"`
+}
+
+// OrphanTransaction is a transaction of a known client that is not booked to an active account
+type OrphanTransaction struct {
+	ID          int    `json:"id"`
+	ClientID    string `json:"client_id"`
+	Transaction string `json:"transaction"`
+	Amount      string `json:"amount"`
+	Status      string `json:"status"`
+	Reason      string `json:"reason"`
+}
+
+// ClientTotals compares a client's completed deposits less withdrawals with what reached the ledger
+type ClientTotals struct {
+	ClientID     string `json:"client_id"`
+	Transactions int    `json:"transactions"`
+	Expected     string `json:"expected"`
+	Ledger       string `json:"ledger"`
+	Difference   string `json:"difference"`
+	Matched      bool   `json:"matched"`
+}
+
+func ensureReconciliationTableExists(db *sql.DB) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS reconciliation_reports (
+			report_date DATE PRIMARY KEY,
+			generated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			report JSON NOT NULL
+		)
+	`
+	_, err := db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create reconciliation table: %v", err)
+	}
+	return nil
+}
+
+// buildReconciliationReport reconciles the transactions dated on the given day
+func buildReconciliationReport(db *sql.DB, day time.Time) (ReconciliationReport, error) {
+	start := day.UTC().Truncate(24 * time.Hour)
+	end := start.AddDate(0, 0, 1)
+
+	report := ReconciliationReport{
+		Date:               start.Format("2006-01-02"),
+		GeneratedAt:        time.Now().UTC(),
+		UnknownClients:     []UnknownClient{},
+		OrphanTransactions: []OrphanTransaction{},
+		Clients:            []ClientTotals{},
+	}
+
+	err := db.QueryRow(
+		`SELECT COUNT(*) FROM transaction_logs WHERE transaction_date >= ? AND transaction_date < ?`,
+		start, end).Scan(&report.TransactionCount)
+	if err != nil {
+		return report, fmt.Errorf("failed to count transactions: %v", err)
+	}
+
+	// Transactions whose client is not in the client table
+	rows, err := db.Query(`
+		SELECT t.clientid, COUNT(*)
+		FROM transaction_logs t
+		LEFT JOIN client c ON c.client_id = t.clientid
+		WHERE c.client_id IS NULL AND t.transaction_date >= ? AND t.transaction_date < ?
+		GROUP BY t.clientid
+		ORDER BY t.clientid`, start, end)
+	if err != nil {
+		return report, fmt.Errorf("failed to find unknown clients: %v", err)
+	}
+	for rows.Next() {
+		var unknown UnknownClient
+		if err := rows.Scan(&unknown.ClientID, &unknown.Transactions); err != nil {
+			rows.Close()
+			return report, fmt.Errorf("error scanning unknown client: %v", err)
+		}
+		report.UnknownClients = append(report.UnknownClients, unknown)
+	}
+	rows.Close()
+
+	// Transactions of known clients with no account, or whose account has since been closed
+	rows, err = db.Query(`
+		SELECT t.id, t.clientid, t.transaction_type, CAST(ROUND(t.amount * 100) AS SIGNED), t.status,
+			CASE
+				WHEN t.account_id IS NULL THEN 'no account linked'
+				WHEN a.account_id IS NULL THEN 'linked account no longer exists'
+				ELSE 'linked account is inactive'
+			END
+		FROM transaction_logs t
+		JOIN client c ON c.client_id = t.clientid
+		LEFT JOIN account a ON a.account_id = t.account_id
+		WHERE (t.account_id IS NULL OR a.account_id IS NULL OR a.is_active = FALSE)
+			AND t.transaction_date >= ? AND t.transaction_date < ?
+		ORDER BY t.id`, start, end)
+	if err != nil {
+		return report, fmt.Errorf("failed to find orphan transactions: %v", err)
+	}
+	for rows.Next() {
+		var orphan OrphanTransaction
+		var amount int64
+		err := rows.Scan(&orphan.ID, &orphan.ClientID, &orphan.Transaction, &amount, &orphan.Status, &orphan.Reason)
+		if err != nil {
+			rows.Close()
+			return report, fmt.Errorf("error scanning orphan transaction: %v", err)
+		}
+		orphan.Amount = formatMinorUnits(amount)
+		report.OrphanTransactions = append(report.OrphanTransactions, orphan)
+	}
+	rows.Close()
+
+	// Completed deposits less withdrawals per client against the account postings they produced
+	rows, err = db.Query(`
+		SELECT t.clientid, COUNT(*),
+			CAST(COALESCE(SUM(CASE
+				WHEN t.status <> 'Completed' THEN 0
+				WHEN UPPER(t.transaction_type) IN ('D', 'DEPOSIT') THEN ROUND(t.amount * 100)
+				WHEN UPPER(t.transaction_type) IN ('W', 'WITHDRAWAL') THEN -ROUND(t.amount * 100)
+				ELSE 0
+			END), 0) AS SIGNED),
+			CAST(COALESCE(SUM(p.amount), 0) AS SIGNED)
+		FROM transaction_logs t
+		LEFT JOIN journal_entries je ON je.source = ? AND je.source_ref = CAST(t.id AS CHAR)
+		LEFT JOIN ledger_postings p ON p.entry_id = je.id AND p.account_id IS NOT NULL
+		WHERE t.transaction_date >= ? AND t.transaction_date < ?
+		GROUP BY t.clientid
+		ORDER BY t.clientid`, ledgerSource, start, end)
+	if err != nil {
+		return report, fmt.Errorf("failed to total transactions: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var totals ClientTotals
+		var expected, posted int64
+		if err := rows.Scan(&totals.ClientID, &totals.Transactions, &expected, &posted); err != nil {
+			return report, fmt.Errorf("error scanning client totals: %v", err)
+		}
+		totals.Expected = formatMinorUnits(expected)
+		totals.Ledger = formatMinorUnits(posted)
+		totals.Difference = formatMinorUnits(expected - posted)
+		totals.Matched = expected == posted
+		report.Clients = append(report.Clients, totals)
+	}
+
+	return report, rows.Err()
+}
+
+// loadReconciliationReport returns the stored report for a day, if there is one
+func loadReconciliationReport(db *sql.DB, day time.Time) (*ReconciliationReport, error) {
+	var raw []byte
+	err := db.QueryRow(`SELECT report FROM reconciliation_reports WHERE report_date = ?`,
+		day.UTC().Format("2006-01-02")).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load reconciliation report: %v", err)
+	}
+
+	var report ReconciliationReport
+	if err := json.Unmarshal(raw, &report); err != nil {
+		return nil, fmt.Errorf("failed to decode reconciliation report: %v", err)
+	}
+	return &report, nil
+}
+
+// storeReconciliationReport saves a report for a day that is over
+func storeReconciliationReport(db *sql.DB, report ReconciliationReport) error {
+	raw, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode reconciliation report: %v", err)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO reconciliation_reports (report_date, report) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE report = VALUES(report), generated_at = CURRENT_TIMESTAMP`,
+		report.Date, raw)
+	if err != nil {
+		return fmt.Errorf("failed to store reconciliation report: %v", err)
+	}
+	return nil
+}
+
+// ensureDailyReconciliation produces yesterday's report once, after the day has ended
+func ensureDailyReconciliation(db *sql.DB) error {
+	yesterday := time.Now().UTC().AddDate(0, 0, -1)
+
+	existing, err := loadReconciliationReport(db, yesterday)
+	if err != nil || existing != nil {
+		return err
+	}
+
+	report, err := buildReconciliationReport(db, yesterday)
+	if err != nil {
+		return err
+	}
+	log.Printf("Reconciliation for %s: %d transactions, %d unknown clients, %d orphan transactions",
+		report.Date, report.TransactionCount, len(report.UnknownClients), len(report.OrphanTransactions))
+	return storeReconciliationReport(db, report)
+}
+
+// getReconciliationHandler serves GET /reconciliation?date=YYYY-MM-DD, defaulting to yesterday.
+// Reports for past days are stored the first time they are built; today's is always rebuilt.
+func getReconciliationHandler(w http.ResponseWriter, r *http.Request) {
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	day := today.AddDate(0, 0, -1)
+	if raw := r.URL.Query().Get("date"); raw != "" {
+		parsed, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			http.Error(w, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		day = parsed
+	}
+
+	db, err := sql.Open("mysql", databaseDSN())
+	if err != nil {
+		log.Println("Failed to connect to database:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	report, err := loadReconciliationReport(db, day)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if report == nil {
+		built, err := buildReconciliationReport(db, day)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if day.Before(today) {
+			if err := storeReconciliationReport(db, built); err != nil {
+				log.Println(err)
+			}
+		}
+		report = &built
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// SourceFile is a transaction log file waiting in a client's directory
+type SourceFile struct {
+	ClientID string
+	Name     string
+	Path     string
+}
+
+// TransactionSource is where transaction log files are picked up from. Files are laid out
+// as <root>/<clientID>/<file>, the layout sftp/transaction_generator.py writes.
+type TransactionSource interface {
+	// List returns the files waiting to be processed. Directories that cannot be read are
+	// reported in the error alongside the files that could be listed.
+	List() ([]SourceFile, error)
+	// Fetch copies a file to a local path
+	Fetch(file SourceFile, localPath string) error
+	// Archive moves a processed file to the processed directory so it is not picked up again
+	Archive(file SourceFile) error
+	// StoreRejects uploads the rejects file of a processed file into the processed directory
+	StoreRejects(file SourceFile, localPath string) error
+	Close() error
+}
+
+// SourceConfig selects and configures the transaction source
+type SourceConfig struct {
+	Type           string // "sftp" or "local"
+	SFTPServer     string
+	Username       string
+	PrivateKeyPath string
+	RootPath       string
+	ProcessedPath  string
+}
+
+// loadSourceConfig reads the source configuration from the environment.
+// TRANSACTION_SOURCE=local reads from LOCAL_LOG_PATH instead of the SFTP server.
+func loadSourceConfig() (SourceConfig, error) {
+	cfg := SourceConfig{Type: strings.ToLower(os.Getenv("TRANSACTION_SOURCE"))}
+	if cfg.Type == "" {
+		cfg.Type = "sftp"
+	}
+
+	switch cfg.Type {
+	case "sftp":
+		cfg.SFTPServer = os.Getenv("SFTP_SERVER")
+		cfg.Username = os.Getenv("SFTP_USERNAME")
+		cfg.PrivateKeyPath = os.Getenv("SFTP_PRIVATE_KEY")
+		cfg.RootPath = os.Getenv("SFTP_REMOTE_LOG_PATH")
+		cfg.ProcessedPath = os.Getenv("SFTP_PROCESSED_PATH")
+
+		// Expand ~ to home directory in privateKeyPath if needed
+		if len(cfg.PrivateKeyPath) > 0 && cfg.PrivateKeyPath[0] == '~' {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return SourceConfig{}, fmt.Errorf("error getting home directory: %v", err)
+			}
+			cfg.PrivateKeyPath = filepath.Join(homeDir, cfg.PrivateKeyPath[1:])
+		}
+	case "local":
+		cfg.RootPath = os.Getenv("LOCAL_LOG_PATH")
+		cfg.ProcessedPath = os.Getenv("LOCAL_PROCESSED_PATH")
+		if cfg.RootPath == "" {
+			return SourceConfig{}, fmt.Errorf("LOCAL_LOG_PATH must be set when TRANSACTION_SOURCE is local")
+		}
+		if cfg.ProcessedPath == "" {
+			cfg.ProcessedPath = filepath.Join(cfg.RootPath, "processed")
+		}
+	default:
+		return SourceConfig{}, fmt.Errorf("unknown TRANSACTION_SOURCE %q: expected sftp or local", cfg.Type)
+	}
+
+	return cfg, nil
+}
+
+// openSource connects to the configured source. It is called once per processing cycle.
+func openSource(cfg SourceConfig) (TransactionSource, error) {
+	if cfg.Type == "local" {
+		return &localSource{root: cfg.RootPath, processed: cfg.ProcessedPath}, nil
+	}
+	return openSFTPSource(cfg)
+}
+
+// listClientFiles walks <root>/<clientID>/ for statement files, skipping hidden entries and the processed directory
+func listClientFiles(readDir func(path string) ([]os.FileInfo, error), root, processed string) ([]SourceFile, error) {
+	clientDirs, err := readDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list directory: %v", err)
+	}
+
+	var files []SourceFile
+	var errs []error
+	for _, clientDir := range clientDirs {
+		// Skip files and hidden directories
+		if !clientDir.IsDir() || strings.HasPrefix(clientDir.Name(), ".") {
+			continue
+		}
+
+		clientID := clientDir.Name()
+		clientPath := filepath.Join(root, clientID)
+		// Skip the "processed" directory itself
+		if clientID == "processed" || filepath.Clean(clientPath) == filepath.Clean(processed) {
+			continue
+		}
+
+		entries, err := readDir(clientPath)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list files for client %s: %v", clientID, err))
+			continue
+		}
+
+		for _, entry := range entries {
+			// Skip directories and hidden files
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+
+			// Only process statement files
+			if !isStatementFile(entry.Name()) {
+				continue
+			}
+
+			files = append(files, SourceFile{
+				ClientID: clientID,
+				Name:     entry.Name(),
+				Path:     filepath.Join(clientPath, entry.Name()),
+			})
+		}
+	}
+
+	return files, errors.Join(errs...)
+}
+
+// copyToFile writes everything from src into a new local file
+func copyToFile(src io.Reader, localPath string) error {
+	dstFile, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to create local file: %v", err)
+	}
+	defer dstFile.Close()
+
+	_, err = io.Copy(dstFile, src)
+	if err != nil {
+		return fmt.Errorf("failed to copy file contents: %v", err)
+	}
+
+	return nil
+}
+
+// sftpSource reads transaction logs from the SFTP server
+type sftpSource struct {
+	ssh       *ssh.Client
+	client    *sftp.Client
+	root      string
+	processed string
+}
+
+func openSFTPSource(cfg SourceConfig) (*sftpSource, error) {
+	// Load SSH private key
+	key, err := ioutil.ReadFile(cfg.PrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read private key: %v", err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse private key: %v", err)
+	}
+
+	// SSH Config
+	config := &ssh.ClientConfig{
+		User:            cfg.Username,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         30 * time.Second,
+	}
+
+	// Connect to SFTP Server
+	sshClient, err := ssh.Dial("tcp", cfg.SFTPServer+":2022", config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %v", err)
+	}
+
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+		return nil, fmt.Errorf("failed to create SFTP client: %v", err)
+	}
+
+	return &sftpSource{ssh: sshClient, client: client, root: cfg.RootPath, processed: cfg.ProcessedPath}, nil
+}
+
+func (s *sftpSource) List() ([]SourceFile, error) {
+	return listClientFiles(s.client.ReadDir, s.root, s.processed)
+}
+
+// Fetch downloads a file from the SFTP server to a local path
+func (s *sftpSource) Fetch(file SourceFile, localPath string) error {
+	srcFile, err := s.client.Open(file.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open remote file: %v", err)
+	}
+	defer srcFile.Close()
+
+	return copyToFile(srcFile, localPath)
+}
+
+// Archive moves a file into the client's processed directory on the SFTP server
+func (s *sftpSource) Archive(file SourceFile) error {
+	clientProcessedPath := filepath.Join(s.processed, file.ClientID)
+	if err := ensureRemoteDir(s.client, clientProcessedPath); err != nil {
+		return fmt.Errorf("could not create processed directory for client %s: %v", file.ClientID, err)
+	}
+	return s.client.Rename(file.Path, filepath.Join(clientProcessedPath, file.Name))
+}
+
+// StoreRejects uploads a rejects file into the client's processed directory on the SFTP server
+func (s *sftpSource) StoreRejects(file SourceFile, localPath string) error {
+	clientProcessedPath := filepath.Join(s.processed, file.ClientID)
+	if err := ensureRemoteDir(s.client, clientProcessedPath); err != nil {
+		return fmt.Errorf("could not create processed directory for client %s: %v", file.ClientID, err)
+	}
+
+	srcFile, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open rejects file: %v", err)
+	}
+	defer srcFile.Close()
+
+	dstFile, err := s.client.Create(filepath.Join(clientProcessedPath, rejectsFileName(file.Name)))
+	if err != nil {
+		return fmt.Errorf("failed to create remote rejects file: %v", err)
+	}
+	defer dstFile.Close()
+
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		return fmt.Errorf("failed to upload rejects file: %v", err)
+	}
+	return nil
+}
+
+func (s *sftpSource) Close() error {
+	s.client.Close()
+	return s.ssh.Close()
+}
+
+// ensureRemoteDir ensures a directory exists on the remote server
+func ensureRemoteDir(client *sftp.Client, path string) error {
+	_, err := client.Stat(path)
+	if err == nil {
+		// Directory exists
+		return nil
+	}
+
+	// Create directory if it doesn't exist
+	return client.MkdirAll(path)
+}
+
+// localSource reads transaction logs from a directory on this machine, e.g. the
+// transaction-logs folder written by sftp/transaction_generator.py
+type localSource struct {
+	root      string
+	processed string
+}
+
+func (s *localSource) List() ([]SourceFile, error) {
+	return listClientFiles(ioutil.ReadDir, s.root, s.processed)
+}
+
+// Fetch copies a file to a local path, so the pipeline never reads a file it is about to move
+func (s *localSource) Fetch(file SourceFile, localPath string) error {
+	srcFile, err := os.Open(file.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %v", err)
+	}
+	defer srcFile.Close()
+
+	return copyToFile(srcFile, localPath)
+}
+
+// Archive moves a file into the client's processed directory
+func (s *localSource) Archive(file SourceFile) error {
+	clientProcessedPath := filepath.Join(s.processed, file.ClientID)
+	if err := os.MkdirAll(clientProcessedPath, 0755); err != nil {
+		return fmt.Errorf("could not create processed directory for client %s: %v", file.ClientID, err)
+	}
+	return os.Rename(file.Path, filepath.Join(clientProcessedPath, file.Name))
+}
+
+// StoreRejects copies a rejects file into the client's processed directory
+func (s *localSource) StoreRejects(file SourceFile, localPath string) error {
+	clientProcessedPath := filepath.Join(s.processed, file.ClientID)
+	if err := os.MkdirAll(clientProcessedPath, 0755); err != nil {
+		return fmt.Errorf("could not create processed directory for client %s: %v", file.ClientID, err)
+	}
+
+	srcFile, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open rejects file: %v", err)
+	}
+	defer srcFile.Close()
+
+	return copyToFile(srcFile, filepath.Join(clientProcessedPath, rejectsFileName(file.Name)))
+}
+
+func (s *localSource) Close() error {
+	return nil
+}
+
+// logSourceConfig prints where files are read from
+func logSourceConfig(cfg SourceConfig) {
+	log.Printf("Transaction source: %s", cfg.Type)
+	log.Printf("Remote path: %s", cfg.RootPath)
+	log.Printf("Processed path: %s", cfg.ProcessedPath)
+}
@@ -0,0 +1,95 @@
+package transfer
+
+import (
+	"backend/models"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateTransferHandler restricts to Admin or Agent. Retries carrying the same Idempotency-Key
+// header return the original transfer instead of posting it again.
+func CreateTransferHandler(service *TransferService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userCtx := r.Context().Value("user").(map[string]interface{})
+		role := userCtx["role"].(string)
+		if role != "Admin" && role != "Agent" {
+			http.Error(w, "Unauthorized: only Admin or Agent can create transfers", http.StatusForbidden)
+			return
+		}
+
+		var body models.Transfer
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		transfer := models.Transfer{
+			FromAccountID:  body.FromAccountID,
+			ToAccountID:    body.ToAccountID,
+			Amount:         body.Amount,
+			Currency:       body.Currency,
+			Description:    body.Description,
+			RequestedBy:    userCtx["id"].(int),
+			IdempotencyKey: r.Header.Get("Idempotency-Key"),
+		}
+
+		created, replayed, err := service.CreateTransfer(r.Context(), transfer)
+		if err != nil {
+			http.Error(w, err.Error(), transferErrorStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if replayed {
+			w.Header().Set("Idempotent-Replayed", "true")
+		} else {
+			w.WriteHeader(http.StatusCreated)
+		}
+		json.NewEncoder(w).Encode(created)
+	}
+}
+
+// GetTransferHandler restricts to Admin or Agent
+func GetTransferHandler(service *TransferService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userCtx := r.Context().Value("user").(map[string]interface{})
+		role := userCtx["role"].(string)
+		if role != "Admin" && role != "Agent" {
+			http.Error(w, "Unauthorized: only Admin or Agent can view transfers", http.StatusForbidden)
+			return
+		}
+
+		transferID, err := strconv.ParseInt(mux.Vars(r)["transfer_id"], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid transfer ID", http.StatusBadRequest)
+			return
+		}
+
+		transfer, err := service.GetTransfer(transferID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(transfer)
+	}
+}
+
+func transferErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrIdempotencyConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrAccountNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrAccountInactive), errors.Is(err, ErrCurrencyMismatch), errors.Is(err, ErrInsufficientFunds):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, ErrSameAccount), errors.Is(err, ErrInvalidAmount):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
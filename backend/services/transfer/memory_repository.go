@@ -0,0 +1,94 @@
+package transfer
+
+import (
+	"backend/database/memstore"
+	"backend/models"
+	"backend/services/events"
+	"backend/services/interfaces"
+	"backend/services/ledger"
+	"fmt"
+	"time"
+)
+
+var _ interfaces.TransferRepositoryInterface = (*MemoryTransferRepository)(nil)
+
+// MemoryTransferRepository is an in-memory implementation of interfaces.TransferRepositoryInterface
+type MemoryTransferRepository struct {
+	store  *memstore.Store
+	ledger *ledger.MemoryLedgerRepository
+}
+
+// NewMemoryTransferRepository initializes a new MemoryTransferRepository on the given store
+func NewMemoryTransferRepository(store *memstore.Store) *MemoryTransferRepository {
+	return &MemoryTransferRepository{store: store, ledger: ledger.NewMemoryLedgerRepository(store)}
+}
+
+// CreateTransfer checks and records the transfer, posts its journal entry and records
+// TransferCompleted under one lock
+func (r *MemoryTransferRepository) CreateTransfer(transfer models.Transfer, meta models.EventMeta) (models.Transfer, bool, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	if transfer.IdempotencyKey != "" {
+		for _, existing := range r.store.Transfers {
+			if existing.RequestedBy == transfer.RequestedBy && existing.IdempotencyKey == transfer.IdempotencyKey {
+				return replay(existing, transfer, nil)
+			}
+		}
+	}
+
+	from := r.accountState(transfer.FromAccountID)
+	to := r.accountState(transfer.ToAccountID)
+
+	var balance models.Money
+	if from != nil {
+		balance = r.store.LedgerBalances[ledger.AccountLedger(from.AccountID)+"|"+from.Currency]
+	}
+
+	if err := CheckTransfer(&transfer, from, to, balance); err != nil {
+		return models.Transfer{}, false, err
+	}
+
+	transfer.ID = r.store.NextTransferID
+	entry, err := r.ledger.PostEntryLocked(Entry(transfer))
+	if err != nil {
+		return models.Transfer{}, false, err
+	}
+
+	r.store.NextTransferID++
+	transfer.EntryID = entry.ID
+	transfer.CreatedAt = time.Now().UTC().Format("2006-01-02 15:04:05")
+	r.store.Transfers = append(r.store.Transfers, transfer)
+
+	if err := events.AppendLocked(r.store, meta, events.TransferCompleted{Transfer: transfer}); err != nil {
+		return models.Transfer{}, false, err
+	}
+	return transfer, false, nil
+}
+
+// GetTransfer retrieves a transfer by ID
+func (r *MemoryTransferRepository) GetTransfer(transferID int64) (models.Transfer, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	for _, transfer := range r.store.Transfers {
+		if transfer.ID == transferID {
+			return transfer, nil
+		}
+	}
+	return models.Transfer{}, fmt.Errorf("transfer with ID %d does not exist", transferID)
+}
+
+func (r *MemoryTransferRepository) accountState(accountID int) *AccountState {
+	account, ok := r.store.Accounts[accountID]
+	if !ok {
+		return nil
+	}
+	return &AccountState{
+		AccountID:     account.AccountID,
+		ClientID:      account.ClientID,
+		Currency:      account.Currency,
+		AccountStatus: account.AccountStatus,
+		IsActive:      account.IsActive,
+	}
+}
@@ -0,0 +1,225 @@
+package transfer
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"backend/models"
+	"backend/services/events"
+	"backend/services/ledger"
+
+	"backend/services/metrics"
+	"github.com/go-sql-driver/mysql"
+	"time"
+)
+
+// TransferRepository is the MySQL implementation of interfaces.TransferRepositoryInterface
+type TransferRepository struct {
+	db *sql.DB
+}
+
+// NewTransferRepository initializes a new TransferRepository
+func NewTransferRepository(db *sql.DB) *TransferRepository {
+	return &TransferRepository{db: db}
+}
+
+// CreateTransfer locks both accounts and both balances, checks them and the debited balance,
+// then records the transfer, posts its journal entry and records TransferCompleted in one
+// database transaction
+func (r *TransferRepository) CreateTransfer(transfer models.Transfer, meta models.EventMeta) (models.Transfer, bool, error) {
+	defer metrics.ObserveQuery("TransferRepository", "CreateTransfer", time.Now())
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Transfer{}, false, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	if transfer.IdempotencyKey != "" {
+		existing, found, err := r.findByKey(tx, transfer.RequestedBy, transfer.IdempotencyKey)
+		if err != nil || found {
+			tx.Rollback()
+			return replay(existing, transfer, err)
+		}
+	}
+
+	// Lock the accounts in ID order so concurrent transfers between the same pair queue up
+	rows, err := tx.Query(`
+		SELECT account_id, client_id, currency, account_status, is_active
+		FROM account WHERE account_id IN (?, ?)
+		ORDER BY account_id FOR UPDATE`,
+		transfer.FromAccountID, transfer.ToAccountID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return models.Transfer{}, false, fmt.Errorf("failed to lock accounts: %v", err)
+	}
+
+	var from, to *AccountState
+	for rows.Next() {
+		var state AccountState
+		err := rows.Scan(&state.AccountID, &state.ClientID, &state.Currency, &state.AccountStatus, &state.IsActive)
+		if err != nil {
+			rows.Close()
+			tx.Rollback()
+			return models.Transfer{}, false, fmt.Errorf("error scanning account row: %v", err)
+		}
+		if state.AccountID == transfer.FromAccountID {
+			from = &state
+		} else {
+			to = &state
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		tx.Rollback()
+		return models.Transfer{}, false, fmt.Errorf("error iterating account rows: %v", err)
+	}
+
+	// Both balances are locked before the debited one is checked, in the order PostEntryTx
+	// locks them, so this cannot deadlock with other entries posting to either account
+	var balance models.Money
+	if from != nil && to != nil && from.Currency == to.Currency {
+		locking := transfer
+		locking.Currency = from.Currency
+		balances, err := ledger.LockBalancesTx(tx, Entry(locking).Postings)
+		if err != nil {
+			tx.Rollback()
+			return models.Transfer{}, false, err
+		}
+		// Entry debits the from account in its first posting
+		balance = balances[0]
+	}
+
+	if err := CheckTransfer(&transfer, from, to, balance); err != nil {
+		tx.Rollback()
+		return models.Transfer{}, false, err
+	}
+
+	var key interface{}
+	if transfer.IdempotencyKey != "" {
+		key = transfer.IdempotencyKey
+	}
+	result, err := tx.Exec(`
+		INSERT INTO transfers (from_account_id, to_account_id, currency, amount, description, requested_by, idempotency_key, request_hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		transfer.FromAccountID, transfer.ToAccountID, transfer.Currency, transfer.Amount,
+		transfer.Description, transfer.RequestedBy, key, transfer.RequestHash,
+	)
+	if err != nil {
+		tx.Rollback()
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 && transfer.IdempotencyKey != "" {
+			// A concurrent retry with the same key committed first
+			existing, _, err := r.findByKey(r.db, transfer.RequestedBy, transfer.IdempotencyKey)
+			return replay(existing, transfer, err)
+		}
+		return models.Transfer{}, false, fmt.Errorf("failed to insert transfer: %v", err)
+	}
+
+	transfer.ID, err = result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return models.Transfer{}, false, fmt.Errorf("failed to get transfer ID: %v", err)
+	}
+
+	entry, err := ledger.PostEntryTx(tx, Entry(transfer))
+	if err != nil {
+		tx.Rollback()
+		return models.Transfer{}, false, err
+	}
+
+	_, err = tx.Exec(`UPDATE transfers SET entry_id = ? WHERE id = ?`, entry.ID, transfer.ID)
+	if err != nil {
+		tx.Rollback()
+		return models.Transfer{}, false, fmt.Errorf("failed to link journal entry: %v", err)
+	}
+
+	created, err := scanTransfer(tx.QueryRow(transferQuery+` WHERE t.id = ?`, transfer.ID))
+	if err != nil {
+		tx.Rollback()
+		return models.Transfer{}, false, fmt.Errorf("failed to retrieve transfer: %v", err)
+	}
+
+	if err := events.AppendTx(tx, meta, events.TransferCompleted{Transfer: created}); err != nil {
+		tx.Rollback()
+		return models.Transfer{}, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Transfer{}, false, fmt.Errorf("failed to commit transfer: %v", err)
+	}
+	return created, false, nil
+}
+
+// GetTransfer retrieves a transfer by ID
+func (r *TransferRepository) GetTransfer(transferID int64) (models.Transfer, error) {
+	defer metrics.ObserveQuery("TransferRepository", "GetTransfer", time.Now())
+	transfer, err := scanTransfer(r.db.QueryRow(transferQuery+` WHERE t.id = ?`, transferID))
+	if err == sql.ErrNoRows {
+		return models.Transfer{}, fmt.Errorf("transfer with ID %d does not exist", transferID)
+	}
+	if err != nil {
+		return models.Transfer{}, fmt.Errorf("failed to retrieve transfer: %v", err)
+	}
+	return transfer, nil
+}
+
+const transferQuery = `
+	SELECT t.id, t.from_account_id, t.to_account_id, COALESCE(fa.client_id, ''), COALESCE(ta.client_id, ''),
+		t.amount, t.currency, t.description, COALESCE(t.entry_id, 0), t.requested_by,
+		COALESCE(t.idempotency_key, ''), t.request_hash, t.created_at
+	FROM transfers t
+	LEFT JOIN account fa ON fa.account_id = t.from_account_id
+	LEFT JOIN account ta ON ta.account_id = t.to_account_id`
+
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// findByKey looks up an earlier transfer made with the same idempotency key
+func (r *TransferRepository) findByKey(q queryRower, requestedBy int, key string) (models.Transfer, bool, error) {
+	transfer, err := scanTransfer(q.QueryRow(transferQuery+` WHERE t.requested_by = ? AND t.idempotency_key = ?`, requestedBy, key))
+	if err == sql.ErrNoRows {
+		return models.Transfer{}, false, nil
+	}
+	if err != nil {
+		return models.Transfer{}, false, fmt.Errorf("failed to look up idempotency key: %v", err)
+	}
+	return transfer, true, nil
+}
+
+func scanTransfer(row *sql.Row) (models.Transfer, error) {
+	var transfer models.Transfer
+	err := row.Scan(
+		&transfer.ID, &transfer.FromAccountID, &transfer.ToAccountID, &transfer.FromClientID, &transfer.ToClientID,
+		&transfer.Amount, &transfer.Currency, &transfer.Description, &transfer.EntryID, &transfer.RequestedBy,
+		&transfer.IdempotencyKey, &transfer.RequestHash, &transfer.CreatedAt,
+	)
+	return transfer, err
+}
+
+// replay returns an earlier transfer for a repeated idempotency key, provided the request is the same
+func replay(existing, requested models.Transfer, err error) (models.Transfer, bool, error) {
+	if err != nil {
+		return models.Transfer{}, false, err
+	}
+	if existing.RequestHash != requested.RequestHash {
+		return models.Transfer{}, false, ErrIdempotencyConflict
+	}
+	return existing, true, nil
+}
+
+// Entry builds the journal entry that moves a transfer's amount between the two account ledgers
+func Entry(transfer models.Transfer) models.JournalEntry {
+	from, to := transfer.FromAccountID, transfer.ToAccountID
+	return models.JournalEntry{
+		Source:      SourceTransfer,
+		SourceRef:   strconv.FormatInt(transfer.ID, 10),
+		Description: transfer.Description,
+		Postings: []models.Posting{
+			{LedgerAccount: ledger.AccountLedger(from), AccountID: &from, Currency: transfer.Currency, Amount: -transfer.Amount},
+			{LedgerAccount: ledger.AccountLedger(to), AccountID: &to, Currency: transfer.Currency, Amount: transfer.Amount},
+		},
+	}
+}
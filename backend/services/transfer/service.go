@@ -0,0 +1,102 @@
+package transfer
+
+import (
+	"backend/models"
+	"backend/services/account"
+	"backend/services/events"
+	"backend/services/interfaces"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// SourceTransfer is the journal entry source of transfers
+const SourceTransfer = "transfer"
+
+var (
+	ErrSameAccount         = errors.New("cannot transfer to the same account")
+	ErrInvalidAmount       = errors.New("transfer amount must be greater than 0")
+	ErrAccountNotFound     = errors.New("account does not exist")
+	ErrAccountInactive     = errors.New("both accounts must be active")
+	ErrCurrencyMismatch    = errors.New("accounts must have the same currency")
+	ErrInsufficientFunds   = errors.New("insufficient funds")
+	ErrIdempotencyConflict = errors.New("idempotency key was already used for a different transfer")
+)
+
+// AccountState is the part of an account a transfer checks, read while the account is locked
+type AccountState struct {
+	AccountID     int
+	ClientID      string
+	Currency      string
+	AccountStatus string
+	IsActive      bool
+}
+
+// TransferService handles transfers between accounts
+type TransferService struct {
+	repo interfaces.TransferRepositoryInterface
+}
+
+// NewTransferService initializes the transfer service
+func NewTransferService(repo interfaces.TransferRepositoryInterface) *TransferService {
+	return &TransferService{repo: repo}
+}
+
+// CreateTransfer debits one account and credits another. A request repeating an earlier
+// idempotency key returns the earlier transfer with replayed set instead of posting again.
+func (s *TransferService) CreateTransfer(ctx context.Context, transfer models.Transfer) (models.Transfer, bool, error) {
+	if transfer.FromAccountID == transfer.ToAccountID {
+		return models.Transfer{}, false, ErrSameAccount
+	}
+	if transfer.Amount <= 0 {
+		return models.Transfer{}, false, ErrInvalidAmount
+	}
+	if len(transfer.IdempotencyKey) > 255 {
+		return models.Transfer{}, false, fmt.Errorf("idempotency key must be at most 255 characters")
+	}
+
+	transfer.RequestHash = requestHash(transfer)
+
+	created, replayed, err := s.repo.CreateTransfer(transfer, events.NewMeta(ctx, transfer.RequestedBy))
+	if err != nil {
+		return models.Transfer{}, false, err
+	}
+	return created, replayed, nil
+}
+
+// GetTransfer retrieves a transfer by ID
+func (s *TransferService) GetTransfer(transferID int64) (models.Transfer, error) {
+	return s.repo.GetTransfer(transferID)
+}
+
+// CheckTransfer validates a transfer against the locked state of both accounts and the
+// current balance of the account being debited, and fills in the currency and client IDs
+func CheckTransfer(transfer *models.Transfer, from, to *AccountState, fromBalance models.Money) error {
+	if from == nil || to == nil {
+		return ErrAccountNotFound
+	}
+	if !from.IsActive || !to.IsActive || from.AccountStatus != account.StatusActive || to.AccountStatus != account.StatusActive {
+		return ErrAccountInactive
+	}
+	if from.Currency != to.Currency || (transfer.Currency != "" && transfer.Currency != from.Currency) {
+		return ErrCurrencyMismatch
+	}
+	if fromBalance < transfer.Amount {
+		return ErrInsufficientFunds
+	}
+
+	transfer.Currency = from.Currency
+	transfer.FromClientID = from.ClientID
+	transfer.ToClientID = to.ClientID
+	return nil
+}
+
+// requestHash fingerprints what a transfer asks for, so a reused idempotency key can be
+// told apart from a genuine retry
+func requestHash(transfer models.Transfer) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d|%d|%d|%s|%s",
+		transfer.FromAccountID, transfer.ToAccountID, transfer.Amount, transfer.Currency, transfer.Description)))
+	return hex.EncodeToString(sum[:])
+}
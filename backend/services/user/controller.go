@@ -7,7 +7,9 @@ import (
 	"strconv"
 	"time"
 
+	"backend/database"
 	"backend/services/auth"
+	"backend/services/listquery"
 	"github.com/gorilla/mux"
 	"golang.org/x/oauth2"
 )
@@ -122,7 +124,7 @@ func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Save metadata in local DB
-	user, err := NewUserService(NewUserRepository()).CreateUser(
+	user, err := NewUserService(NewUserRepository(database.DB)).CreateUser(
 		input.FirstName,
 		input.LastName,
 		input.Email,
@@ -137,6 +139,31 @@ func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(user)
 }
 
+// ListUsersHandler lets Admins page through users
+func ListUsersHandler(w http.ResponseWriter, r *http.Request) {
+	userCtx := r.Context().Value("user").(map[string]interface{})
+	requesterRole := userCtx["role"].(string)
+	if requesterRole != "Admin" {
+		http.Error(w, "Only admins can list users", http.StatusForbidden)
+		return
+	}
+
+	q, err := listquery.Parse(r, ListSpec)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	users, err := NewUserService(NewUserRepository(database.DB)).ListUsers(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(users)
+}
+
 // DisableUserHandler uses JWT to restrict access
 func DisableUserHandler(w http.ResponseWriter, r *http.Request) {
 	userID := mux.Vars(r)["userId"]
@@ -145,7 +172,7 @@ func DisableUserHandler(w http.ResponseWriter, r *http.Request) {
 	requesterID := userCtx["id"].(int)
 	requesterRole := userCtx["role"].(string)
 
-	err := NewUserService(NewUserRepository()).DisableUser(userID, requesterID, requesterRole)
+	err := NewUserService(NewUserRepository(database.DB)).DisableUser(userID, requesterID, requesterRole)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusForbidden)
 		return
@@ -172,7 +199,7 @@ func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := NewUserService(NewUserRepository()).UpdateUser(userID, updatedUser)
+	err := NewUserService(NewUserRepository(database.DB)).UpdateUser(userID, updatedUser)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -0,0 +1,116 @@
+package user
+
+import (
+	"backend/database/memstore"
+	"backend/models"
+	"backend/services/interfaces"
+	"backend/services/listquery"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var _ interfaces.UserRepositoryInterface = (*MemoryUserRepository)(nil)
+
+// MemoryUserRepository is an in-memory implementation of interfaces.UserRepositoryInterface.
+type MemoryUserRepository struct {
+	store *memstore.Store
+}
+
+// NewMemoryUserRepository initializes a new MemoryUserRepository on the given store.
+func NewMemoryUserRepository(store *memstore.Store) *MemoryUserRepository {
+	return &MemoryUserRepository{store: store}
+}
+
+// CreateUser stores metadata for a new user.
+func (r *MemoryUserRepository) CreateUser(firstName, lastName, email, role string) (models.User, error) {
+	if _, err := r.GetUserByEmail(email); err == nil {
+		return models.User{}, fmt.Errorf("failed to insert user: duplicate email %s", email)
+	}
+	return r.store.SeedUser(models.User{
+		FirstName: firstName,
+		LastName:  lastName,
+		Email:     email,
+		Role:      role,
+		Status:    "active",
+	}), nil
+}
+
+// GetUserByEmail returns user by email.
+func (r *MemoryUserRepository) GetUserByEmail(email string) (models.User, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	for _, user := range r.store.Users {
+		if strings.EqualFold(user.Email, email) {
+			return user, nil
+		}
+	}
+	return models.User{}, fmt.Errorf("failed to fetch user: no user with email %s", email)
+}
+
+// GetUserByID returns user by ID.
+func (r *MemoryUserRepository) GetUserByID(userID string) (models.User, error) {
+	id, err := strconv.Atoi(userID)
+	if err != nil {
+		return models.User{}, fmt.Errorf("failed to fetch user by ID: %v", err)
+	}
+
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	user, ok := r.store.Users[id]
+	if !ok {
+		return models.User{}, fmt.Errorf("failed to fetch user by ID: no user with ID %d", id)
+	}
+	return user, nil
+}
+
+// DisableUser sets status = 'inactive'
+func (r *MemoryUserRepository) DisableUser(userID string) error {
+	return r.modify(userID, func(user *models.User) { user.Status = "inactive" })
+}
+
+// UpdateUser allows admins to modify user fields.
+func (r *MemoryUserRepository) UpdateUser(userID string, updated models.User) error {
+	return r.modify(userID, func(user *models.User) {
+		user.FirstName = updated.FirstName
+		user.LastName = updated.LastName
+		user.Email = updated.Email
+		user.Role = updated.Role
+	})
+}
+
+// InsertUserFromCognito stores a user synced from Cognito.
+func (r *MemoryUserRepository) InsertUserFromCognito(email, role string) (models.User, error) {
+	return r.store.SeedUser(models.User{Email: email, Role: role, Status: "active"}), nil
+}
+
+// ListUsers returns one page of users matching the query.
+func (r *MemoryUserRepository) ListUsers(q listquery.Query) ([]models.User, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	var users []models.User
+	for _, user := range r.store.Users {
+		users = append(users, user)
+	}
+	return listquery.Apply(users, q, ListField), nil
+}
+
+// modify applies change to a stored user; like an UPDATE, a missing user is not an error
+func (r *MemoryUserRepository) modify(userID string, change func(*models.User)) error {
+	id, err := strconv.Atoi(userID)
+	if err != nil {
+		return err
+	}
+
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	if user, ok := r.store.Users[id]; ok {
+		change(&user)
+		r.store.Users[id] = user
+	}
+	return nil
+}
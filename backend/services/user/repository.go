@@ -1,79 +1,35 @@
 package user
 
 import (
-	"backend/database"
+	"backend/models"
+	"backend/services/listquery"
+	"backend/services/metrics"
+	"database/sql"
 	"fmt"
-	"log"
+	"time"
 )
 
 // User struct represents a user in the system (no password).
-type User struct {
-	ID        int    `json:"id"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Email     string `json:"email"`
-	Role      string `json:"role"`
-	Status    string `json:"status"`
-}
-
-// UserRepository handles DB logic for users.
-type UserRepository struct{}
+type User = models.User
 
-// NewUserRepository initializes a new UserRepository and ensures table exists.
-func NewUserRepository() *UserRepository {
-	repo := &UserRepository{}
-	repo.InitUserTable()
-	return repo
+// UserRepository is the MySQL implementation of interfaces.UserRepositoryInterface.
+type UserRepository struct {
+	db *sql.DB
 }
 
-// InitUserTable creates the users table if not exists (no password)
-func (r *UserRepository) InitUserTable() {
-	query := `
-	CREATE TABLE IF NOT EXISTS users (
-		id INT AUTO_INCREMENT PRIMARY KEY,
-		first_name VARCHAR(100),
-		last_name VARCHAR(100),
-		email VARCHAR(100) UNIQUE NOT NULL,
-		role ENUM('Admin', 'Agent') NOT NULL DEFAULT 'Agent',
-		status ENUM('active', 'inactive') NOT NULL DEFAULT 'active'
-	);`
-
-	_, err := database.DB.Exec(query)
-	if err != nil {
-		log.Fatal("❌ Error creating users table:", err)
-	}
-
-	fmt.Println("✅ Users table ready (no password stored)")
-
-	// 🔽 Insert seed users if not exists
-	seed := `
-	INSERT IGNORE INTO users (id, first_name, last_name, email, role, status)
-	VALUES 
-		(1, 'Admin', 'Root', 'Admin@root.com', 'Admin', 'active'),
-	(2, 'Agent1', 'Agent', 'Agent1@agent.com', 'Agent', 'active'),
-	(3, 'Admin2', 'Admin', 'Admin2@notRoot.com', 'Admin', 'active'),
-	(4, 'Admin3', 'Admin', 'Admin3@notRoot.com', 'Admin', 'active'),
-	(5, 'Admin4', 'Admin', 'Admin4@notRoot.com', 'Admin', 'active'),
-	(6, 'Agent2', 'Agent', 'Agent2@agent.com', 'Agent', 'active'),
-	(7, 'Agent3', 'Agent', 'Agent3@agent.com', 'Agent', 'active'),
-	(8, 'Agent4', 'Agent', 'Agent4@agent.com', 'Agent', 'active');`
-
-	_, err = database.DB.Exec(seed)
-	if err != nil {
-		log.Fatal("❌ Failed to seed users:", err)
-	}
-
-	fmt.Println("✅ Seed users inserted (if not already present)")
+// NewUserRepository initializes a new UserRepository.
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
 }
 
-
 // CreateUser inserts metadata for a new user (already registered in Cognito).
 func (r *UserRepository) CreateUser(firstName, lastName, email, role string) (User, error) {
+	defer metrics.ObserveQuery("UserRepository", "CreateUser", time.Now())
 	query := `
 	INSERT INTO users (first_name, last_name, email, role, status)
 	VALUES (?, ?, ?, ?, 'active')`
 
-	result, err := database.DB.Exec(query, firstName, lastName, email, role)
+	result, err := r.db.Exec(query, firstName, lastName, email, role)
 	if err != nil {
 		return User{}, fmt.Errorf("failed to insert user: %v", err)
 	}
@@ -95,9 +51,10 @@ func (r *UserRepository) CreateUser(firstName, lastName, email, role string) (Us
 
 // GetUserByEmail returns user by email.
 func (r *UserRepository) GetUserByEmail(email string) (User, error) {
+	defer metrics.ObserveQuery("UserRepository", "GetUserByEmail", time.Now())
 	var user User
 	query := `SELECT id, first_name, last_name, email, role, status FROM users WHERE email = ?`
-	err := database.DB.QueryRow(query, email).Scan(
+	err := r.db.QueryRow(query, email).Scan(
 		&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Role, &user.Status,
 	)
 	if err != nil {
@@ -108,9 +65,10 @@ func (r *UserRepository) GetUserByEmail(email string) (User, error) {
 
 // GetUserByID returns user by ID.
 func (r *UserRepository) GetUserByID(userID string) (User, error) {
+	defer metrics.ObserveQuery("UserRepository", "GetUserByID", time.Now())
 	var user User
 	query := `SELECT id, first_name, last_name, email, role, status FROM users WHERE id = ?`
-	err := database.DB.QueryRow(query, userID).Scan(
+	err := r.db.QueryRow(query, userID).Scan(
 		&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Role, &user.Status,
 	)
 	if err != nil {
@@ -121,13 +79,15 @@ func (r *UserRepository) GetUserByID(userID string) (User, error) {
 
 // DisableUser sets status = 'inactive'
 func (r *UserRepository) DisableUser(userID string) error {
-	_, err := database.DB.Exec(`UPDATE users SET status = 'inactive' WHERE id = ?`, userID)
+	defer metrics.ObserveQuery("UserRepository", "DisableUser", time.Now())
+	_, err := r.db.Exec(`UPDATE users SET status = 'inactive' WHERE id = ?`, userID)
 	return err
 }
 
 // UpdateUser allows admins to modify user fields.
 func (r *UserRepository) UpdateUser(userID string, user User) error {
-	_, err := database.DB.Exec(`
+	defer metrics.ObserveQuery("UserRepository", "UpdateUser", time.Now())
+	_, err := r.db.Exec(`
 		UPDATE users SET first_name = ?, last_name = ?, email = ?, role = ? WHERE id = ?
 	`, user.FirstName, user.LastName, user.Email, user.Role, userID)
 	return err
@@ -135,13 +95,14 @@ func (r *UserRepository) UpdateUser(userID string, user User) error {
 
 // SyncOrInsertUserByEmailAndRole ensures user exists, else inserts (used by JWT middleware)
 func (r *UserRepository) SyncOrInsertUserByEmailAndRole(email, role string) (int, error) {
+	defer metrics.ObserveQuery("UserRepository", "SyncOrInsertUserByEmailAndRole", time.Now())
 	user, err := r.GetUserByEmail(email)
 	if err == nil {
 		return user.ID, nil // Already exists
 	}
 
 	// Insert placeholder with minimal info
-	result, err := database.DB.Exec(`
+	result, err := r.db.Exec(`
 		INSERT INTO users (email, role, status)
 		VALUES (?, ?, 'active')
 	`, email, role)
@@ -154,11 +115,12 @@ func (r *UserRepository) SyncOrInsertUserByEmailAndRole(email, role string) (int
 }
 // InsertUserFromCognito inserts a user synced from Cognito (no password stored)
 func (r *UserRepository) InsertUserFromCognito(email, role string) (User, error) {
+	defer metrics.ObserveQuery("UserRepository", "InsertUserFromCognito", time.Now())
 	query := `
 		INSERT INTO users (first_name, last_name, email, role, status)
 		VALUES ('', '', ?, ?, 'active')
 	`
-	result, err := database.DB.Exec(query, email, role)
+	result, err := r.db.Exec(query, email, role)
 	if err != nil {
 		return User{}, fmt.Errorf("failed to insert Cognito user: %v", err)
 	}
@@ -175,3 +137,61 @@ func (r *UserRepository) InsertUserFromCognito(email, role string) (User, error)
 		Status: "active",
 	}, nil
 }
+
+// ListSpec lists the sort keys and filters accepted by user listings.
+var ListSpec = listquery.Spec{
+	IDColumn: "id",
+	SortColumns: map[string]string{
+		"id":        "id",
+		"email":     "email",
+		"last_name": "last_name",
+	},
+	DefaultSort: "id",
+	Filters: map[string]listquery.Filter{
+		"role":   {Column: "role", Op: "="},
+		"status": {Column: "status", Op: "="},
+	},
+}
+
+// ListField returns a user's value for a ListSpec column.
+func ListField(user User, column string) interface{} {
+	switch column {
+	case "id":
+		return user.ID
+	case "email":
+		return user.Email
+	case "last_name":
+		return user.LastName
+	case "role":
+		return user.Role
+	case "status":
+		return user.Status
+	}
+	return nil
+}
+
+// ListUsers returns one page of users matching the query.
+func (r *UserRepository) ListUsers(q listquery.Query) ([]User, error) {
+	defer metrics.ObserveQuery("UserRepository", "ListUsers", time.Now())
+	clause, args := q.SQL()
+	rows, err := r.db.Query(`SELECT id, first_name, last_name, email, role, status FROM users`+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch users: %v", err)
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		var firstName, lastName sql.NullString
+		if err := rows.Scan(&user.ID, &firstName, &lastName, &user.Email, &user.Role, &user.Status); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %v", err)
+		}
+		user.FirstName, user.LastName = firstName.String, lastName.String
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch users: %v", err)
+	}
+	return users, nil
+}
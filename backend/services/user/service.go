@@ -1,17 +1,20 @@
 package user
 
 import (
+	"backend/database"
+	"backend/services/interfaces"
+	"backend/services/listquery"
 	"errors"
 	"fmt"
 )
 
 // UserService handles business logic for users.
 type UserService struct {
-	repo *UserRepository
+	repo interfaces.UserRepositoryInterface
 }
 
 // NewUserService initializes a new UserService.
-func NewUserService(repo *UserRepository) *UserService {
+func NewUserService(repo interfaces.UserRepositoryInterface) *UserService {
 	return &UserService{repo: repo}
 }
 
@@ -58,6 +61,15 @@ func (s *UserService) UpdateUser(userID string, user User) error {
 	return nil
 }
 
+// ListUsers retrieves one page of users.
+func (s *UserService) ListUsers(q listquery.Query) (listquery.Page[User], error) {
+	users, err := s.repo.ListUsers(q)
+	if err != nil {
+		return listquery.Page[User]{}, fmt.Errorf("failed to list users: %v", err)
+	}
+	return listquery.NewPage(users, q, ListField)
+}
+
 // GetUserByEmail retrieves a user's details by their email.
 func (s *UserService) GetUserByEmail(email string) (User, error) {
 	user, err := s.repo.GetUserByEmail(email)
@@ -69,7 +81,7 @@ func (s *UserService) GetUserByEmail(email string) (User, error) {
 
 // SyncOrInsertUser checks if the user exists by email; if not, inserts it with given role.
 func SyncOrInsertUser(email, role string) (int, error) {
-	repo := NewUserRepository()
+	repo := NewUserRepository(database.DB)
 	existingUser, err := repo.GetUserByEmail(email)
 	if err == nil {
 		// User exists
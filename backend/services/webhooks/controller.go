@@ -0,0 +1,151 @@
+package webhooks
+
+import (
+	"backend/models"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// requireAdmin writes 403 and reports false unless the caller is an Admin
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	userCtx := r.Context().Value("user").(map[string]interface{})
+	if userCtx["role"].(string) != "Admin" {
+		http.Error(w, "Unauthorized: only Admin can manage webhooks", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// CreateSubscriptionHandler restricts to Admin. The response carries the signing secret,
+// which is not shown again.
+func CreateSubscriptionHandler(service *WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(w, r) {
+			return
+		}
+
+		var body models.WebhookSubscription
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		userCtx := r.Context().Value("user").(map[string]interface{})
+		subscription, err := service.CreateSubscription(body, userCtx["id"].(int))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(subscription)
+	}
+}
+
+// ListSubscriptionsHandler restricts to Admin
+func ListSubscriptionsHandler(service *WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(w, r) {
+			return
+		}
+
+		subscriptions, err := service.ListSubscriptions()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(subscriptions)
+	}
+}
+
+// DeleteSubscriptionHandler restricts to Admin
+func DeleteSubscriptionHandler(service *WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(w, r) {
+			return
+		}
+
+		subscriptionID, err := strconv.ParseInt(mux.Vars(r)["webhook_id"], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := service.DeleteSubscription(subscriptionID); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Webhook subscription deactivated"})
+	}
+}
+
+// ListDeliveriesHandler restricts to Admin. ?limit= caps the result (default 100, max 500).
+func ListDeliveriesHandler(service *WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(w, r) {
+			return
+		}
+
+		subscriptionID, err := strconv.ParseInt(mux.Vars(r)["webhook_id"], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+			return
+		}
+
+		limit := 100
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > 500 {
+				http.Error(w, "limit must be between 1 and 500", http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+
+		deliveries, err := service.ListDeliveries(subscriptionID, limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(deliveries)
+	}
+}
+
+// ReplayDeliveryHandler restricts to Admin. It answers with the new attempt, successful or not.
+func ReplayDeliveryHandler(service *WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(w, r) {
+			return
+		}
+
+		deliveryID, err := strconv.ParseInt(mux.Vars(r)["delivery_id"], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid delivery ID", http.StatusBadRequest)
+			return
+		}
+
+		delivery, err := service.Replay(r.Context(), deliveryID)
+		if err != nil {
+			status := http.StatusNotFound
+			if errors.Is(err, ErrSubscriptionInactive) {
+				status = http.StatusConflict
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(delivery)
+	}
+}
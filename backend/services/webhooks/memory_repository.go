@@ -0,0 +1,141 @@
+package webhooks
+
+import (
+	"backend/database/memstore"
+	"backend/models"
+	"backend/services/interfaces"
+	"fmt"
+	"slices"
+	"time"
+)
+
+var _ interfaces.WebhookRepositoryInterface = (*MemoryWebhookRepository)(nil)
+
+// MemoryWebhookRepository is an in-memory implementation of interfaces.WebhookRepositoryInterface
+type MemoryWebhookRepository struct {
+	store *memstore.Store
+}
+
+// NewMemoryWebhookRepository initializes a new MemoryWebhookRepository on the given store
+func NewMemoryWebhookRepository(store *memstore.Store) *MemoryWebhookRepository {
+	return &MemoryWebhookRepository{store: store}
+}
+
+// CreateSubscription stores an active subscription
+func (r *MemoryWebhookRepository) CreateSubscription(subscription models.WebhookSubscription) (models.WebhookSubscription, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	subscription.ID = r.store.NextWebhookSubscriptionID
+	subscription.EventTypes = slices.Clone(subscription.EventTypes)
+	subscription.Active = true
+	subscription.CreatedAt = time.Now().UTC().Format("2006-01-02 15:04:05")
+	r.store.NextWebhookSubscriptionID++
+	r.store.WebhookSubscriptions = append(r.store.WebhookSubscriptions, subscription)
+	return subscription, nil
+}
+
+// GetSubscription retrieves a subscription, active or not, including its secret
+func (r *MemoryWebhookRepository) GetSubscription(subscriptionID int64) (models.WebhookSubscription, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	for _, subscription := range r.store.WebhookSubscriptions {
+		if subscription.ID == subscriptionID {
+			return subscription, nil
+		}
+	}
+	return models.WebhookSubscription{}, fmt.Errorf("webhook subscription with ID %d does not exist", subscriptionID)
+}
+
+// ListSubscriptions returns every subscription, newest first
+func (r *MemoryWebhookRepository) ListSubscriptions() ([]models.WebhookSubscription, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	subscriptions := slices.Clone(r.store.WebhookSubscriptions)
+	slices.Reverse(subscriptions)
+	return subscriptions, nil
+}
+
+// ListActiveSubscriptions returns the active subscriptions to an event type
+func (r *MemoryWebhookRepository) ListActiveSubscriptions(eventType string) ([]models.WebhookSubscription, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	var subscriptions []models.WebhookSubscription
+	for _, subscription := range r.store.WebhookSubscriptions {
+		if subscription.Active && slices.Contains(subscription.EventTypes, eventType) {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	return subscriptions, nil
+}
+
+// DeactivateSubscription stops deliveries to a subscription but keeps its delivery log
+func (r *MemoryWebhookRepository) DeactivateSubscription(subscriptionID int64) error {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	for i := range r.store.WebhookSubscriptions {
+		if r.store.WebhookSubscriptions[i].ID == subscriptionID {
+			r.store.WebhookSubscriptions[i].Active = false
+			return nil
+		}
+	}
+	return fmt.Errorf("webhook subscription with ID %d does not exist", subscriptionID)
+}
+
+// RecordDelivery appends an attempt to the delivery log
+func (r *MemoryWebhookRepository) RecordDelivery(delivery models.WebhookDelivery) (models.WebhookDelivery, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	delivery.ID = r.store.NextWebhookDeliveryID
+	delivery.CreatedAt = time.Now().UTC().Format("2006-01-02 15:04:05")
+	r.store.NextWebhookDeliveryID++
+	r.store.WebhookDeliveries = append(r.store.WebhookDeliveries, delivery)
+	return delivery, nil
+}
+
+// GetDelivery retrieves one attempt from the delivery log
+func (r *MemoryWebhookRepository) GetDelivery(deliveryID int64) (models.WebhookDelivery, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	for _, delivery := range r.store.WebhookDeliveries {
+		if delivery.ID == deliveryID {
+			return delivery, nil
+		}
+	}
+	return models.WebhookDelivery{}, fmt.Errorf("webhook delivery with ID %d does not exist", deliveryID)
+}
+
+// ListDeliveries returns a subscription's most recent attempts
+func (r *MemoryWebhookRepository) ListDeliveries(subscriptionID int64, limit int) ([]models.WebhookDelivery, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	var deliveries []models.WebhookDelivery
+	for i := len(r.store.WebhookDeliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if r.store.WebhookDeliveries[i].SubscriptionID == subscriptionID {
+			deliveries = append(deliveries, r.store.WebhookDeliveries[i])
+		}
+	}
+	return deliveries, nil
+}
+
+// DeliveryState counts the attempts logged for an event and subscription
+func (r *MemoryWebhookRepository) DeliveryState(subscriptionID, eventID int64) (int, bool, error) {
+	r.store.Mu.Lock()
+	defer r.store.Mu.Unlock()
+
+	attempts, delivered := 0, false
+	for _, delivery := range r.store.WebhookDeliveries {
+		if delivery.SubscriptionID == subscriptionID && delivery.EventID == eventID {
+			attempts++
+			delivered = delivered || delivery.Succeeded
+		}
+	}
+	return attempts, delivered, nil
+}
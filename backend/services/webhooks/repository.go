@@ -0,0 +1,228 @@
+package webhooks
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"backend/models"
+	"backend/services/interfaces"
+	"backend/services/metrics"
+)
+
+var _ interfaces.WebhookRepositoryInterface = (*WebhookRepository)(nil)
+
+// WebhookRepository is the MySQL implementation of interfaces.WebhookRepositoryInterface
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+// NewWebhookRepository initializes a new WebhookRepository
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+const subscriptionQuery = `SELECT id, url, event_types, secret, active, created_by, created_at FROM webhook_subscriptions`
+
+const deliveryQuery = `SELECT id, subscription_id, event_id, event_type, attempt, replay, succeeded, status_code,
+		COALESCE(error, ''), duration_ms, created_at
+	FROM webhook_deliveries`
+
+// CreateSubscription inserts a subscription
+func (r *WebhookRepository) CreateSubscription(subscription models.WebhookSubscription) (models.WebhookSubscription, error) {
+	defer metrics.ObserveQuery("WebhookRepository", "CreateSubscription", time.Now())
+	eventTypes, err := json.Marshal(subscription.EventTypes)
+	if err != nil {
+		return models.WebhookSubscription{}, fmt.Errorf("failed to encode event types: %v", err)
+	}
+
+	result, err := r.db.Exec(
+		`INSERT INTO webhook_subscriptions (url, event_types, secret, created_by) VALUES (?, ?, ?, ?)`,
+		subscription.URL, eventTypes, subscription.Secret, subscription.CreatedBy,
+	)
+	if err != nil {
+		return models.WebhookSubscription{}, fmt.Errorf("failed to insert webhook subscription: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.WebhookSubscription{}, fmt.Errorf("failed to get webhook subscription ID: %v", err)
+	}
+	return r.GetSubscription(id)
+}
+
+// GetSubscription retrieves a subscription, active or not, including its secret
+func (r *WebhookRepository) GetSubscription(subscriptionID int64) (models.WebhookSubscription, error) {
+	defer metrics.ObserveQuery("WebhookRepository", "GetSubscription", time.Now())
+	subscription, err := scanSubscription(r.db.QueryRow(subscriptionQuery+` WHERE id = ?`, subscriptionID))
+	if err == sql.ErrNoRows {
+		return models.WebhookSubscription{}, fmt.Errorf("webhook subscription with ID %d does not exist", subscriptionID)
+	}
+	if err != nil {
+		return models.WebhookSubscription{}, fmt.Errorf("failed to retrieve webhook subscription: %v", err)
+	}
+	return subscription, nil
+}
+
+// ListSubscriptions returns every subscription, newest first
+func (r *WebhookRepository) ListSubscriptions() ([]models.WebhookSubscription, error) {
+	defer metrics.ObserveQuery("WebhookRepository", "ListSubscriptions", time.Now())
+	rows, err := r.db.Query(subscriptionQuery + ` ORDER BY id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %v", err)
+	}
+	return scanSubscriptions(rows)
+}
+
+// ListActiveSubscriptions returns the active subscriptions to an event type
+func (r *WebhookRepository) ListActiveSubscriptions(eventType string) ([]models.WebhookSubscription, error) {
+	defer metrics.ObserveQuery("WebhookRepository", "ListActiveSubscriptions", time.Now())
+	rows, err := r.db.Query(
+		subscriptionQuery+` WHERE active = TRUE AND JSON_CONTAINS(event_types, JSON_QUOTE(?)) ORDER BY id`,
+		eventType,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %v", err)
+	}
+	return scanSubscriptions(rows)
+}
+
+// DeactivateSubscription stops deliveries to a subscription but keeps its delivery log
+func (r *WebhookRepository) DeactivateSubscription(subscriptionID int64) error {
+	defer metrics.ObserveQuery("WebhookRepository", "DeactivateSubscription", time.Now())
+	result, err := r.db.Exec(`UPDATE webhook_subscriptions SET active = FALSE WHERE id = ?`, subscriptionID)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate webhook subscription: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		if _, err := r.GetSubscription(subscriptionID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordDelivery appends an attempt to the delivery log
+func (r *WebhookRepository) RecordDelivery(delivery models.WebhookDelivery) (models.WebhookDelivery, error) {
+	defer metrics.ObserveQuery("WebhookRepository", "RecordDelivery", time.Now())
+	var deliveryError interface{}
+	if delivery.Error != "" {
+		deliveryError = delivery.Error
+	}
+
+	result, err := r.db.Exec(
+		`INSERT INTO webhook_deliveries
+		(subscription_id, event_id, event_type, attempt, replay, succeeded, status_code, error, duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		delivery.SubscriptionID, delivery.EventID, delivery.EventType, delivery.Attempt, delivery.Replay,
+		delivery.Succeeded, delivery.StatusCode, deliveryError, delivery.DurationMs,
+	)
+	if err != nil {
+		return models.WebhookDelivery{}, fmt.Errorf("failed to record webhook delivery: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.WebhookDelivery{}, fmt.Errorf("failed to get webhook delivery ID: %v", err)
+	}
+	return r.GetDelivery(id)
+}
+
+// GetDelivery retrieves one attempt from the delivery log
+func (r *WebhookRepository) GetDelivery(deliveryID int64) (models.WebhookDelivery, error) {
+	defer metrics.ObserveQuery("WebhookRepository", "GetDelivery", time.Now())
+	delivery, err := scanDelivery(r.db.QueryRow(deliveryQuery+` WHERE id = ?`, deliveryID))
+	if err == sql.ErrNoRows {
+		return models.WebhookDelivery{}, fmt.Errorf("webhook delivery with ID %d does not exist", deliveryID)
+	}
+	if err != nil {
+		return models.WebhookDelivery{}, fmt.Errorf("failed to retrieve webhook delivery: %v", err)
+	}
+	return delivery, nil
+}
+
+// ListDeliveries returns a subscription's most recent attempts
+func (r *WebhookRepository) ListDeliveries(subscriptionID int64, limit int) ([]models.WebhookDelivery, error) {
+	defer metrics.ObserveQuery("WebhookRepository", "ListDeliveries", time.Now())
+	rows, err := r.db.Query(deliveryQuery+` WHERE subscription_id = ? ORDER BY id DESC LIMIT ?`, subscriptionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %v", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %v", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read webhook deliveries: %v", err)
+	}
+	return deliveries, nil
+}
+
+// DeliveryState counts the attempts logged for an event and subscription
+func (r *WebhookRepository) DeliveryState(subscriptionID, eventID int64) (int, bool, error) {
+	defer metrics.ObserveQuery("WebhookRepository", "DeliveryState", time.Now())
+	var attempts int
+	var delivered bool
+	err := r.db.QueryRow(
+		`SELECT COUNT(*), COALESCE(MAX(succeeded), FALSE) FROM webhook_deliveries WHERE subscription_id = ? AND event_id = ?`,
+		subscriptionID, eventID,
+	).Scan(&attempts, &delivered)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read webhook delivery state: %v", err)
+	}
+	return attempts, delivered, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSubscription(row scanner) (models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	var eventTypes []byte
+	err := row.Scan(
+		&subscription.ID, &subscription.URL, &eventTypes, &subscription.Secret,
+		&subscription.Active, &subscription.CreatedBy, &subscription.CreatedAt,
+	)
+	if err != nil {
+		return models.WebhookSubscription{}, err
+	}
+	if err := json.Unmarshal(eventTypes, &subscription.EventTypes); err != nil {
+		return models.WebhookSubscription{}, fmt.Errorf("failed to decode event types: %v", err)
+	}
+	return subscription, nil
+}
+
+func scanSubscriptions(rows *sql.Rows) ([]models.WebhookSubscription, error) {
+	defer rows.Close()
+
+	var subscriptions []models.WebhookSubscription
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription: %v", err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read webhook subscriptions: %v", err)
+	}
+	return subscriptions, nil
+}
+
+func scanDelivery(row scanner) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := row.Scan(
+		&delivery.ID, &delivery.SubscriptionID, &delivery.EventID, &delivery.EventType, &delivery.Attempt,
+		&delivery.Replay, &delivery.Succeeded, &delivery.StatusCode, &delivery.Error, &delivery.DurationMs,
+		&delivery.CreatedAt,
+	)
+	return delivery, err
+}
@@ -0,0 +1,337 @@
+package webhooks
+
+import (
+	"backend/models"
+	"backend/services/events"
+	"backend/services/interfaces"
+	"backend/services/logging"
+	"backend/services/metrics"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Subscriber is the outbox subscriber name of the WebhookService
+const Subscriber = "webhooks"
+
+// Delivery headers. The signature is "sha256=" followed by the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the subscription's secret.
+const (
+	HeaderEventID   = "X-Webhook-ID"
+	HeaderEventType = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// EventTypes are the events a subscription may ask for
+var EventTypes = []string{
+	events.TypeClientCreated, events.TypeClientUpdated, events.TypeClientDeleted,
+	events.TypeAccountCreated, events.TypeAccountUpdated, events.TypeAccountDeleted,
+}
+
+// ErrSubscriptionInactive is returned when replaying to a deactivated subscription
+var ErrSubscriptionInactive = errors.New("webhook subscription is not active")
+
+// Config controls outbound requests
+type Config struct {
+	Timeout time.Duration
+}
+
+// ConfigFromEnv reads WEBHOOK_TIMEOUT (default 10s), how long a receiver has to answer
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{Timeout: 10 * time.Second}
+
+	if raw := os.Getenv("WEBHOOK_TIMEOUT"); raw != "" {
+		timeout, err := time.ParseDuration(raw)
+		if err != nil || timeout <= 0 {
+			return Config{}, fmt.Errorf("WEBHOOK_TIMEOUT must be a positive duration such as 5s, got %q", raw)
+		}
+		cfg.Timeout = timeout
+	}
+	return cfg, nil
+}
+
+// WebhookService manages subscriptions and delivers events to them. Deliveries ride on the
+// event outbox, on a worker of their own so slow receivers do not hold up the other
+// subscribers: a failed receiver makes the outbox retry the event with exponential backoff,
+// and receivers that already accepted it are skipped on the retry.
+type WebhookService struct {
+	repo   interfaces.WebhookRepositoryInterface
+	outbox interfaces.OutboxRepositoryInterface
+	client *http.Client
+	logger *slog.Logger
+}
+
+// NewWebhookService initializes the webhook service
+func NewWebhookService(repo interfaces.WebhookRepositoryInterface, outbox interfaces.OutboxRepositoryInterface, config Config, logger *slog.Logger) *WebhookService {
+	return &WebhookService{
+		repo:   repo,
+		outbox: outbox,
+		client: &http.Client{
+			Timeout: config.Timeout,
+			// A redirect is treated as a failed delivery rather than followed with the signed body
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		logger: logger,
+	}
+}
+
+// Register subscribes the webhooks, on their own worker, to every event type a subscription
+// may ask for
+func (s *WebhookService) Register(dispatcher *events.Dispatcher) {
+	dispatcher.SubscribeIsolated(Subscriber, s.Handle, EventTypes...)
+}
+
+// CreateSubscription validates and stores a subscription. A secret is generated when none is
+// given; the returned subscription is the only place it is shown.
+func (s *WebhookService) CreateSubscription(subscription models.WebhookSubscription, createdBy int) (models.WebhookSubscription, error) {
+	target, err := url.Parse(subscription.URL)
+	if err != nil || (target.Scheme != "https" && target.Scheme != "http") || target.Host == "" {
+		return models.WebhookSubscription{}, fmt.Errorf("url must be an absolute http or https URL")
+	}
+
+	if len(subscription.EventTypes) == 0 {
+		return models.WebhookSubscription{}, fmt.Errorf("at least one event type is required")
+	}
+	var eventTypes []string
+	for _, eventType := range subscription.EventTypes {
+		if !slices.Contains(EventTypes, eventType) {
+			return models.WebhookSubscription{}, fmt.Errorf("unsupported event type %q, must be one of %v", eventType, EventTypes)
+		}
+		if !slices.Contains(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	subscription.EventTypes = eventTypes
+
+	if subscription.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return models.WebhookSubscription{}, fmt.Errorf("failed to generate secret: %v", err)
+		}
+		subscription.Secret = hex.EncodeToString(secret)
+	} else if len(subscription.Secret) < 16 {
+		return models.WebhookSubscription{}, fmt.Errorf("secret must be at least 16 characters")
+	}
+
+	subscription.CreatedBy = createdBy
+	return s.repo.CreateSubscription(subscription)
+}
+
+// ListSubscriptions returns every subscription without its secret
+func (s *WebhookService) ListSubscriptions() ([]models.WebhookSubscription, error) {
+	subscriptions, err := s.repo.ListSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+	return subscriptions, nil
+}
+
+// DeleteSubscription stops deliveries to a subscription; its delivery log is kept
+func (s *WebhookService) DeleteSubscription(subscriptionID int64) error {
+	return s.repo.DeactivateSubscription(subscriptionID)
+}
+
+// ListDeliveries returns a subscription's most recent delivery attempts
+func (s *WebhookService) ListDeliveries(subscriptionID int64, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := s.repo.GetSubscription(subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(subscriptionID, limit)
+}
+
+// Handle delivers one outbox event to every active subscription for its type, concurrently.
+// It fails if any receiver did, so the outbox retries; receivers that already accepted it are
+// skipped.
+func (s *WebhookService) Handle(ctx context.Context, event events.Event, meta models.EventMeta) error {
+	subscriptions, err := s.repo.ListActiveSubscriptions(event.Type())
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	body, err := Payload(event, meta)
+	if err != nil {
+		return err
+	}
+
+	failed := make([]error, len(subscriptions))
+	var wg sync.WaitGroup
+	for i, subscription := range subscriptions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			attempts, delivered, err := s.repo.DeliveryState(subscription.ID, meta.EventID)
+			if err != nil {
+				failed[i] = err
+				return
+			}
+			if delivered {
+				return
+			}
+
+			if _, err := s.deliver(ctx, subscription, meta.EventID, event.Type(), body, attempts+1, false); err != nil {
+				failed[i] = fmt.Errorf("webhook %d: %v", subscription.ID, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(failed...)
+}
+
+// Replay sends the event of a logged delivery to its subscription again, now, whether or not
+// it was delivered before. The new attempt is logged and returned.
+func (s *WebhookService) Replay(ctx context.Context, deliveryID int64) (models.WebhookDelivery, error) {
+	previous, err := s.repo.GetDelivery(deliveryID)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	subscription, err := s.repo.GetSubscription(previous.SubscriptionID)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	if !subscription.Active {
+		return models.WebhookDelivery{}, ErrSubscriptionInactive
+	}
+
+	stored, err := s.outbox.GetEvent(previous.EventID)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	event, err := events.Decode(stored)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	meta := models.EventMeta{AgentID: stored.AgentID, RequestID: stored.RequestID, EventID: stored.ID, OccurredAt: stored.CreatedAt}
+
+	body, err := Payload(event, meta)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	attempts, _, err := s.repo.DeliveryState(subscription.ID, stored.ID)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	// A failed replay is still a logged attempt; the caller reads the outcome from it
+	delivery, _ := s.deliver(ctx, subscription, stored.ID, stored.Type, body, attempts+1, true)
+	return delivery, nil
+}
+
+// envelope is the JSON body of a delivery
+type envelope struct {
+	ID         int64        `json:"id"`
+	Type       string       `json:"type"`
+	ClientID   string       `json:"client_id"`
+	AgentID    int          `json:"agent_id"`
+	RequestID  string       `json:"request_id,omitempty"`
+	OccurredAt string       `json:"occurred_at"`
+	Data       events.Event `json:"data"`
+}
+
+// Payload builds the body delivered for an event
+func Payload(event events.Event, meta models.EventMeta) ([]byte, error) {
+	body, err := json.Marshal(envelope{
+		ID:         meta.EventID,
+		Type:       event.Type(),
+		ClientID:   event.ClientID(),
+		AgentID:    meta.AgentID,
+		RequestID:  meta.RequestID,
+		OccurredAt: meta.OccurredAt,
+		Data:       event,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %v", err)
+	}
+	return body, nil
+}
+
+// Sign computes the X-Webhook-Signature of a body sent at timestamp
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliver posts a signed body to one subscription and logs the attempt. Any response other
+// than 2xx is a failure.
+func (s *WebhookService) deliver(ctx context.Context, subscription models.WebhookSubscription, eventID int64, eventType string, body []byte, attempt int, replay bool) (models.WebhookDelivery, error) {
+	delivery := models.WebhookDelivery{
+		SubscriptionID: subscription.ID,
+		EventID:        eventID,
+		EventType:      eventType,
+		Attempt:        attempt,
+		Replay:         replay,
+	}
+
+	start := time.Now()
+	statusCode, err := s.post(ctx, subscription, eventID, eventType, body)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	delivery.StatusCode = statusCode
+	if err == nil && (statusCode < 200 || statusCode > 299) {
+		err = fmt.Errorf("receiver answered %d", statusCode)
+	}
+	delivery.Succeeded = err == nil
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+	metrics.ObserveWebhook(eventType, delivery.Succeeded)
+
+	logged, logErr := s.repo.RecordDelivery(delivery)
+	if logErr != nil {
+		s.logger.ErrorContext(ctx, "failed to log webhook delivery", "subscription_id", subscription.ID, "event_id", eventID, "error", logErr)
+		logged = delivery
+	}
+	if err != nil {
+		s.logger.WarnContext(ctx, "webhook delivery failed",
+			"subscription_id", subscription.ID, "event_id", eventID, "attempt", attempt, "error", err)
+	}
+	return logged, err
+}
+
+func (s *WebhookService) post(ctx context.Context, subscription models.WebhookSubscription, eventID int64, eventType string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build request: %v", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, strconv.FormatInt(eventID, 10))
+	req.Header.Set(HeaderEventType, eventType)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, timestamp, body))
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		req.Header.Set("X-Request-ID", requestID)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
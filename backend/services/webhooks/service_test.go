@@ -0,0 +1,371 @@
+package webhooks
+
+import (
+	"backend/database/memstore"
+	"backend/models"
+	"backend/services/events"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// receivedRequest is one delivery as the receiver saw it
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// receiver is a webhook endpoint answering with the given status codes in turn, then 200
+type receiver struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	received []receivedRequest
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	t.Helper()
+	r := &receiver{statuses: statuses}
+	r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.received = append(r.received, receivedRequest{header: req.Header.Clone(), body: body})
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+func (r *receiver) requests() []receivedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedRequest(nil), r.received...)
+}
+
+type webhookFixture struct {
+	store      *memstore.Store
+	service    *WebhookService
+	dispatcher *events.Dispatcher
+}
+
+func newWebhookFixture(t *testing.T) webhookFixture {
+	t.Helper()
+	store := memstore.New()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	outbox := events.NewMemoryOutboxRepository(store)
+
+	service := NewWebhookService(NewMemoryWebhookRepository(store), outbox, Config{Timeout: 5 * time.Second}, logger)
+	dispatcher := events.NewDispatcher(outbox, events.DispatcherConfig{
+		BatchSize:   10,
+		MaxAttempts: 5,
+		BaseBackoff: time.Hour,
+		MaxBackoff:  4 * time.Hour,
+		Lease:       time.Minute,
+	}, logger)
+	service.Register(dispatcher)
+
+	return webhookFixture{store: store, service: service, dispatcher: dispatcher}
+}
+
+func (f webhookFixture) subscribe(t *testing.T, r *receiver) models.WebhookSubscription {
+	t.Helper()
+	subscription, err := f.service.CreateSubscription(models.WebhookSubscription{
+		URL:        r.server.URL,
+		EventTypes: []string{events.TypeClientCreated},
+		Secret:     testSecret,
+	}, 1)
+	if err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	return subscription
+}
+
+func (f webhookFixture) appendEvent(t *testing.T, event events.Event) {
+	t.Helper()
+	f.store.Mu.Lock()
+	defer f.store.Mu.Unlock()
+	if err := events.AppendLocked(f.store, models.EventMeta{AgentID: 1, RequestID: "req-1"}, event); err != nil {
+		t.Fatalf("AppendLocked: %v", err)
+	}
+}
+
+func (f webhookFixture) dispatch(t *testing.T) int {
+	t.Helper()
+	attempted, err := f.dispatcher.DispatchOnce(context.Background())
+	if err != nil {
+		t.Fatalf("DispatchOnce: %v", err)
+	}
+	return attempted
+}
+
+// retryIn is how long until the webhooks' outbox delivery is next due
+func (f webhookFixture) retryIn(t *testing.T) time.Duration {
+	t.Helper()
+	f.store.Mu.Lock()
+	defer f.store.Mu.Unlock()
+	for _, delivery := range f.store.OutboxDeliveries {
+		if delivery.Subscriber == Subscriber {
+			availableAt, err := time.Parse(time.RFC3339Nano, delivery.AvailableAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return time.Until(availableAt)
+		}
+	}
+	t.Fatal("no outbox delivery for the webhooks")
+	return 0
+}
+
+// makeDue moves the webhooks' outbox delivery to now, as if its backoff had passed
+func (f webhookFixture) makeDue() {
+	f.store.Mu.Lock()
+	defer f.store.Mu.Unlock()
+	for i := range f.store.OutboxDeliveries {
+		if f.store.OutboxDeliveries[i].Subscriber == Subscriber {
+			f.store.OutboxDeliveries[i].AvailableAt = time.Now().Format(time.RFC3339Nano)
+		}
+	}
+}
+
+// checkSignature verifies a request the way a receiver would
+func checkSignature(t *testing.T, request receivedRequest) {
+	t.Helper()
+	timestamp := request.header.Get(HeaderTimestamp)
+	if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		t.Fatalf("%s = %q, want a Unix time", HeaderTimestamp, timestamp)
+	}
+
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(request.body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := request.header.Get(HeaderSignature); !hmac.Equal([]byte(got), []byte(want)) {
+		t.Errorf("%s = %q, want %q", HeaderSignature, got, want)
+	}
+}
+
+func TestWebhookDeliveryRetriesWithBackoff(t *testing.T) {
+	f := newWebhookFixture(t)
+	failing := newReceiver(t, http.StatusServiceUnavailable, http.StatusInternalServerError)
+	healthy := newReceiver(t)
+	subscription := f.subscribe(t, failing)
+	f.subscribe(t, healthy)
+
+	f.appendEvent(t, events.ClientCreated{Client: models.Client{ClientID: "client1", City: "Singapore"}})
+
+	// First attempt: the failing receiver answers 503, so the event is retried an hour later
+	if attempted := f.dispatch(t); attempted != 1 {
+		t.Fatalf("attempted %d deliveries, want 1", attempted)
+	}
+	if retryIn := f.retryIn(t); retryIn < 59*time.Minute || retryIn > time.Hour {
+		t.Errorf("retry in %s after the first failure, want an hour", retryIn)
+	}
+	if attempted := f.dispatch(t); attempted != 0 {
+		t.Fatalf("retried %d deliveries before the backoff passed", attempted)
+	}
+
+	// Second attempt: 500, and the backoff doubles
+	f.makeDue()
+	f.dispatch(t)
+	if retryIn := f.retryIn(t); retryIn < 119*time.Minute || retryIn > 2*time.Hour {
+		t.Errorf("retry in %s after the second failure, want two hours", retryIn)
+	}
+
+	// Third attempt succeeds
+	f.makeDue()
+	f.dispatch(t)
+	if attempted := f.dispatch(t); attempted != 0 {
+		t.Errorf("attempted %d deliveries after the event was delivered", attempted)
+	}
+
+	requests := failing.requests()
+	if len(requests) != 3 {
+		t.Fatalf("failing receiver got %d requests, want 3", len(requests))
+	}
+	for _, request := range requests {
+		checkSignature(t, request)
+		if request.header.Get(HeaderEventType) != events.TypeClientCreated || request.header.Get(HeaderEventID) != "1" {
+			t.Errorf("delivered as event %s %s", request.header.Get(HeaderEventType), request.header.Get(HeaderEventID))
+		}
+		if request.header.Get("X-Request-ID") != "req-1" {
+			t.Errorf("X-Request-ID = %q, want the request that caused the event", request.header.Get("X-Request-ID"))
+		}
+	}
+	var body struct {
+		ID       int64                `json:"id"`
+		Type     string               `json:"type"`
+		ClientID string               `json:"client_id"`
+		AgentID  int                  `json:"agent_id"`
+		Data     events.ClientCreated `json:"data"`
+	}
+	if err := json.Unmarshal(requests[0].body, &body); err != nil {
+		t.Fatalf("body: %v", err)
+	}
+	if body.ID != 1 || body.Type != events.TypeClientCreated || body.ClientID != "client1" || body.AgentID != 1 || body.Data.Client.City != "Singapore" {
+		t.Errorf("body = %+v", body)
+	}
+
+	// The receiver that accepted the first attempt is not sent the retries
+	if n := len(healthy.requests()); n != 1 {
+		t.Errorf("healthy receiver got %d requests, want 1", n)
+	}
+
+	deliveries, err := f.service.ListDeliveries(subscription.ID, 10)
+	if err != nil {
+		t.Fatalf("ListDeliveries: %v", err)
+	}
+	if len(deliveries) != 3 {
+		t.Fatalf("logged %d deliveries, want 3", len(deliveries))
+	}
+	// Newest first
+	for i, want := range []struct {
+		attempt    int
+		statusCode int
+		succeeded  bool
+	}{{3, 200, true}, {2, 500, false}, {1, 503, false}} {
+		got := deliveries[i]
+		if got.Attempt != want.attempt || got.StatusCode != want.statusCode || got.Succeeded != want.succeeded || got.Replay {
+			t.Errorf("delivery %d = attempt %d, status %d, succeeded %v, replay %v; want attempt %d, status %d, succeeded %v",
+				i, got.Attempt, got.StatusCode, got.Succeeded, got.Replay, want.attempt, want.statusCode, want.succeeded)
+		}
+		if got.EventID != 1 || got.EventType != events.TypeClientCreated {
+			t.Errorf("delivery %d logged for event %d %s", i, got.EventID, got.EventType)
+		}
+		if !got.Succeeded && got.Error == "" {
+			t.Errorf("failed delivery %d logged without an error", i)
+		}
+	}
+}
+
+func TestWebhookReplay(t *testing.T) {
+	f := newWebhookFixture(t)
+	r := newReceiver(t, http.StatusOK, http.StatusBadGateway)
+	subscription := f.subscribe(t, r)
+
+	f.appendEvent(t, events.ClientCreated{Client: models.Client{ClientID: "client1"}})
+	f.dispatch(t)
+
+	deliveries, err := f.service.ListDeliveries(subscription.ID, 10)
+	if err != nil || len(deliveries) != 1 || !deliveries[0].Succeeded {
+		t.Fatalf("deliveries = %v, %v; want one successful delivery", deliveries, err)
+	}
+
+	// A replay is sent whether or not the event was delivered, and a failed one is still logged
+	replayed, err := f.service.Replay(context.Background(), deliveries[0].ID)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if !replayed.Replay || replayed.Attempt != 2 || replayed.Succeeded || replayed.StatusCode != http.StatusBadGateway {
+		t.Errorf("replay logged as %+v, want a failed second attempt", replayed)
+	}
+
+	replayed, err = f.service.Replay(context.Background(), deliveries[0].ID)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if !replayed.Replay || replayed.Attempt != 3 || !replayed.Succeeded {
+		t.Errorf("replay logged as %+v, want a successful third attempt", replayed)
+	}
+
+	requests := r.requests()
+	if len(requests) != 3 {
+		t.Fatalf("receiver got %d requests, want 3", len(requests))
+	}
+	for _, request := range requests {
+		checkSignature(t, request)
+		if string(request.body) != string(requests[0].body) {
+			t.Errorf("replayed body %s differs from the original %s", request.body, requests[0].body)
+		}
+	}
+
+	if err := f.service.DeleteSubscription(subscription.ID); err != nil {
+		t.Fatalf("DeleteSubscription: %v", err)
+	}
+	if _, err := f.service.Replay(context.Background(), deliveries[0].ID); err != ErrSubscriptionInactive {
+		t.Errorf("Replay to a deleted subscription = %v, want ErrSubscriptionInactive", err)
+	}
+}
+
+func TestSlowReceiverHoldsUpNeitherSubscribersNorShutdown(t *testing.T) {
+	f := newWebhookFixture(t)
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		started <- struct{}{}
+		select {
+		case <-release:
+		case <-req.Context().Done():
+		}
+	}))
+	t.Cleanup(slow.Close)
+	t.Cleanup(func() { close(release) })
+	if _, err := f.service.CreateSubscription(models.WebhookSubscription{
+		URL:        slow.URL,
+		EventTypes: []string{events.TypeClientCreated},
+		Secret:     testSecret,
+	}, 1); err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dispatcher := events.NewDispatcher(events.NewMemoryOutboxRepository(f.store), events.DispatcherConfig{
+		PollInterval: 10 * time.Millisecond,
+		BatchSize:    10,
+		MaxAttempts:  5,
+		BaseBackoff:  time.Hour,
+		MaxBackoff:   time.Hour,
+		Lease:        time.Minute,
+	}, logger)
+	f.service.Register(dispatcher)
+	audited := make(chan int64, 1)
+	dispatcher.Subscribe("audit_log", func(ctx context.Context, event events.Event, meta models.EventMeta) error {
+		audited <- meta.EventID
+		return nil
+	}, events.TypeClientCreated)
+
+	f.appendEvent(t, events.ClientCreated{Client: models.Client{ClientID: "client1"}})
+	ctx, stop := context.WithCancel(context.Background())
+	dispatcher.Start(ctx)
+	defer stop()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the webhook was not delivered")
+	}
+	// The receiver has not answered, yet the audit subscriber gets the event
+	select {
+	case <-audited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the audit subscriber waited on the webhook receiver")
+	}
+
+	stop()
+	waitCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := dispatcher.Wait(waitCtx); err != nil {
+		t.Fatalf("shutdown waited on the webhook receiver: %v", err)
+	}
+	if f.retryIn(t) <= 0 {
+		t.Error("the cancelled webhook delivery was not scheduled for a retry")
+	}
+}
@@ -41,8 +41,14 @@ export const logService = {
     return await api.get(`/communication_logs/${logId}`)
   },
 
-  async deleteLog(logId) {
-    return await api.delete(`/agentclient_logs/${logId}`)
+  // Deleting a log redacts it; the backend records who redacted it and why
+  async deleteLog(logId, reason) {
+    return await api.delete(`/agentclient_logs/${logId}`, { data: { reason } })
+  },
+
+  // Reverting an update puts its "before" values back; a 409 lists the fields changed since
+  async revertLog(logId) {
+    return await api.post(`/agentclient_logs/${logId}/revert`)
   }
 }
 